@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+const (
+	// 한 배치에 기록할 본문 수
+	backfillBatchBlocks = 64
+	// 본문을 받지 못했을 때 다시 시도하기까지의 시간
+	backfillRetryInterval = 30 * time.Second
+)
+
+// readBackfillHeight는 스냅샷으로 시작한 full 노드가 본문을 가진 가장 낮은 높이를 반환합니다.
+// 받을 본문이 없으면 0입니다.
+func readBackfillHeight(db storage.Reader) (int64, error) {
+	data, err := db.Get(backfillHeightKey)
+	if err == storage.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid backfill height encoding")
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// isBackfilling은 height의 정규 블록 본문을 스냅샷 복원 뒤 아직 받지 못했는지 확인합니다.
+// 가지치기로 지운 높이는 받지 않으므로 isPruned가 먼저입니다.
+func isBackfilling(db storage.Reader, height int64) bool {
+	if height <= 0 || isPruned(db, height) {
+		return false
+	}
+	backfill, err := readBackfillHeight(db)
+	return err == nil && height < backfill
+}
+
+// BackfillHeight는 스냅샷으로 시작한 full 노드가 본문을 가진 가장 낮은 높이를 반환합니다.
+// 그 아래(genesis 제외)의 본문은 아직 받지 못했으며, 받을 본문이 없으면 0입니다.
+func (chain *BlockChain) BackfillHeight() int64 {
+	backfill, _ := readBackfillHeight(chain.Database)
+	return backfill
+}
+
+// LowestBodyHeight는 genesis를 제외하고 본문을 가진 가장 낮은 높이를 반환합니다.
+// 가지치기로 지웠거나 아직 받지 못한 본문이 없으면 0입니다.
+func (chain *BlockChain) LowestBodyHeight() int64 {
+	return max(chain.PrunedHeight(), chain.BackfillHeight())
+}
+
+// Backfill은 스냅샷 복원 뒤 받지 못한 본문을 높은 높이부터 FetchBlock으로 받아 저장된 헤더와 맞춰 본 뒤 기록합니다.
+// 배치마다 받은 높이를 함께 기록하므로 중간에 멈춰도 다음에 이어서 받습니다. 가지치기로 지운 높이는 받지 않습니다.
+// 본문을 받는 동안에는 chain.Mu를 잡지 않고 기록할 때만 잡으므로, chain.Mu를 잡은 채로 부르면 안 됩니다. 받은 본문 수를 반환합니다.
+func (chain *BlockChain) Backfill() (int, error) {
+	db := chain.Database
+	backfill, err := readBackfillHeight(db)
+	if err != nil {
+		return 0, fmt.Errorf("could not read backfill height: %v", err)
+	}
+	if backfill == 0 {
+		return 0, nil
+	}
+	if chain.FetchBlock == nil {
+		return 0, fmt.Errorf("no peers to fetch block bodies from")
+	}
+	from := max(chain.PrunedHeight(), 1)
+
+	filled := 0
+	batch := db.NewBatch()
+	write := func() error {
+		chain.Mu.Lock()
+		defer chain.Mu.Unlock()
+		if err := batch.Write(); err != nil {
+			return fmt.Errorf("could not write block bodies: %v", err)
+		}
+		batch.Reset()
+		return nil
+	}
+	for height := backfill - 1; height >= from; height-- {
+		header, err := chain.GetHeaderByHeight(height)
+		if err != nil {
+			return filled, err
+		}
+		block, err := chain.FetchBlock(header.Hash, func(block *Block) error {
+			return checkBody(header, block)
+		})
+		if err != nil {
+			return filled, fmt.Errorf("could not fetch block %x at height %d: %w", []byte(header.Hash), height, err)
+		}
+		_, body := splitBlock(block)
+		batch.Put(bodyKey(block.Hash), encodeBody(body))
+		filled++
+
+		if filled%backfillBatchBlocks == 0 {
+			batch.Put(backfillHeightKey, encodeHeight(height))
+			if err := write(); err != nil {
+				return filled, err
+			}
+		}
+	}
+	batch.Delete(backfillHeightKey)
+	if err := write(); err != nil {
+		return filled, err
+	}
+	log.Info("block bodies backfilled", "count", filled, "from", from, "to", backfill-1)
+	return filled, nil
+}
+
+// RunBackfill은 스냅샷 복원 뒤 받지 못한 본문을 모두 받을 때까지 Backfill을 다시 시도합니다.
+func (chain *BlockChain) RunBackfill() {
+	for chain.BackfillHeight() > 0 {
+		if _, err := chain.Backfill(); err != nil {
+			log.Warn("block body backfill paused", "lowest", chain.BackfillHeight(), "err", err)
+			time.Sleep(backfillRetryInterval)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// newBackfillTestChain은 source의 height 스냅샷으로 시작한 full 노드 체인을 만들고, 본문을 source에게서 받도록 합니다.
+func newBackfillTestChain(t *testing.T, source *BlockChain, height int64) *BlockChain {
+	t.Helper()
+	data, sum := createTestSnapshot(t, source, height)
+	chain := newEmptyTestChain(t, 0)
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err != nil {
+		t.Fatal(err)
+	}
+	chain.FetchBlock = func(hash []byte, check func(*Block) error) (*Block, error) {
+		block, err := source.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		return &block, check(&block)
+	}
+	return chain
+}
+
+func TestBackfill(t *testing.T) {
+	source := newTestChain(t, backfillBatchBlocks+30, 0)
+	height := source.GetBestHeight() - 5
+	chain := newBackfillTestChain(t, source, height)
+
+	filled, err := chain.Backfill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filled != int(height-1) || chain.BackfillHeight() != 0 {
+		t.Fatalf("filled %d, backfill height %d", filled, chain.BackfillHeight())
+	}
+	for h := int64(1); h < height; h++ {
+		if _, err := chain.GetBlockByHeight(h); err != nil {
+			t.Fatalf("block %d after backfill: %v", h, err)
+		}
+	}
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) > 0 {
+		t.Fatalf("backfilled database has issues: %v", report.Issues)
+	}
+	if filled, err := chain.Backfill(); err != nil || filled != 0 {
+		t.Fatalf("second backfill: %d, %v", filled, err)
+	}
+}
+
+// 받지 못하면 기록한 배치까지 남기고, 다음에 그 아래부터 이어서 받음
+func TestBackfillResume(t *testing.T) {
+	source := newTestChain(t, backfillBatchBlocks+30, 0)
+	height := source.GetBestHeight()
+	chain := newBackfillTestChain(t, source, height)
+	fetch := chain.FetchBlock
+	stop := height - backfillBatchBlocks - 10
+	chain.FetchBlock = func(hash []byte, check func(*Block) error) (*Block, error) {
+		if header, _ := chain.GetHeader(hash); header.Height == stop {
+			return nil, errors.New("no peers")
+		}
+		return fetch(hash, check)
+	}
+
+	if _, err := chain.Backfill(); err == nil {
+		t.Fatal("backfill succeeded without peers")
+	}
+	resume := height - backfillBatchBlocks
+	if chain.BackfillHeight() != resume {
+		t.Fatalf("backfill height %d, want %d", chain.BackfillHeight(), resume)
+	}
+	if _, err := chain.GetBlockByHeight(resume - 1); !errors.Is(err, ErrBodyNotSynced) {
+		t.Fatalf("block below the written batch: %v", err)
+	}
+
+	chain.FetchBlock = fetch
+	if filled, err := chain.Backfill(); err != nil || filled != int(resume-1) {
+		t.Fatalf("resumed backfill: %d, %v", filled, err)
+	}
+}
+
+// 저장된 헤더와 다른 블록은 기록하지 않음
+func TestBackfillRejectsBody(t *testing.T) {
+	source := newTestChain(t, 10, 0)
+	chain := newBackfillTestChain(t, source, 10)
+	chain.FetchBlock = func(hash []byte, check func(*Block) error) (*Block, error) {
+		block, _ := source.GetBlock(hash)
+		block.Nonce = HexBytes("bad")
+		if err := check(&block); err != nil {
+			return nil, err
+		}
+		return &block, nil
+	}
+
+	if filled, err := chain.Backfill(); err == nil || filled != 0 {
+		t.Fatalf("invalid body backfilled: %d, %v", filled, err)
+	}
+	if chain.BackfillHeight() != 10 {
+		t.Fatalf("backfill height %d after a rejected body", chain.BackfillHeight())
+	}
+	if _, err := chain.RepairDatabase(); err == nil {
+		t.Fatal("repaired a database with bodies still to fetch")
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/big"
 	"strings"
 
-	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/logger"
+	"github.com/Kim-DaeHan/mining-chain/params"
 )
 
+var log = logger.New(logger.Blockchain)
+
 type HexBytes []byte
 
 // MarshalJSON implements the json.Marshaler interface for HexBytes.
@@ -61,9 +63,33 @@ type Block struct {
 	Difficulty      *big.Int
 	Miner           HexBytes
 	Validator       HexBytes
+	ExtraData       HexBytes `json:",omitempty"`
+}
+
+// Header는 블록의 요약 정보입니다.
+type Header struct {
+	Timestamp  int64
+	Hash       HexBytes
+	PrevHash   HexBytes
+	Height     int64
+	Difficulty *big.Int
+	Nonce      HexBytes
+	Miner      HexBytes
 }
 
-func CreateBlock(prevHash []byte, height int64, address string) *Block {
+func (b *Block) Header() *Header {
+	return &Header{
+		Timestamp:  b.Timestamp,
+		Hash:       b.Hash,
+		PrevHash:   b.PrevHash,
+		Height:     b.Height,
+		Difficulty: b.Difficulty,
+		Nonce:      b.Nonce,
+		Miner:      b.Miner,
+	}
+}
+
+func CreateBlock(prevHash []byte, height int64, address string, difficulty *big.Int) (*Block, error) {
 	block := &Block{
 		Timestamp:       int64(0),
 		Hash:            HexBytes{},
@@ -72,44 +98,47 @@ func CreateBlock(prevHash []byte, height int64, address string) *Block {
 		MainBlockHash:   HexBytes{},
 		Nonce:           HexBytes{},
 		Height:          height,
-		Difficulty:      big.NewInt(config.GlobalConfig.DEFAULT_DIFFICULTY.Int64()),
+		Difficulty:      new(big.Int).Set(difficulty),
 		Miner:           HexBytes(address),
 		Validator:       HexBytes(address),
 	}
 
 	pow := NewProof(block)
-	nonce := pow.Run()
+	nonce, err := pow.Run()
+	if err != nil {
+		return nil, err
+	}
 	block.Hash = HexBytes(pow.GetHash(block))
 	block.Nonce = HexBytes(nonce)
 
-	return block
+	return block, nil
 }
 
-func Genesis(address string) *Block {
-	return CreateBlock([]byte{}, 0, address)
+func Genesis(address string, p *params.ChainParams) (*Block, error) {
+	return CreateBlock([]byte{}, 0, address, p.InitialDifficulty)
 }
 
-func (b *Block) Serialize() []byte {
-	data, err := json.Marshal(b)
-	if err != nil {
-		log.Panic(err)
-	}
-	return data
+// Serialize는 저장과 전송에 쓰는 바이너리 형식으로 블록을 인코딩합니다. JSON은 RPC 응답에만 사용합니다.
+func (b *Block) Serialize() ([]byte, error) {
+	return encodeBlock(b)
 }
 
-func Deserialize(data []byte) *Block {
-	var block Block
-	err := json.Unmarshal(data, &block)
-	if err != nil {
-		log.Panic(err)
+// Deserialize는 Serialize로 인코딩된 블록을 디코딩합니다.
+// 이전 버전 노드가 보낸 JSON 블록도 받아들입니다.
+func Deserialize(data []byte) (*Block, error) {
+	if len(data) > 0 && data[0] == '{' {
+		var block Block
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, fmt.Errorf("could not decode block: %v", err)
+		}
+		return &block, nil
 	}
-	return &block
-}
 
-func Handle(err error) {
+	block, err := decodeBlock(data)
 	if err != nil {
-		log.Panic(err)
+		return nil, fmt.Errorf("could not decode block: %v", err)
 	}
+	return block, nil
 }
 
 func DefaultBlock() *Block {
@@ -140,6 +169,9 @@ func (b Block) String() string {
 	lines = append(lines, fmt.Sprintf("Difficulty:  %d", b.Difficulty))
 	lines = append(lines, fmt.Sprintf("Miner:  %x", b.Miner))
 	lines = append(lines, fmt.Sprintf("Validator:  %x", b.Validator))
+	if len(b.ExtraData) > 0 {
+		lines = append(lines, fmt.Sprintf("ExtraData:  %x", b.ExtraData))
+	}
 	lines = append(lines, fmt.Sprintln())
 
 	// 모든 정보를 개행 문자로 구분하여 하나의 문자열로 결합
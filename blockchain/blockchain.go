@@ -1,45 +1,128 @@
 package blockchain
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"os"
-	"runtime"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Kim-DaeHan/mining-chain/config"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/Kim-DaeHan/mining-chain/event"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
+	"github.com/Kim-DaeHan/mining-chain/params"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+	"github.com/Kim-DaeHan/mining-chain/utils"
+)
+
+// ErrBlockNotFound는 요청한 블록이 데이터베이스에 없을 때 반환됩니다.
+var ErrBlockNotFound = errors.New("block not found")
+
+// ErrBlockPruned는 pruned 노드가 본문을 지운 오래된 블록을 요청했을 때 반환됩니다.
+var ErrBlockPruned = errors.New("block pruned")
+
+// ErrBodyNotSynced는 스냅샷으로 시작한 full 노드가 아직 peer에게서 받지 못한 본문의 블록을 요청했을 때 반환됩니다.
+var ErrBodyNotSynced = errors.New("block body not synced yet")
+
+// ImportBlock이 블록을 거부할 때 반환하는 오류
+var (
+	ErrKnownBlock       = errors.New("block already known")
+	ErrInvalidHeight    = errors.New("block height is not above the chain tip")
+	ErrPrevHashMismatch = errors.New("block does not extend the chain tip")
 )
 
 const (
-	dbPath      = "./tmp/blocks_%s"
-	genesisData = "First Proof from Genesis"
+	dbDirFormat  = "blocks_%s"
+	lockFileName = "node.lock"
+	genesisData  = "First Proof from Genesis"
 )
 
 type BlockChain struct {
 	ChainId      string
 	LastHash     []byte
-	Database     *leveldb.DB
+	Database     storage.Store
 	CurrentBlock *Block
+	Params       *params.ChainParams
+	Path         string // 데이터베이스의 절대 경로
 	Mu           sync.Mutex
+
+	HeadFeed  event.Feed[ChainHeadEvent]
+	ReorgFeed event.Feed[ChainReorgEvent]
+
+	// FetchBlock은 light 노드나 본문을 채우는 노드(Backfill)가 저장하지 않은 블록을 peer에게서 받는 함수입니다. check를 통과한 블록만 반환해야 합니다.
+	FetchBlock func(hash []byte, check func(*Block) error) (*Block, error)
+
+	head  atomic.Pointer[Block] // 메모리의 tip. 비어있으면 데이터베이스에서 읽음
+	cache *blockCache
+	light bool // 헤더만 저장하는 light 노드
+	lock  *utils.FileLock
+}
+
+// DBPath는 설정된 데이터 디렉터리 안의 체인 데이터베이스 경로를 반환합니다.
+func DBPath(chainId string) string {
+	return filepath.Join(config.GlobalConfig.DataDir, fmt.Sprintf(dbDirFormat, chainId))
+}
+
+// openDatabase는 데이터 디렉터리를 잠근 뒤 설정된 백엔드(dbEngine)로 저장소를 엽니다.
+// 같은 데이터 디렉터리를 사용하는 두 번째 프로세스는 잠금에서 실패합니다.
+func openDatabase(path string) (storage.Store, *utils.FileLock, error) {
+	dataDir := filepath.Dir(path)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := utils.LockFile(filepath.Join(dataDir, lockFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := storage.Open(config.GlobalConfig.DBEngine, path)
+	if err != nil {
+		lock.Release()
+		return nil, nil, err
+	}
+	return db, lock, nil
 }
 
-func (chain *BlockChain) GetBlocksInRange(startHeight, endHeight int64) [][]byte {
+// Close는 데이터베이스를 닫고 데이터 디렉터리 잠금을 해제합니다.
+func (chain *BlockChain) Close() error {
+	err := chain.Database.Close()
+	chain.lock.Release()
+	return err
+}
+
+func (chain *BlockChain) GetBlocksInRange(startHeight, endHeight int64) ([][]byte, error) {
 	var blocks [][]byte
 	iter := chain.NewIterator()
 
 	for {
-		block := iter.NextBlock()
+		block, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
 		if block == nil || block.Height < startHeight {
 			break
 		}
 		if block.Height <= endHeight {
-			blocks = append(blocks, block.Serialize())
+			data, err := block.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, data)
+		}
+		// 본문이 없을 수 있는 startHeight 아래 블록은 읽지 않음
+		if block.Height <= startHeight {
+			break
 		}
 	}
-	return blocks
+	return blocks, nil
 }
 
 func (chain *BlockChain) GetExpectedBestHeight() int64 {
@@ -48,98 +131,157 @@ func (chain *BlockChain) GetExpectedBestHeight() int64 {
 	return lastBlock.Height
 }
 
-func (chain *BlockChain) AddBlock(block *Block) {
+// ImportBlock은 블록을 검증한 뒤 현재 tip 위에 추가합니다. 직접 채굴한 블록도 같은 검증을 거칩니다.
+// 이미 있는 블록이나 tip을 잇지 않는 블록은 ErrKnownBlock, ErrInvalidHeight, ErrPrevHashMismatch로 거부합니다.
+func (chain *BlockChain) ImportBlock(block *Block) error {
 	db := chain.Database
 
-	_, err := db.Get(block.Hash, nil)
-
-	if err == nil {
-		return
+	known, err := hasBlock(db, block.Hash)
+	if err != nil {
+		return fmt.Errorf("could not read block %x: %v", block.Hash, err)
+	} else if known {
+		metrics.BlocksRejected.WithLabelValues("duplicate").Inc()
+		return ErrKnownBlock
 	}
 
-	lastHash, err := db.Get([]byte("lh"), nil)
-	Handle(err)
-
-	item, err := db.Get(lastHash, nil)
-	Handle(err)
-	lastBlock := Deserialize(item)
+	lastHash, err := chain.GetLastBlockHash()
+	if err != nil {
+		return err
+	}
+	lastBlock, err := chain.GetHeader(lastHash)
+	if err != nil {
+		return fmt.Errorf("could not read chain tip: %w", err)
+	}
 
 	// 블록 높이 검증
 	if block.Height <= lastBlock.Height {
-		fmt.Println("블록 높이 검증 실패")
-		return
+		log.Warn("블록 높이 검증 실패", "height", block.Height, "tip", lastBlock.Height, "hash", fmt.Sprintf("%x", block.Hash))
+		metrics.BlocksRejected.WithLabelValues("height").Inc()
+		return ErrInvalidHeight
 	}
 
 	// 추가할려는 블록의 이전해시랑 현재 체인의 마지막 블록 해시랑 같은지 검증
 	if string(block.PrevHash) != string(lastBlock.Hash) {
-		fmt.Println("블록 해시 검증 실패")
-		return
+		log.Warn("블록 해시 검증 실패", "height", block.Height, "prevHash", fmt.Sprintf("%x", block.PrevHash), "tip", fmt.Sprintf("%x", lastBlock.Hash))
+		metrics.BlocksRejected.WithLabelValues("prev_hash").Inc()
+		return ErrPrevHashMismatch
 	}
 
-	batch := new(leveldb.Batch)
-	blockData := block.Serialize()
-
-	fmt.Println("Serialize block is added", string(blockData))
-
-	batch.Put(block.Hash, blockData)
-	batch.Put([]byte("lh"), block.Hash)
+	// light 노드는 본문을 버리므로 저장하기 전에 작업증명과 난이도를 검증해야 함
+	if err := chain.VerifyBlock(block, joinBlock(lastBlock, &blockBody{})); err != nil {
+		log.Warn("invalid block", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash), "err", err)
+		metrics.BlocksRejected.WithLabelValues("invalid").Inc()
+		return err
+	}
 
-	// 블록 높이를 키로 블록 해시 저장
-	heightKey := []byte(fmt.Sprintf("height-%d", block.Height))
-	batch.Put(heightKey, block.Hash)
+	batch := db.NewBatch()
+	if err := chain.putBlock(batch, block); err != nil {
+		return err
+	}
+	writeCanonical(batch, block)
 
-	err = db.Write(batch, nil)
-	Handle(err)
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("could not write block %x: %v", block.Hash, err)
+	}
 
-	chain.CurrentBlock = block
-	chain.LastHash = block.Hash
+	chain.setHead(block)
+	metrics.BlocksAccepted.Inc()
+	observeHead(block)
+	chain.HeadFeed.Send(ChainHeadEvent{Block: block})
+	log.Debug("block added", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash), "miner", string(block.Miner))
+	return nil
 }
 
 func (chain *BlockChain) GetBlockByHeight(height int64) (*Block, error) {
-	db := chain.Database
+	if hash := chain.cache.canonicalHash(height); hash != nil {
+		if block := chain.cache.block(hash); block != nil {
+			b := *block
+			return &b, nil
+		}
+	}
 
 	// 블록 높이로 해시를 가져오기
-	heightKey := []byte(fmt.Sprintf("height-%d", height))
-	blockHash, err := db.Get(heightKey, nil)
+	blockHash, err := readCanonicalHash(chain.Database, height)
 	if err != nil {
-		return nil, fmt.Errorf("block with height %d not found: %v", height, err)
+		return nil, err
 	}
 
 	// 해시로 블록 데이터를 가져오기
-	blockData, err := db.Get(blockHash, nil)
+	block, err := chain.loadBlock(blockHash)
 	if err != nil {
-		return nil, fmt.Errorf("block data for hash %x not found: %v", blockHash, err)
+		return nil, err
 	}
-
-	block := Deserialize(blockData)
-	return block, nil
+	chain.cache.addCanonical(block)
+	b := *block
+	return &b, nil
 }
 
-func (chain *BlockChain) GetLastBlockHash() []byte {
-	db := chain.Database
-	lasthash, err := db.Get([]byte("lh"), nil)
+// GetHeaderByHeight는 정규 체인에서 height의 블록 헤더를 반환합니다. 본문을 지운 블록의 헤더도 읽을 수 있습니다.
+func (chain *BlockChain) GetHeaderByHeight(height int64) (*Header, error) {
+	if hash := chain.cache.canonicalHash(height); hash != nil {
+		if block := chain.cache.block(hash); block != nil {
+			return block.Header(), nil
+		}
+	}
+
+	blockHash, err := readCanonicalHash(chain.Database, height)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
+	}
+	return readHeader(chain.Database, blockHash)
+}
+
+// GetHeader는 해시로 블록 헤더를 반환합니다. light 노드에서도 peer에게 요청하지 않고 읽을 수 있습니다.
+func (chain *BlockChain) GetHeader(hash []byte) (*Header, error) {
+	if block := chain.cache.block(hash); block != nil {
+		return block.Header(), nil
 	}
+	return readHeader(chain.Database, hash)
+}
 
-	return lasthash
+// GetLastBlockHash는 tip 블록의 해시를 반환합니다. 아직 블록이 없으면 ErrBlockNotFound를 반환합니다.
+func (chain *BlockChain) GetLastBlockHash() ([]byte, error) {
+	lasthash, err := chain.Database.Get(lastHashKey)
+	if err == storage.ErrNotFound {
+		return nil, fmt.Errorf("%w: no last block hash", ErrBlockNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read last block hash: %v", err)
+	}
+	return lasthash, nil
 }
 
+// GetLastBlock은 tip 블록을 반환합니다. 읽을 수 없으면 DefaultBlock을 반환합니다.
 func (chain *BlockChain) GetLastBlock() *Block {
-	db := chain.Database
-	var lastBlock *Block
-	lasthash, err := db.Get([]byte("lh"), nil)
+	if head := chain.head.Load(); head != nil {
+		b := *head
+		return &b
+	}
+
+	lasthash, err := chain.GetLastBlockHash()
 	if err != nil {
 		return DefaultBlock()
 	}
 
-	item, err := db.Get(lasthash, nil)
+	var lastBlock Block
+	if chain.light {
+		// light 노드는 tip의 헤더만 읽음
+		var header *Header
+		if header, err = chain.GetHeader(lasthash); err == nil {
+			lastBlock = *joinBlock(header, &blockBody{})
+		}
+	} else {
+		lastBlock, err = chain.GetBlock(lasthash)
+	}
 	if err != nil {
+		if !errors.Is(err, ErrBlockNotFound) {
+			log.Error("could not read last block", "hash", fmt.Sprintf("%x", lasthash), "err", err)
+		}
 		return DefaultBlock()
 	}
-
-	lastBlock = Deserialize(item)
-	return lastBlock
+	// 그 사이에 새 tip이 기록되었으면 덮어쓰지 않음
+	head := lastBlock
+	chain.head.CompareAndSwap(nil, &head)
+	return &lastBlock
 }
 
 func (chain *BlockChain) GetBestHeight() int64 {
@@ -147,13 +289,16 @@ func (chain *BlockChain) GetBestHeight() int64 {
 }
 
 func (chain *BlockChain) GetBlock(blockhash []byte) (Block, error) {
-	db := chain.Database
-	var block Block
-
-	item, err := db.Get(blockhash, nil)
-	block = *Deserialize(item)
+	if block := chain.cache.block(blockhash); block != nil {
+		return *block, nil
+	}
 
-	return block, err
+	block, err := chain.loadBlock(blockhash)
+	if err != nil {
+		return Block{}, err
+	}
+	chain.cache.addBlock(block)
+	return *block, nil
 }
 
 func (chain *BlockChain) GetBlockHashes() [][]byte {
@@ -174,48 +319,85 @@ func (chain *BlockChain) GetBlockHashes() [][]byte {
 	return blocks
 }
 
-func (chain *BlockChain) GetBlockList() []Block {
-	var blocks []Block
-	iter := chain.NewIterator()
-
-	for {
-		block := iter.NextBlock()
-		blocks = append(blocks, *block)
+// GetBlockList는 from부터 to까지(포함) 높이 순서대로 최대 limit개의 블록을 반환합니다.
+// to가 현재 높이보다 크면 현재 높이까지만 반환합니다.
+func (chain *BlockChain) GetBlockList(from, to int64, limit int) []*Block {
+	var blocks []*Block
+	if from < 0 {
+		from = 0
+	}
+	if best := chain.GetBestHeight(); to > best {
+		to = best
+	}
 
-		// 마지막 블록(이전 해시가 없는 블록)에 도달하면 반복 종료
-		if len(block.PrevHash) == 0 {
+	for height := from; height <= to && len(blocks) < limit; height++ {
+		block, err := chain.GetBlockByHeight(height)
+		if err != nil {
 			break
 		}
+		blocks = append(blocks, block)
 	}
 	return blocks
 }
 
-func (chain *BlockChain) Difficulty(height int64) *big.Int {
-	Config := config.GlobalConfig
+// GetBlocksByMiner는 before 높이부터 아래로 내려가며 miner가 채굴한 블록을 최대 limit개 찾습니다.
+// 한 번에 최대 maxScan개의 블록만 살펴보며, next는 다음 검색을 시작할 높이입니다(끝이면 -1).
+func (chain *BlockChain) GetBlocksByMiner(miner []byte, before int64, limit, maxScan int) (blocks []*Block, next int64) {
+	if best := chain.GetBestHeight(); before > best {
+		before = best
+	}
+
+	height := before
+	for scanned := 0; height >= 0 && len(blocks) < limit && scanned < maxScan; scanned++ {
+		block, err := chain.GetBlockByHeight(height)
+		if err != nil {
+			return blocks, -1
+		}
+		if bytes.Equal(block.Miner, miner) {
+			blocks = append(blocks, block)
+		}
+		height--
+	}
+	return blocks, height
+}
+
+func (chain *BlockChain) Difficulty(height int64) (*big.Int, error) {
+	return calcDifficulty(chain.Params, height, chain.GetHeaderByHeight)
+}
+
+// calcDifficulty는 headerAt이 돌려주는 체인의 이전 블록 헤더로 height의 난이도를 계산합니다.
+func calcDifficulty(p *params.ChainParams, height int64, headerAt func(int64) (*Header, error)) (*big.Int, error) {
+	rules := p.RulesAt(height)
 
-	if height < (Config.DIFFICULTY_CHANGE_CYCLE + 1) {
-		return new(big.Int).Set(&Config.DEFAULT_DIFFICULTY)
-	} else if height%Config.DIFFICULTY_CHANGE_CYCLE != 1 {
-		block, err := chain.GetBlockByHeight(height - 1)
-		Handle(err)
-		return block.Difficulty
+	if height < (rules.DifficultyChangeCycle + 1) {
+		return new(big.Int).Set(p.InitialDifficulty), nil
+	} else if height%rules.DifficultyChangeCycle != 1 {
+		block, err := headerAt(height - 1)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Set(block.Difficulty), nil
 	}
 
-	endBlock, err := chain.GetBlockByHeight(height - 1)
-	Handle(err)
-	startBlock, err := chain.GetBlockByHeight(height - Config.DIFFICULTY_CHANGE_CYCLE - 1)
-	Handle(err)
+	endBlock, err := headerAt(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	startBlock, err := headerAt(height - rules.DifficultyChangeCycle - 1)
+	if err != nil {
+		return nil, err
+	}
 	lastDifficulty := endBlock.Difficulty
 
 	gap := endBlock.Timestamp - startBlock.Timestamp
-	standardGap := Config.RESOURCE_INTERVAL * Config.DIFFICULTY_CHANGE_CYCLE
+	standardGap := rules.ResourceInterval * rules.DifficultyChangeCycle
 
 	weight := float64(standardGap) / float64(gap)
-	if weight > Config.MAX_DIFFICULTY_WEIGHT {
-		weight = Config.MAX_DIFFICULTY_WEIGHT
+	if weight > rules.MaxDifficultyWeight {
+		weight = rules.MaxDifficultyWeight
 	}
-	if weight < Config.MIN_DIFFICULTY_WEIGHT {
-		weight = Config.MIN_DIFFICULTY_WEIGHT
+	if weight < rules.MinDifficultyWeight {
+		weight = rules.MinDifficultyWeight
 	}
 
 	// 난이도 계산
@@ -226,94 +408,401 @@ func (chain *BlockChain) Difficulty(height int64) *big.Int {
 	resultDifficulty, _ := difficultyFloat.Int(nil)
 
 	if resultDifficulty.Cmp(big.NewInt(1)) <= 0 {
-		return big.NewInt(1)
+		return big.NewInt(1), nil
+	}
+
+	return resultDifficulty, nil
+}
+
+func InitBlockChain(address, chainId string) (*BlockChain, error) {
+	p, err := defaultChainParams()
+	if err != nil {
+		return nil, err
 	}
 
-	return resultDifficulty
+	genesis, err := Genesis(address, p)
+	if err != nil {
+		return nil, err
+	}
+	return initBlockChain(chainId, genesis, p, nil)
 }
 
-func InitBlockChain(address, chainId string) *BlockChain {
-	fmt.Printf("init blockchain path : %s\n", chainId)
+// InitBlockChainFromGenesis는 genesis 파일로 체인을 초기화합니다.
+// 같은 spec이면 어느 노드에서든 바이트 단위로 동일한 genesis 블록이 만들어집니다.
+func InitBlockChainFromGenesis(spec *GenesisSpec) (*BlockChain, error) {
+	p, err := spec.ChainParams()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocalParams(p); err != nil {
+		return nil, err
+	}
 
-	path := fmt.Sprintf(dbPath, chainId)
-	fmt.Printf("init blockchain path : %s\n", path)
-	if DBexists(path) {
-		// err := os.RemoveAll(path)
-		// if err != nil {
-		// 	log.Fatalf("Failed to delete existing blockchain data: %v", err)
-		// }
-		fmt.Println("Blockchain already exists")
-		runtime.Goexit()
+	genesis, err := spec.ToBlock()
+	if err != nil {
+		return nil, err
 	}
 
-	var lastHash []byte
+	return initBlockChain(strconv.Itoa(spec.ChainId), genesis, p, spec)
+}
 
-	db, err := leveldb.OpenFile(path, nil)
-	Handle(err)
-	batch := new(leveldb.Batch)
+func initBlockChain(chainId string, genesis *Block, p *params.ChainParams, spec *GenesisSpec) (*BlockChain, error) {
+	path := DBPath(chainId)
+	log.Info("init blockchain", "chainId", chainId, "path", path)
+	if DBexists(path) {
+		return nil, fmt.Errorf("blockchain already exists: %s", path)
+	}
 
-	genesis := Genesis(address)
+	paramsData, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var specData []byte
+	if spec != nil {
+		if specData, err = json.Marshal(spec); err != nil {
+			return nil, err
+		}
+	}
 
-	fmt.Printf("genesis hash :%v\n", string(genesis.Serialize()))
-	batch.Put(genesis.Hash, genesis.Serialize())
-	batch.Put([]byte("lh"), genesis.Hash)
-	heightKey := []byte(fmt.Sprintf("height-%d", genesis.Height))
-	batch.Put(heightKey, genesis.Hash)
+	db, lock, err := openDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %v", err)
+	}
+	light, err := checkNodeType(db)
+	if err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
 
-	err = db.Write(batch, nil)
-	Handle(err)
-	lastHash = genesis.Hash
+	batch := db.NewBatch()
+	log.Info("genesis block", "hash", fmt.Sprintf("%x", genesis.Hash))
+	log.Debug("genesis block", "block", genesis.String())
+	if err := writeBlock(batch, genesis); err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
+	writeCanonical(batch, genesis)
+	batch.Put(genesisHashKey, genesis.Hash)
+	writeSchemaVersion(batch)
+	batch.Put(chainParamsKey, paramsData)
+	if specData != nil {
+		batch.Put(genesisSpecKey, specData)
+	}
+	if err := batch.Write(); err != nil {
+		db.Close()
+		lock.Release()
+		return nil, fmt.Errorf("could not write genesis block: %v", err)
+	}
+	lastHash := genesis.Hash
 
+	absPath, _ := filepath.Abs(path)
 	chain := BlockChain{
+		ChainId:  chainId,
 		LastHash: lastHash,
 		Database: db,
+		Params:   p,
+		Path:     absPath,
+		cache:    newBlockCache(config.GlobalConfig.BlockCache),
+		light:    light,
+		lock:     lock,
 	}
-	return &chain
+	return &chain, nil
 }
 
 func DBexists(path string) bool {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return false
-	}
-	return true
+	return storage.Exists(config.GlobalConfig.DBEngine, path)
 }
 
-func ContinueBlockChain(chainId string) *BlockChain {
-	path := fmt.Sprintf(dbPath, chainId)
-	fmt.Printf("blockchain Path : %s\n", path)
+func ContinueBlockChain(chainId string) (*BlockChain, error) {
+	path := DBPath(chainId)
+	log.Info("open blockchain", "path", path)
 	if !DBexists(path) {
-		fmt.Println("No existing blockchain found, create one!")
-		runtime.Goexit()
+		return nil, fmt.Errorf("no existing blockchain found at %s, create one first", path)
 	}
 
-	var lastHash []byte
+	db, lock, err := openDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %v", err)
+	}
 
-	db, err := leveldb.OpenFile(path, nil)
-	Handle(err)
+	if err := migrateSchema(db); err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
+	if err := finishInterrupted(db); err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
 
-	lastHash, _ = db.Get([]byte("lh"), nil)
-	Handle(err)
+	light, err := checkNodeType(db)
+	if err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
 
+	// 초기화했거나 중간에 멈춘 스냅샷 복원을 지운 데이터베이스에는 아직 tip이 없음
+	lastHash, err := db.Get(lastHashKey)
+	if err != nil && err != storage.ErrNotFound {
+		db.Close()
+		lock.Release()
+		return nil, fmt.Errorf("could not read last block hash: %v", err)
+	}
+
+	absPath, _ := filepath.Abs(path)
 	chain := BlockChain{
 		ChainId:  chainId,
 		LastHash: lastHash,
 		Database: db,
+		Path:     absPath,
+		cache:    newBlockCache(config.GlobalConfig.BlockCache),
+		light:    light,
+		lock:     lock,
+	}
+
+	err = chain.checkGenesis()
+	if err == nil {
+		err = chain.loadChainParams()
+	}
+	if err != nil {
+		chain.Close()
+		return nil, err
+	}
+	observeHead(chain.GetLastBlock())
+
+	return &chain, nil
+}
+
+// checkGenesis는 저장된 genesis 해시가 높이 0 블록, 설정의 genesisHash와 일치하는지 확인합니다.
+// genesis 해시가 저장되지 않은 기존 데이터베이스는 높이 0 블록 해시로 채워 넣습니다.
+func (chain *BlockChain) checkGenesis() error {
+	db := chain.Database
+
+	blockHash, err := readCanonicalHash(db, 0)
+	if err != nil {
+		// 초기화했거나 중간에 멈춘 스냅샷 복원을 지운 데이터베이스에는 아직 genesis가 없음
+		return nil
+	}
+
+	stored, err := db.Get(genesisHashKey)
+	if err == storage.ErrNotFound {
+		stored = blockHash
+		if err := db.Put(genesisHashKey, stored); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(stored, blockHash) {
+		return fmt.Errorf("genesis mismatch: stored %x, block at height 0 is %x", stored, blockHash)
 	}
 
-	return &chain
+	if expected := config.GlobalConfig.GenesisHash; expected != "" && expected != hex.EncodeToString(stored) {
+		return fmt.Errorf("genesis mismatch: database has %x, config expects %s", stored, expected)
+	}
+
+	return nil
 }
 
-func (chain *BlockChain) ResetDatabase() {
+// GetGenesisHash는 데이터베이스에 저장된 genesis 해시를 반환합니다.
+func (chain *BlockChain) GetGenesisHash() []byte {
+	hash, err := chain.Database.Get(genesisHashKey)
+	if err != nil {
+		return nil
+	}
+	return hash
+}
+
+// WriteGenesis는 동기화로 받은 genesis 블록을 저장합니다.
+// 저장된 genesis 해시, 설정의 genesisHash, 저장된 genesis spec으로 만든 블록 순서로 기대하는 해시를 정하며
+// 이와 다르거나 해시가 내용과 맞지 않는 블록은 거부합니다. 기대하는 해시가 없으면 어느 peer의 genesis도 믿지 않습니다.
+func (chain *BlockChain) WriteGenesis(block *Block) error {
 	db := chain.Database
-	iter := db.NewIterator(nil, nil)
 
+	expected, specBlock, err := chain.expectedGenesis()
+	if err != nil {
+		return err
+	}
+	if expected == nil && specBlock != nil {
+		expected = specBlock.Hash
+	}
+	if expected == nil {
+		return fmt.Errorf("no genesis hash to check genesis %x against, set genesisHash in config", block.Hash)
+	}
+	if !bytes.Equal(expected, block.Hash) {
+		metrics.BlocksRejected.WithLabelValues("genesis").Inc()
+		return fmt.Errorf("genesis mismatch: expected %x, got %x", expected, block.Hash)
+	}
+	if block.Height != 0 {
+		return fmt.Errorf("genesis %x has height %d", block.Hash, block.Height)
+	}
+	if err := VerifyPoW(block); err != nil {
+		metrics.BlocksRejected.WithLabelValues("genesis").Inc()
+		return err
+	}
+	// 블록 해시는 본문을 덮지 않으므로 spec이 있으면 블록 전체를 비교
+	if specBlock != nil {
+		got, err := block.Serialize()
+		if err != nil {
+			return err
+		}
+		want, err := specBlock.Serialize()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			metrics.BlocksRejected.WithLabelValues("genesis").Inc()
+			return fmt.Errorf("genesis mismatch: block %x differs from the genesis spec", block.Hash)
+		}
+	}
+
+	batch := db.NewBatch()
+	if err := writeBlock(batch, block); err != nil {
+		return err
+	}
+	writeCanonical(batch, block)
+	batch.Put(genesisHashKey, block.Hash)
+
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	chain.setHead(block)
+	metrics.BlocksAccepted.Inc()
+	observeHead(block)
+	chain.HeadFeed.Send(ChainHeadEvent{Block: block})
+	return nil
+}
+
+// expectedGenesis는 WriteGenesis가 받아들일 genesis 해시를 반환하며, 저장된 genesis spec이 있으면 spec으로 만든 블록도 반환합니다.
+// 저장된 genesis 해시나 설정의 genesisHash가 spec보다 먼저입니다. 정할 수 없으면 nil입니다.
+func (chain *BlockChain) expectedGenesis() ([]byte, *Block, error) {
+	data, err := chain.Database.Get(genesisSpecKey)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, nil, err
+	}
+	var specBlock *Block
+	if err == nil {
+		var spec GenesisSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, nil, fmt.Errorf("invalid stored genesis spec: %v", err)
+		}
+		if specBlock, err = spec.ToBlock(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if hash := chain.GetGenesisHash(); hash != nil {
+		return hash, specBlock, nil
+	}
+	if config.GlobalConfig.GenesisHash != "" {
+		decoded, err := hex.DecodeString(config.GlobalConfig.GenesisHash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid genesisHash in config: %v", err)
+		}
+		return decoded, specBlock, nil
+	}
+	return nil, specBlock, nil
+}
+
+// setHead는 block을 새 tip으로 기억하고 캐시합니다.
+func (chain *BlockChain) setHead(block *Block) {
+	chain.CurrentBlock = block
+	chain.LastHash = block.Hash
+	chain.head.Store(block)
+	chain.cache.addCanonical(block)
+}
+
+// observeHead는 체인 tip 지표를 갱신합니다.
+func observeHead(block *Block) {
+	difficulty, _ := new(big.Float).SetInt(block.Difficulty).Float64()
+	metrics.SetHead(block.Height, block.Timestamp, difficulty)
+}
+
+// ResetDatabase는 블록과 색인을 모두 지워 빈 체인으로 만듭니다.
+// 첫 배치에서 tip을 지우고 초기화 표시를 남기므로 그 뒤로는 빈 체인으로 보이며,
+// 나머지 키를 지우는 중에 멈추면 다음에 데이터베이스를 열 때 이어서 지웁니다.
+func (chain *BlockChain) ResetDatabase() error {
+	oldHead := chain.GetLastBlock()
+
+	batch := chain.Database.NewBatch()
+	batch.Put(resetPendingKey, []byte{1})
+	batch.Delete(lastHashKey)
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("could not reset database: %v", err)
+	}
+	chain.CurrentBlock = nil
+	chain.LastHash = nil
+	chain.head.Store(nil)
+	chain.cache.purge()
+	if err := resetDatabase(chain.Database, resetPendingKey); err != nil {
+		return fmt.Errorf("could not reset database: %v", err)
+	}
+
+	log.Info("로컬 데이터베이스 초기화 완료")
+	chain.ReorgFeed.Send(ChainReorgEvent{OldHeight: oldHead.Height, OldHash: oldHead.Hash})
+	return nil
+}
+
+// finishInterrupted는 중간에 멈춘 초기화를 마치고, 중간에 멈춘 스냅샷 복원이 절반만 기록한 체인을 지웁니다.
+func finishInterrupted(db storage.Store) error {
+	if pending, err := db.Has(resetPendingKey); err == nil && pending {
+		log.Info("resuming interrupted database reset")
+		if err := resetDatabase(db, resetPendingKey); err != nil {
+			return fmt.Errorf("could not finish database reset: %v", err)
+		}
+	}
+	if pending, err := db.Has(restorePendingKey); err == nil && pending {
+		log.Warn("snapshot restore was interrupted, removing the partially restored chain")
+		if err := resetDatabase(db, restorePendingKey); err != nil {
+			return fmt.Errorf("could not remove partially restored snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+// resetDatabase는 유지할 키를 빼고 모두 배치로 나눠 지운 뒤, 마지막 배치에서 pending 표시를 지웁니다.
+func resetDatabase(db storage.Store, pending []byte) error {
+	batch := db.NewBatch()
+	iter := db.NewIterator(nil)
 	for iter.Next() {
 		key := iter.Key()
-		db.Delete(key, nil)
+		if keepOnReset(key) {
+			continue
+		}
+		batch.Delete(append([]byte{}, key...))
+		if batch.Len() >= migrationBatchWrites {
+			if err := batch.Write(); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+		}
 	}
-
+	err := iter.Error()
 	iter.Release()
-	fmt.Println("로컬 데이터베이스 초기화 완료")
+	if err != nil {
+		return err
+	}
+
+	batch.Delete(pending)
+	return batch.Write()
+}
+
+// keepOnReset은 초기화 후에도 남길 키인지 확인합니다.
+// genesis 정보, 스키마 버전과 light 표시는 체인을 다시 받아도 바뀌지 않고, 되돌린 블록은 다시 적용할 수 있도록 남깁니다.
+func keepOnReset(key []byte) bool {
+	for _, k := range [][]byte{genesisHashKey, genesisSpecKey, chainParamsKey, schemaVersionKey, resetPendingKey, restorePendingKey, lightKey} {
+		if bytes.Equal(key, k) {
+			return true
+		}
+	}
+	return bytes.HasPrefix(key, rewoundPrefix)
 }
 
 func SortBlocksByHeight(blocks []*Block) {
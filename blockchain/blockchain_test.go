@@ -0,0 +1,311 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/params"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// testParams는 테스트 체인의 합의 파라미터입니다.
+// 블록 간격을 ResourceInterval로 맞추므로 난이도는 InitialDifficulty에서 바뀌지 않습니다.
+var testParams = params.ChainParams{
+	Name:              "test",
+	InitialDifficulty: big.NewInt(1),
+	Rules: params.Rules{
+		DifficultyChangeCycle: 10,
+		ResourceInterval:      5,
+		MaxDifficultyWeight:   4.0,
+		MinDifficultyWeight:   0.25,
+	},
+}
+
+const (
+	testMiner       = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	testGenesisTime = 1700000000
+)
+
+// sealBlock은 block의 작업증명을 찾고 해시를 채웁니다.
+func sealBlock(t testing.TB, block *Block) *Block {
+	t.Helper()
+	pow := NewProof(block)
+	nonce, err := pow.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Nonce = nonce
+	block.Hash = pow.GetHash(block)
+	return block
+}
+
+// newTestBlock은 parent 바로 위에 오는 채굴된 블록을 만듭니다. extra로 같은 높이의 다른 블록을 만들 수 있습니다.
+func newTestBlock(t testing.TB, parent *Block, extra string) *Block {
+	t.Helper()
+	block := &Block{
+		Timestamp:     parent.Timestamp + testParams.ResourceInterval,
+		Hash:          HexBytes{},
+		PrevHash:      parent.Hash,
+		MainBlockHash: HexBytes{},
+		Nonce:         HexBytes{},
+		Height:        parent.Height + 1,
+		Difficulty:    new(big.Int).Set(testParams.InitialDifficulty),
+		Miner:         HexBytes(testMiner),
+		Validator:     HexBytes(testMiner),
+	}
+	if extra != "" {
+		block.ExtraData = HexBytes(extra)
+	}
+	return sealBlock(t, block)
+}
+
+// tamperBody는 block의 본문만 바꾼 복사본을 만듭니다. 해시는 본문을 포함하지 않으므로 그대로이고,
+// 난이도 1에서는 바뀐 본문도 작업증명을 통과할 수 있으므로 통과하지 않는 본문을 고릅니다.
+func tamperBody(t testing.TB, block *Block) *Block {
+	t.Helper()
+	for i := 0; ; i++ {
+		tampered := *block
+		tampered.ExtraData = HexBytes(fmt.Sprintf("tampered-%d", i))
+		if VerifyPoW(&tampered) != nil {
+			return &tampered
+		}
+	}
+}
+
+// newEmptyTestChain은 블록 없이 테스트 파라미터만 가진 체인을 만듭니다.
+func newEmptyTestChain(t testing.TB, cacheSize int) *BlockChain {
+	t.Helper()
+	chain := &BlockChain{
+		ChainId:  "test",
+		Database: storage.NewMemory(),
+		Params:   testParams.Copy(),
+		cache:    newBlockCache(cacheSize),
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+// newTestChain은 메모리 저장소에 genesis와 blocks개의 블록을 가진 체인을 만듭니다.
+func newTestChain(t testing.TB, blocks int, cacheSize int) *BlockChain {
+	t.Helper()
+	genesis := sealBlock(t, &Block{
+		Timestamp:     testGenesisTime,
+		Hash:          HexBytes{},
+		PrevHash:      HexBytes{},
+		MainBlockHash: HexBytes{},
+		Nonce:         HexBytes{},
+		Difficulty:    new(big.Int).Set(testParams.InitialDifficulty),
+		Miner:         HexBytes(testMiner),
+		Validator:     HexBytes(testMiner),
+	})
+	chain := newTestChainFrom(t, genesis, cacheSize)
+	extendTestChain(t, chain, blocks)
+	return chain
+}
+
+// newTestChainFrom은 genesis만 가진 테스트 체인을 만듭니다.
+func newTestChainFrom(t testing.TB, genesis *Block, cacheSize int) *BlockChain {
+	t.Helper()
+	chain := newEmptyTestChain(t, cacheSize)
+	// 설정에 genesisHash를 둔 노드처럼 받을 genesis 해시를 먼저 기록
+	if err := chain.Database.Put(genesisHashKey, genesis.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.WriteGenesis(genesis); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+// extendTestChain은 tip 위에 n개의 블록을 추가하고 추가한 블록을 반환합니다.
+func extendTestChain(t testing.TB, chain *BlockChain, n int) []*Block {
+	t.Helper()
+	var added []*Block
+	for i := 0; i < n; i++ {
+		block := newTestBlock(t, chain.GetLastBlock(), "")
+		if err := chain.ImportBlock(block); err != nil {
+			t.Fatalf("import block %d: %v", block.Height, err)
+		}
+		added = append(added, block)
+	}
+	return added
+}
+
+// tip을 잇지 않는 블록은 검증하기 전에 거부
+func TestImportBlockRejectsOffTip(t *testing.T) {
+	chain := newTestChain(t, 5, 0)
+	tip := chain.GetLastBlock()
+	parent, _ := chain.GetBlockByHeight(3)
+
+	wrongPrev := newTestBlock(t, parent, "")
+	wrongPrev.Height = tip.Height + 1
+	tests := []struct {
+		name  string
+		block *Block
+		want  error
+	}{
+		{"known", tip, ErrKnownBlock},
+		{"height", newTestBlock(t, parent, "side"), ErrInvalidHeight},
+		{"prev hash", wrongPrev, ErrPrevHashMismatch},
+	}
+	for _, tt := range tests {
+		if err := chain.ImportBlock(tt.block); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if !bytes.Equal(chain.GetLastBlock().Hash, tip.Hash) {
+		t.Fatal("tip moved after rejected blocks")
+	}
+
+	// tip을 잇는 블록은 추가되고 새 tip을 알림
+	sub := chain.HeadFeed.Subscribe(1)
+	defer sub.Unsubscribe()
+	next := newTestBlock(t, tip, "")
+	if err := chain.ImportBlock(next); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-sub.Chan(); !bytes.Equal(ev.Block.Hash, next.Hash) {
+		t.Fatalf("head event for height %d", ev.Block.Height)
+	}
+	if chain.GetBestHeight() != next.Height {
+		t.Fatalf("best height %d, want %d", chain.GetBestHeight(), next.Height)
+	}
+}
+
+// ImportBlock은 추가하기 전에 난이도와 작업증명을 검사
+func TestImportBlockRejects(t *testing.T) {
+	chain := newTestChain(t, 5, 0)
+	tip := chain.GetLastBlock()
+
+	difficulty := newTestBlock(t, tip, "")
+	difficulty.Difficulty = big.NewInt(2)
+	// 작업증명은 해시를 빼고 계산하므로 해시만 바꾸면 해시 검사에서 거부
+	hash := newTestBlock(t, tip, "")
+	hash.Hash = make(HexBytes, 32)
+
+	tests := []struct {
+		name  string
+		block *Block
+		want  error
+	}{
+		{"difficulty", difficulty, ErrInvalidDifficulty},
+		{"body", tamperBody(t, newTestBlock(t, tip, "")), ErrInvalidPoW},
+		{"hash", hash, ErrInvalidHash},
+		{"height", newTestBlock(t, DefaultBlock(), ""), ErrInvalidHeight},
+	}
+	for _, tt := range tests {
+		if err := chain.ImportBlock(tt.block); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if chain.GetBestHeight() != tip.Height {
+		t.Fatal("invalid block imported")
+	}
+}
+
+// headersWithGap은 height까지 블록 간격이 gap초인 난이도 1000의 헤더를 돌려주는 headerAt을 만듭니다.
+func headersWithGap(gap int64) func(int64) (*Header, error) {
+	return func(height int64) (*Header, error) {
+		return &Header{Height: height, Timestamp: testGenesisTime + height*gap, Difficulty: big.NewInt(1000)}, nil
+	}
+}
+
+func TestCalcDifficulty(t *testing.T) {
+	p := testParams.Copy()
+	p.InitialDifficulty = big.NewInt(500)
+	// 100부터는 조정 주기가 20
+	p.Forks = []params.Fork{{Name: "slow", Height: 100, Rules: params.Rules{DifficultyChangeCycle: 20}}}
+
+	interval := testParams.ResourceInterval
+	tests := []struct {
+		name   string
+		height int64
+		gap    int64
+		want   int64
+	}{
+		{"first cycle", 10, interval, 500},
+		{"inside cycle", 15, interval / 5, 1000},
+		{"on target", 11, interval, 1000},
+		{"faster than target", 21, interval * 2 / 5, 2500},
+		{"clamped to max", 21, 0, 4000},
+		{"clamped to min", 21, interval * 10, 250},
+		{"slower with fork cycle", 101, interval * 2, 500},
+		{"old cycle after fork", 111, interval * 2, 1000},
+	}
+	for _, tt := range tests {
+		got, err := calcDifficulty(p, tt.height, headersWithGap(tt.gap))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got.Int64() != tt.want {
+			t.Errorf("%s: height %d got %v, want %d", tt.name, tt.height, got, tt.want)
+		}
+	}
+}
+
+// 캐시 없이 읽을 때와 기본 크기의 캐시로 읽을 때를 비교
+var benchCacheSizes = []int{0, config.Defaults().BlockCache}
+
+func BenchmarkGetBlockByHeight(b *testing.B) {
+	for _, size := range benchCacheSizes {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			chain := newTestChain(b, 1000, size)
+			best := chain.GetBestHeight()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := chain.GetBlockByHeight(best - int64(i)%(best+1)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 채굴할 때처럼 다음 블록의 난이도를 계산. 1000 + 1은 조정 주기의 시작이라 헤더 두 개를 읽음
+func BenchmarkCalcDifficulty(b *testing.B) {
+	for _, size := range benchCacheSizes {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			chain := newTestChain(b, 1000, size)
+			next := chain.GetBestHeight() + 1
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := calcDifficulty(chain.Params, next, chain.GetHeaderByHeight); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 같은 데이터 디렉터리를 두 노드가 함께 열지 못함
+func TestOpenDatabaseLocksDataDir(t *testing.T) {
+	saved := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = saved })
+	config.GlobalConfig.DBEngine = storage.EngineMemory
+
+	path := filepath.Join(t.TempDir(), "chain")
+	db, lock, err := openDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openDatabase(path); err == nil || !strings.Contains(err.Error(), lockFileName) {
+		t.Fatalf("second open of a locked data dir: %v", err)
+	}
+
+	db.Close()
+	lock.Release()
+	db, lock, err = openDatabase(path)
+	if err != nil {
+		t.Fatalf("open after release: %v", err)
+	}
+	db.Close()
+	lock.Release()
+}
@@ -0,0 +1,116 @@
+package blockchain
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru는 가장 오래 쓰지 않은 항목부터 버리는 고정 크기 캐시입니다.
+type lru[K comparable, V any] struct {
+	size  int
+	items map[K]*list.Element
+	order *list.List // 앞쪽이 최근에 쓴 항목
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{size: size, items: make(map[K]*list.Element), order: list.New()}
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *lru[K, V]) add(key K, value V) {
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key, value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *lru[K, V]) purge() {
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// blockCache는 해시별 블록과 높이별 정규 블록 해시를 캐시합니다.
+// 크기가 0이면 아무것도 캐시하지 않습니다.
+type blockCache struct {
+	mu       sync.Mutex
+	byHash   *lru[string, *Block]
+	byHeight *lru[int64, []byte]
+}
+
+func newBlockCache(size int) *blockCache {
+	if size <= 0 {
+		return nil
+	}
+	return &blockCache{byHash: newLRU[string, *Block](size), byHeight: newLRU[int64, []byte](size)}
+}
+
+func (c *blockCache) block(hash []byte) *Block {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block, _ := c.byHash.get(string(hash))
+	return block
+}
+
+func (c *blockCache) canonicalHash(height int64) []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, _ := c.byHeight.get(height)
+	return hash
+}
+
+func (c *blockCache) addBlock(block *Block) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byHash.add(string(block.Hash), block)
+}
+
+// addCanonical은 정규 체인의 블록을 해시와 높이로 모두 캐시합니다.
+func (c *blockCache) addCanonical(block *Block) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byHash.add(string(block.Hash), block)
+	c.byHeight.add(block.Height, block.Hash)
+}
+
+// purge는 정규 체인이 바뀌었을 때 캐시를 비웁니다.
+func (c *blockCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byHash.purge()
+	c.byHeight.purge()
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := newLRU[string, int](2)
+	c.add("a", 1)
+	c.add("b", 2)
+	// a를 읽으면 b가 가장 오래된 항목이 됨
+	if v, ok := c.get("a"); !ok || v != 1 {
+		t.Fatalf("get a: %v, %v", v, ok)
+	}
+	c.add("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b not evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("recently used a evicted")
+	}
+
+	// 있는 키를 다시 넣으면 값만 바뀌고 다른 항목을 버리지 않음
+	c.add("c", 4)
+	if v, _ := c.get("c"); v != 4 {
+		t.Fatalf("c: got %d, want 4", v)
+	}
+	if c.order.Len() != 2 || len(c.items) != 2 {
+		t.Fatalf("size %d/%d, want 2", c.order.Len(), len(c.items))
+	}
+
+	c.purge()
+	if _, ok := c.get("a"); ok || c.order.Len() != 0 {
+		t.Fatal("entries left after purge")
+	}
+	c.add("d", 5)
+	if v, ok := c.get("d"); !ok || v != 5 {
+		t.Fatal("add after purge failed")
+	}
+}
+
+// 크기가 0이면 캐시가 없고 모든 메서드가 아무것도 하지 않음
+func TestBlockCacheDisabled(t *testing.T) {
+	c := newBlockCache(0)
+	if c != nil {
+		t.Fatal("cache created with size 0")
+	}
+	block := &Block{Hash: HexBytes("hash"), Height: 1}
+	c.addCanonical(block)
+	c.purge()
+	if c.block(block.Hash) != nil || c.canonicalHash(block.Height) != nil {
+		t.Fatal("disabled cache returned a block")
+	}
+}
+
+func TestBlockCachePurgedOnRewind(t *testing.T) {
+	chain := newTestChain(t, 20, 64)
+	old, err := chain.GetBlockByHeight(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.cache.canonicalHash(15) == nil {
+		t.Fatal("block 15 not cached")
+	}
+
+	if _, err := chain.Rewind(10); err != nil {
+		t.Fatal(err)
+	}
+	if chain.cache.canonicalHash(15) != nil || chain.cache.block(old.Hash) != nil {
+		t.Fatal("rewound block still cached")
+	}
+	if _, err := chain.GetBlockByHeight(15); !errors.Is(err, ErrBlockNotFound) {
+		t.Fatalf("GetBlockByHeight(15) after rewind: %v", err)
+	}
+	if _, err := chain.GetBlock(old.Hash); !errors.Is(err, ErrBlockNotFound) {
+		t.Fatalf("GetBlock of rewound block: %v", err)
+	}
+
+	// 같은 높이에 다른 블록이 오면 그 블록을 돌려줘야 함
+	extendTestChain(t, chain, 5)
+	got, err := chain.GetBlockByHeight(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got.Hash, old.Hash) {
+		t.Fatal("GetBlockByHeight returned the rewound block")
+	}
+}
+
+func TestBlockCachePurgedOnPrune(t *testing.T) {
+	chain := newTestChain(t, 20, 64)
+	if _, err := chain.GetBlockByHeight(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := chain.Prune(10); err != nil {
+		t.Fatal(err)
+	}
+	if chain.cache.canonicalHash(5) != nil {
+		t.Fatal("pruned block still cached")
+	}
+	if _, err := chain.GetBlockByHeight(5); !errors.Is(err, ErrBlockPruned) {
+		t.Fatalf("GetBlockByHeight(5) after prune: %v", err)
+	}
+	// 헤더는 남아 있음
+	if header, err := chain.GetHeaderByHeight(5); err != nil || header.Height != 5 {
+		t.Fatalf("GetHeaderByHeight(5) after prune: %v, %v", header, err)
+	}
+}
@@ -0,0 +1,239 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// 블록 바이너리 인코딩 버전. 첫 바이트에 기록됩니다.
+//
+// 버전 1 형식:
+//
+//	version(1) timestamp(8) hash prevHash mainBlockHeight(8) mainBlockHash nonce height(8) difficulty miner validator extraData
+//
+// 정수는 8바이트 big-endian, 바이트 필드와 difficulty(big-endian 바이트)는 uvarint 길이 접두사를 붙입니다.
+// difficulty는 부호를 기록하지 않으므로 양수만 인코딩하며, 빈 difficulty는 디코딩하지 않습니다.
+const blockEncodingVersion = 1
+
+// 바이트 필드 하나의 최대 길이. 잘못된 입력으로 큰 메모리를 할당하지 않도록 제한
+const maxEncodedFieldLen = 1 << 20
+
+var errShortBuffer = errors.New("unexpected end of data")
+
+type encoder struct {
+	buf []byte
+	err error
+}
+
+func (e *encoder) int64(v int64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) bigInt(v *big.Int) {
+	if v == nil || v.Sign() <= 0 {
+		if e.err == nil {
+			e.err = fmt.Errorf("cannot encode difficulty %v, it must be positive", v)
+		}
+		return
+	}
+	e.bytes(v.Bytes())
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) int64() int64 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) < 8 {
+		d.err = errShortBuffer
+		return 0
+	}
+	v := binary.BigEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	return int64(v)
+}
+
+func (d *decoder) bytes() HexBytes {
+	if d.err != nil {
+		return nil
+	}
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 {
+		d.err = errShortBuffer
+		return nil
+	}
+	if n > maxEncodedFieldLen {
+		d.err = fmt.Errorf("field length %d exceeds limit", n)
+		return nil
+	}
+	d.data = d.data[size:]
+	if uint64(len(d.data)) < n {
+		d.err = errShortBuffer
+		return nil
+	}
+	if n == 0 {
+		return HexBytes{}
+	}
+	b := make([]byte, n)
+	copy(b, d.data)
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) bigInt() *big.Int {
+	b := d.bytes()
+	if d.err != nil {
+		return nil
+	}
+	if len(b) == 0 {
+		d.err = fmt.Errorf("empty difficulty")
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// finish는 남은 데이터가 없는지 확인하고 디코딩 오류를 반환합니다.
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("%d trailing bytes", len(d.data))
+	}
+	return d.err
+}
+
+// version은 첫 바이트의 인코딩 버전을 읽고 지원하는 버전인지 확인합니다.
+func (d *decoder) version() {
+	if len(d.data) == 0 {
+		d.err = errShortBuffer
+		return
+	}
+	if v := d.data[0]; v != blockEncodingVersion {
+		d.err = fmt.Errorf("unsupported encoding version %d", v)
+		return
+	}
+	d.data = d.data[1:]
+}
+
+// encodeBlock은 블록을 바이너리 형식으로 인코딩합니다.
+func encodeBlock(b *Block) ([]byte, error) {
+	e := &encoder{buf: make([]byte, 0, 256)}
+	e.buf = append(e.buf, blockEncodingVersion)
+	e.int64(b.Timestamp)
+	e.bytes(b.Hash)
+	e.bytes(b.PrevHash)
+	e.int64(int64(b.MainBlockHeight))
+	e.bytes(b.MainBlockHash)
+	e.bytes(b.Nonce)
+	e.int64(b.Height)
+	e.bigInt(b.Difficulty)
+	e.bytes(b.Miner)
+	e.bytes(b.Validator)
+	e.bytes(b.ExtraData)
+	return e.buf, e.err
+}
+
+func decodeBlock(data []byte) (*Block, error) {
+	d := &decoder{data: data}
+	d.version()
+	b := &Block{
+		Timestamp:       d.int64(),
+		Hash:            d.bytes(),
+		PrevHash:        d.bytes(),
+		MainBlockHeight: int(d.int64()),
+		MainBlockHash:   d.bytes(),
+		Nonce:           d.bytes(),
+		Height:          d.int64(),
+		Difficulty:      d.bigInt(),
+		Miner:           d.bytes(),
+		Validator:       d.bytes(),
+		ExtraData:       d.bytes(),
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	if len(b.ExtraData) == 0 {
+		b.ExtraData = nil
+	}
+	return b, nil
+}
+
+// encodeHeader와 encodeBody는 저장소의 헤더와 본문 값입니다. 블록과 같은 버전 바이트를 사용합니다.
+func encodeHeader(h *Header) ([]byte, error) {
+	e := &encoder{buf: make([]byte, 0, 160)}
+	e.buf = append(e.buf, blockEncodingVersion)
+	e.int64(h.Timestamp)
+	e.bytes(h.Hash)
+	e.bytes(h.PrevHash)
+	e.int64(h.Height)
+	e.bigInt(h.Difficulty)
+	e.bytes(h.Nonce)
+	e.bytes(h.Miner)
+	return e.buf, e.err
+}
+
+func decodeHeader(data []byte) (*Header, error) {
+	d := &decoder{data: data}
+	d.version()
+	h := &Header{
+		Timestamp:  d.int64(),
+		Hash:       d.bytes(),
+		PrevHash:   d.bytes(),
+		Height:     d.int64(),
+		Difficulty: d.bigInt(),
+		Nonce:      d.bytes(),
+		Miner:      d.bytes(),
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// sameHeader는 두 헤더의 인코딩이 같은지 확인합니다. 인코딩할 수 없는 헤더는 같지 않습니다.
+func sameHeader(a, b *Header) bool {
+	encodedA, err := encodeHeader(a)
+	if err != nil {
+		return false
+	}
+	encodedB, err := encodeHeader(b)
+	return err == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func encodeBody(body *blockBody) []byte {
+	e := &encoder{buf: make([]byte, 0, 64)}
+	e.buf = append(e.buf, blockEncodingVersion)
+	e.int64(int64(body.MainBlockHeight))
+	e.bytes(body.MainBlockHash)
+	e.bytes(body.Validator)
+	e.bytes(body.ExtraData)
+	return e.buf
+}
+
+func decodeBody(data []byte) (*blockBody, error) {
+	d := &decoder{data: data}
+	d.version()
+	body := &blockBody{
+		MainBlockHeight: int(d.int64()),
+		MainBlockHash:   d.bytes(),
+		Validator:       d.bytes(),
+		ExtraData:       d.bytes(),
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	if len(body.ExtraData) == 0 {
+		body.ExtraData = nil
+	}
+	return body, nil
+}
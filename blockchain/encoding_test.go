@@ -0,0 +1,242 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// sampleBlocks는 채굴된 블록과 같은 크기의 필드를 가진 블록을 만듭니다.
+func sampleBlocks(n int) []*Block {
+	fill := func(seed, size int) HexBytes {
+		b := make([]byte, size)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		// 앞 8바이트에 seed를 넣어 블록마다 다른 값
+		binary.BigEndian.PutUint64(b, uint64(seed))
+		return b
+	}
+	miner := HexBytes("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+
+	var blocks []*Block
+	prevHash := fill(0, 32)
+	for i := 0; i < n; i++ {
+		b := &Block{
+			Timestamp:     1700000000 + int64(i),
+			Hash:          fill(i+1, 32),
+			PrevHash:      prevHash,
+			MainBlockHash: HexBytes{},
+			Nonce:         fill(i, 8),
+			Height:        int64(i + 1),
+			Difficulty:    big.NewInt(1_000_000 + int64(i)),
+			Miner:         miner,
+			Validator:     miner,
+		}
+		prevHash = b.Hash
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+func TestBlockEncodingRoundTrip(t *testing.T) {
+	extra := sampleBlocks(1)[0]
+	extra.MainBlockHeight = 7
+	extra.MainBlockHash = HexBytes{1, 2, 3}
+	extra.ExtraData = HexBytes("extra")
+
+	for name, b := range map[string]*Block{"plain": sampleBlocks(1)[0], "extra": extra} {
+		data, err := b.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data[0] != blockEncodingVersion {
+			t.Fatalf("%s: version byte %d", name, data[0])
+		}
+		got, err := Deserialize(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Fatalf("%s: round trip mismatch\n got %+v\nwant %+v", name, got, b)
+		}
+	}
+}
+
+func TestHeaderBodyRoundTrip(t *testing.T) {
+	block := sampleBlocks(1)[0]
+	block.ExtraData = HexBytes("extra")
+	header, body := splitBlock(block)
+
+	encoded, err := encodeHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotHeader, err := decodeHeader(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBody, err := decodeBody(encodeBody(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := joinBlock(gotHeader, gotBody); !reflect.DeepEqual(got, block) {
+		t.Fatalf("round trip mismatch\n got %+v\nwant %+v", got, block)
+	}
+}
+
+// Deserialize는 이전 노드가 보낸 JSON 블록도 받아야 함
+func TestDeserializeJSON(t *testing.T) {
+	block := sampleBlocks(1)[0]
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Hash, block.Hash) || got.Height != block.Height || got.Difficulty.Cmp(block.Difficulty) != 0 {
+		t.Fatalf("got %+v, want %+v", got, block)
+	}
+}
+
+func TestDecodeBlockRejects(t *testing.T) {
+	data, _ := sampleBlocks(1)[0].Serialize()
+
+	// 해시 필드 길이를 한도보다 크게 바꾼 블록: 버전(1) + timestamp(8) 뒤가 해시 길이
+	oversized := binary.AppendUvarint(append([]byte{}, data[:9]...), maxEncodedFieldLen+1)
+	oversized = append(oversized, make([]byte, 64)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, errShortBuffer.Error()},
+		{"version", append([]byte{blockEncodingVersion + 1}, data[1:]...), "unsupported encoding version"},
+		{"short", data[:len(data)-3], errShortBuffer.Error()},
+		{"trailing", append(append([]byte{}, data...), 0), "trailing bytes"},
+		{"oversized", oversized, "exceeds limit"},
+	}
+	for _, tt := range tests {
+		if _, err := Deserialize(tt.data); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+
+	// 헤더와 본문도 같은 디코더를 사용
+	if _, err := decodeHeader(oversized); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("decodeHeader: got %v", err)
+	}
+}
+
+// difficulty는 부호 없이 기록하므로 양수만 인코딩하고, 빈 값은 디코딩하지 않음
+func TestEncodeDifficultyRejects(t *testing.T) {
+	for _, difficulty := range []*big.Int{nil, big.NewInt(0), big.NewInt(-5)} {
+		block := sampleBlocks(1)[0]
+		block.Difficulty = difficulty
+		if _, err := block.Serialize(); err == nil {
+			t.Errorf("block with difficulty %v encoded", difficulty)
+		}
+		if _, err := encodeHeader(block.Header()); err == nil {
+			t.Errorf("header with difficulty %v encoded", difficulty)
+		}
+		if err := writeBlock(storage.NewMemory().NewBatch(), block); err == nil {
+			t.Errorf("block with difficulty %v written", difficulty)
+		}
+	}
+
+	block := sampleBlocks(1)[0]
+	e := &encoder{buf: []byte{blockEncodingVersion}}
+	e.int64(block.Timestamp)
+	e.bytes(block.Hash)
+	e.bytes(block.PrevHash)
+	e.int64(block.Height)
+	e.bytes(nil)
+	e.bytes(block.Nonce)
+	e.bytes(block.Miner)
+	if _, err := decodeHeader(e.buf); err == nil || !strings.Contains(err.Error(), "empty difficulty") {
+		t.Fatalf("header without difficulty: %v", err)
+	}
+}
+
+// 한도와 같은 길이의 필드는 받아들여야 함
+func TestDecodeMaxFieldLen(t *testing.T) {
+	block := sampleBlocks(1)[0]
+	block.ExtraData = make(HexBytes, maxEncodedFieldLen)
+	data, _ := block.Serialize()
+	got, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.ExtraData) != maxEncodedFieldLen {
+		t.Fatalf("ExtraData length %d", len(got.ExtraData))
+	}
+}
+
+// 블록 인코딩 방식별 인코딩/디코딩 함수
+var blockCodecs = []struct {
+	name   string
+	encode func(*Block) ([]byte, error)
+	decode func([]byte) (*Block, error)
+}{
+	{
+		name:   "json",
+		encode: func(b *Block) ([]byte, error) { return json.Marshal(b) },
+		decode: func(data []byte) (*Block, error) {
+			var b Block
+			err := json.Unmarshal(data, &b)
+			return &b, err
+		},
+	},
+	{
+		name:   "binary",
+		encode: (*Block).Serialize,
+		decode: Deserialize,
+	},
+}
+
+func BenchmarkEncodeBlock(b *testing.B) {
+	blocks := sampleBlocks(1000)
+	for _, codec := range blockCodecs {
+		b.Run(codec.name, func(b *testing.B) {
+			size := 0
+			for _, block := range blocks {
+				data, _ := codec.encode(block)
+				size += len(data)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				codec.encode(blocks[i%len(blocks)])
+			}
+			b.ReportMetric(float64(size)/float64(len(blocks)), "bytes/block")
+		})
+	}
+}
+
+func BenchmarkDecodeBlock(b *testing.B) {
+	blocks := sampleBlocks(1000)
+	for _, codec := range blockCodecs {
+		b.Run(codec.name, func(b *testing.B) {
+			encoded := make([][]byte, len(blocks))
+			for i, block := range blocks {
+				encoded[i], _ = codec.encode(block)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.decode(encoded[i%len(encoded)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
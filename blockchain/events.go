@@ -0,0 +1,15 @@
+package blockchain
+
+// ChainHeadEvent는 블록이 체인 tip으로 추가될 때 발생합니다.
+type ChainHeadEvent struct {
+	Block *Block
+}
+
+// ChainReorgEvent는 체인 tip이 뒤로 물러날 때(초기화, 되감기, 더 긴 갈래로 전환) 발생합니다.
+// NewHash가 비어있으면 체인이 비워진 것입니다.
+type ChainReorgEvent struct {
+	OldHeight int64
+	OldHash   []byte
+	NewHeight int64
+	NewHash   []byte
+}
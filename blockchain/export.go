@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/params"
+)
+
+// 내보내기 파일 형식(압축을 푼 뒤):
+//
+//	magic("MCEXPORT") version(1) headerLen(4) header(JSON) { recordLen(4) block(Serialize) }*
+//
+// 길이는 4바이트 big-endian입니다. 블록은 낮은 높이부터 순서대로 기록됩니다.
+const (
+	exportMagic   = "MCEXPORT"
+	exportVersion = 1
+	// 레코드 하나의 최대 길이
+	maxExportRecordLen = 16 << 20
+)
+
+// ExportHeader는 내보내기 파일의 머리말입니다.
+type ExportHeader struct {
+	ChainId     string              `json:"chainId"`
+	GenesisHash HexBytes            `json:"genesisHash"`
+	Params      *params.ChainParams `json:"params"`
+	From        int64               `json:"from"`
+	To          int64               `json:"to"`
+}
+
+// Export는 from부터 to까지(포함)의 블록을 w에 기록합니다. progress는 블록을 하나 기록할 때마다 호출됩니다.
+func (chain *BlockChain) Export(w io.Writer, from, to int64, progress func(height int64)) error {
+	if from < 0 || to < from {
+		return fmt.Errorf("invalid export range %d-%d", from, to)
+	}
+	if best := chain.GetBestHeight(); to > best {
+		return fmt.Errorf("export range %d-%d exceeds best height %d", from, to, best)
+	}
+
+	header, err := json.Marshal(&ExportHeader{
+		ChainId:     chain.ChainId,
+		GenesisHash: chain.GetGenesisHash(),
+		Params:      chain.Params,
+		From:        from,
+		To:          to,
+	})
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(exportMagic)
+	bw.WriteByte(exportVersion)
+	if err := writeRecord(bw, header); err != nil {
+		return err
+	}
+
+	for height := from; height <= to; height++ {
+		block, err := chain.GetBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		data, err := block.Serialize()
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(bw, data); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(height)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeRecord(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// ExportReader는 내보내기 파일의 블록을 순서대로 읽습니다.
+type ExportReader struct {
+	Header ExportHeader
+	r      *bufio.Reader
+}
+
+// NewExportReader는 머리말을 읽고 검사한 ExportReader를 반환합니다.
+func NewExportReader(r io.Reader) (*ExportReader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(exportMagic)+1)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("could not read export header: %v", err)
+	}
+	if !bytes.Equal(magic[:len(exportMagic)], []byte(exportMagic)) {
+		return nil, fmt.Errorf("not a chain export file")
+	}
+	if v := magic[len(exportMagic)]; v != exportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", v)
+	}
+
+	er := &ExportReader{r: br}
+	data, err := er.readRecord()
+	if err != nil {
+		return nil, fmt.Errorf("could not read export header: %v", err)
+	}
+	if err := json.Unmarshal(data, &er.Header); err != nil {
+		return nil, fmt.Errorf("could not decode export header: %v", err)
+	}
+	return er, nil
+}
+
+// Next는 다음 블록을 반환합니다. 파일 끝에서는 io.EOF를 반환합니다.
+func (er *ExportReader) Next() (*Block, error) {
+	data, err := er.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	return Deserialize(data)
+}
+
+func (er *ExportReader) readRecord() ([]byte, error) {
+	return readRecord(er.r)
+}
+
+// readRecord는 4바이트 길이 접두사가 붙은 레코드 하나를 읽습니다. 레코드 경계의 파일 끝에서는 io.EOF를 반환합니다.
+func readRecord(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("truncated file: %v", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxExportRecordLen {
+		return nil, fmt.Errorf("record of %d bytes exceeds limit", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated file: %v", err)
+	}
+	return data, nil
+}
+
+// InitBlockChainFromExport는 내보내기 파일의 genesis 블록으로 새 체인을 만듭니다.
+func InitBlockChainFromExport(header *ExportHeader, genesis *Block) (*BlockChain, error) {
+	if genesis.Height != 0 {
+		return nil, fmt.Errorf("export starts at height %d, a new database needs the genesis block", genesis.Height)
+	}
+	if !bytes.Equal(genesis.Hash, header.GenesisHash) {
+		return nil, fmt.Errorf("genesis mismatch: export header has %x, first block is %x", header.GenesisHash, genesis.Hash)
+	}
+	if err := VerifyPoW(genesis); err != nil {
+		return nil, err
+	}
+	if header.Params == nil {
+		return nil, errors.New("export header has no chain params")
+	}
+	if err := header.Params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chain params in export: %v", err)
+	}
+	if err := checkLocalParams(header.Params); err != nil {
+		return nil, err
+	}
+	if expected := config.GlobalConfig.GenesisHash; expected != "" && expected != hex.EncodeToString(genesis.Hash) {
+		return nil, fmt.Errorf("genesis mismatch: export has %x, config expects %s", genesis.Hash, expected)
+	}
+
+	return initBlockChain(header.ChainId, genesis, header.Params, nil)
+}
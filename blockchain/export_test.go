@@ -0,0 +1,224 @@
+package blockchain
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// useExportConfig는 InitBlockChainFromExport가 임시 디렉터리의 메모리 저장소를 쓰도록 설정합니다.
+func useExportConfig(t *testing.T) {
+	t.Helper()
+	saved := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = saved })
+	config.GlobalConfig.DataDir = t.TempDir()
+	config.GlobalConfig.DBEngine = storage.EngineMemory
+	config.GlobalConfig.Network = ""
+	config.GlobalConfig.GenesisHash = ""
+}
+
+// exportChain은 chain의 from부터 to까지를 내보낸 파일 내용을 반환합니다.
+func exportChain(t *testing.T, chain *BlockChain, from, to int64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var exported []int64
+	if err := chain.Export(&buf, from, to, func(height int64) { exported = append(exported, height) }); err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(exported)) != to-from+1 || exported[0] != from || exported[len(exported)-1] != to {
+		t.Fatalf("progress reported heights %v", exported)
+	}
+	return buf.Bytes()
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	useExportConfig(t)
+	src := newTestChain(t, 12, 0)
+
+	reader, err := NewExportReader(bytes.NewReader(exportChain(t, src, 0, 12)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Header.ChainId != src.ChainId || !bytes.Equal(reader.Header.GenesisHash, src.GetGenesisHash()) ||
+		reader.Header.From != 0 || reader.Header.To != 12 || !reader.Header.Params.Equal(src.Params) {
+		t.Fatalf("header %+v", reader.Header)
+	}
+
+	genesis, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := InitBlockChainFromExport(&reader.Header, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dst.Close() })
+
+	for {
+		block, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.ImportBlock(block); err != nil {
+			t.Fatalf("import block %d: %v", block.Height, err)
+		}
+	}
+
+	if dst.GetBestHeight() != 12 || !bytes.Equal(dst.LastHash, src.LastHash) {
+		t.Fatalf("imported chain at height %d tip %x, want 12 tip %x", dst.GetBestHeight(), dst.LastHash, src.LastHash)
+	}
+	for height := int64(0); height <= 12; height++ {
+		want, _ := src.GetBlockByHeight(height)
+		got, err := dst.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantData, _ := want.Serialize()
+		gotData, _ := got.Serialize()
+		if !bytes.Equal(gotData, wantData) {
+			t.Fatalf("block %d differs after round trip", height)
+		}
+	}
+}
+
+// 일부 높이만 내보낸 파일은 기존 체인에 이어서 가져올 수 있음
+func TestExportRangeExtendsChain(t *testing.T) {
+	src := newTestChain(t, 10, 0)
+	genesis, _ := src.GetBlockByHeight(0)
+	dst := newTestChainFrom(t, genesis, 0)
+
+	for _, r := range [][2]int64{{1, 4}, {5, 10}} {
+		reader, err := NewExportReader(bytes.NewReader(exportChain(t, src, r[0], r[1])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for block, err := reader.Next(); err != io.EOF; block, err = reader.Next() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := dst.ImportBlock(block); err != nil {
+				t.Fatalf("import block %d: %v", block.Height, err)
+			}
+		}
+	}
+	if !bytes.Equal(dst.LastHash, src.LastHash) {
+		t.Fatalf("tip %x, want %x", dst.LastHash, src.LastHash)
+	}
+}
+
+func TestExportRejects(t *testing.T) {
+	chain := newTestChain(t, 3, 0)
+	for _, r := range [][2]int64{{-1, 2}, {2, 1}, {0, 4}} {
+		if err := chain.Export(io.Discard, r[0], r[1], nil); err == nil {
+			t.Errorf("export %d-%d accepted", r[0], r[1])
+		}
+	}
+}
+
+func TestExportReaderRejects(t *testing.T) {
+	data := exportChain(t, newTestChain(t, 3, 0), 0, 3)
+
+	badVersion := append([]byte{}, data...)
+	badVersion[len(exportMagic)]++
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"magic", append([]byte("NOTCHAIN"), data[len(exportMagic):]...), "not a chain export file"},
+		{"version", badVersion, "unsupported export version"},
+		{"empty", nil, "could not read export header"},
+	}
+	for _, tt := range tests {
+		if _, err := NewExportReader(bytes.NewReader(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	// 레코드 중간에서 잘린 파일은 io.EOF가 아닌 오류
+	reader, err := NewExportReader(bytes.NewReader(data[:len(data)-3]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			t.Fatal("truncated file read to the end")
+		} else if err != nil {
+			if !strings.Contains(err.Error(), "truncated") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestInitBlockChainFromExportRejects(t *testing.T) {
+	useExportConfig(t)
+	src := newTestChain(t, 2, 0)
+	reader, err := NewExportReader(bytes.NewReader(exportChain(t, src, 0, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherGenesis := reader.Header
+	otherGenesis.GenesisHash = block.Hash
+	noParams := reader.Header
+	noParams.Params = nil
+
+	tests := []struct {
+		name    string
+		header  *ExportHeader
+		genesis *Block
+		want    string
+	}{
+		{"not genesis", &reader.Header, block, "needs the genesis block"},
+		{"header mismatch", &otherGenesis, genesis, "genesis mismatch"},
+		{"no params", &noParams, genesis, "no chain params"},
+	}
+	for _, tt := range tests {
+		if _, err := InitBlockChainFromExport(tt.header, tt.genesis); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	config.GlobalConfig.GenesisHash = "00"
+	if _, err := InitBlockChainFromExport(&reader.Header, genesis); err == nil || !strings.Contains(err.Error(), "config expects") {
+		t.Fatalf("config genesis mismatch: %v", err)
+	}
+}
+
+// 내보내기 파일의 블록도 검증을 거쳐 추가됨
+func TestImportTamperedExport(t *testing.T) {
+	src := newTestChain(t, 3, 0)
+	genesis, _ := src.GetBlockByHeight(0)
+	dst := newTestChainFrom(t, genesis, 0)
+	reader, err := NewExportReader(bytes.NewReader(exportChain(t, src, 1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.ImportBlock(tamperBody(t, block)); err == nil {
+		t.Fatal("tampered block imported")
+	}
+	if dst.GetBestHeight() != 0 {
+		t.Fatalf("best height %d after rejected import", dst.GetBestHeight())
+	}
+}
@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/Kim-DaeHan/mining-chain/params"
+)
+
+// GenesisSpec은 genesis.json 파일의 형식입니다.
+// 같은 파일로 초기화한 노드는 모두 동일한 genesis 블록을 갖습니다.
+// consensus를 생략하면 network 프리셋(기본 mainnet)의 파라미터를 사용합니다.
+type GenesisSpec struct {
+	ChainId    int                 `json:"chainId"`
+	Timestamp  int64               `json:"timestamp"`
+	Difficulty *big.Int            `json:"difficulty"`
+	Validators []string            `json:"validators"`
+	Network    string              `json:"network,omitempty"`
+	Consensus  *params.ChainParams `json:"consensus,omitempty"`
+	ExtraData  HexBytes            `json:"extraData"`
+}
+
+// LoadGenesisSpec은 genesis 파일을 읽어 검증된 GenesisSpec을 반환합니다.
+func LoadGenesisSpec(path string) (*GenesisSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open genesis file: %v", err)
+	}
+	defer file.Close()
+
+	var spec GenesisSpec
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("could not decode genesis file: %v", err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func (spec *GenesisSpec) Validate() error {
+	if spec.ChainId <= 0 {
+		return fmt.Errorf("invalid genesis chainId: %d", spec.ChainId)
+	}
+	if spec.Timestamp < 0 {
+		return fmt.Errorf("invalid genesis timestamp: %d", spec.Timestamp)
+	}
+	if spec.Difficulty == nil || spec.Difficulty.Cmp(big.NewInt(1)) < 0 {
+		return fmt.Errorf("invalid genesis difficulty: %v", spec.Difficulty)
+	}
+	if len(spec.Validators) == 0 {
+		return fmt.Errorf("genesis must define at least one validator")
+	}
+	for _, v := range spec.Validators {
+		if v == "" {
+			return fmt.Errorf("genesis validator address is empty")
+		}
+	}
+
+	_, err := spec.ChainParams()
+	return err
+}
+
+// ChainParams는 spec에 정의된 합의 파라미터를 반환합니다.
+// 초기 난이도는 genesis 블록의 difficulty를 따릅니다.
+func (spec *GenesisSpec) ChainParams() (*params.ChainParams, error) {
+	var p *params.ChainParams
+	if spec.Consensus != nil {
+		p = spec.Consensus.Copy()
+		if p.Name == "" {
+			p.Name = spec.Network
+		}
+	} else {
+		network := spec.Network
+		if network == "" {
+			network = params.Mainnet.Name
+		}
+		preset, err := params.Preset(network)
+		if err != nil {
+			return nil, err
+		}
+		p = preset
+	}
+
+	if p.InitialDifficulty != nil && p.InitialDifficulty.Cmp(spec.Difficulty) != 0 {
+		return nil, fmt.Errorf("genesis difficulty %v does not match consensus initialDifficulty %v", spec.Difficulty, p.InitialDifficulty)
+	}
+	p.InitialDifficulty = new(big.Int).Set(spec.Difficulty)
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid genesis consensus: %v", err)
+	}
+	return p, nil
+}
+
+// Hash는 spec을 정규화된 JSON으로 직렬화한 SHA-256 해시입니다.
+func (spec *GenesisSpec) Hash() ([]byte, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// ToBlock은 채굴 없이 spec으로부터 결정적인 genesis 블록을 만듭니다.
+// nonce는 spec 해시이므로 spec의 어떤 값이 바뀌어도 블록 해시가 달라집니다.
+func (spec *GenesisSpec) ToBlock() (*Block, error) {
+	specHash, err := spec.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	block := &Block{
+		Timestamp:       spec.Timestamp,
+		Hash:            HexBytes{},
+		PrevHash:        HexBytes{},
+		MainBlockHeight: 0,
+		MainBlockHash:   HexBytes{},
+		Nonce:           HexBytes(specHash),
+		Height:          0,
+		Difficulty:      new(big.Int).Set(spec.Difficulty),
+		Miner:           HexBytes{},
+		Validator:       HexBytes(spec.Validators[0]),
+		ExtraData:       spec.ExtraData,
+	}
+
+	pow := NewProof(block)
+	block.Hash = HexBytes(pow.GetHash(block))
+
+	return block, nil
+}
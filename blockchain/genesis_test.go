@@ -0,0 +1,211 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/params"
+)
+
+func testGenesisSpec() *GenesisSpec {
+	return &GenesisSpec{
+		ChainId:    3002,
+		Timestamp:  testGenesisTime,
+		Difficulty: big.NewInt(1),
+		Validators: []string{testMiner},
+		Consensus:  testParams.Copy(),
+		ExtraData:  HexBytes("genesis"),
+	}
+}
+
+// writeGenesisFile은 data를 임시 genesis 파일로 기록하고 경로를 반환합니다.
+func writeGenesisFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 같은 spec이면 파일로 읽어도 바이트 단위로 같은 genesis 블록
+func TestGenesisDeterministic(t *testing.T) {
+	spec := testGenesisSpec()
+	want, err := spec.ToBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantData, _ := want.Serialize()
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGenesisSpec(writeGenesisFile(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := loaded.ToBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotData, _ := got.Serialize(); !bytes.Equal(gotData, wantData) {
+			t.Fatalf("genesis differs\n got %+v\nwant %+v", got, want)
+		}
+	}
+	if !bytes.Equal(want.Hash, NewProof(want).GetHash(want)) {
+		t.Fatal("genesis hash does not match its contents")
+	}
+}
+
+// spec의 어떤 값이 바뀌어도 genesis 해시가 달라짐
+func TestGenesisHashCoversSpec(t *testing.T) {
+	base, _ := testGenesisSpec().ToBlock()
+	changes := map[string]func(*GenesisSpec){
+		"chainId":    func(s *GenesisSpec) { s.ChainId++ },
+		"timestamp":  func(s *GenesisSpec) { s.Timestamp++ },
+		"validators": func(s *GenesisSpec) { s.Validators = append(s.Validators, "0x01") },
+		"extraData":  func(s *GenesisSpec) { s.ExtraData = HexBytes("other") },
+		"consensus":  func(s *GenesisSpec) { s.Consensus.ResourceInterval++ },
+	}
+	for name, change := range changes {
+		spec := testGenesisSpec()
+		change(spec)
+		block, err := spec.ToBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(block.Hash, base.Hash) {
+			t.Errorf("%s: genesis hash unchanged", name)
+		}
+	}
+}
+
+func TestLoadGenesisSpecRejects(t *testing.T) {
+	valid, _ := json.Marshal(testGenesisSpec())
+	tests := []struct {
+		name string
+		edit func(*GenesisSpec)
+		want string
+	}{
+		{"chainId", func(s *GenesisSpec) { s.ChainId = 0 }, "invalid genesis chainId"},
+		{"timestamp", func(s *GenesisSpec) { s.Timestamp = -1 }, "invalid genesis timestamp"},
+		{"difficulty", func(s *GenesisSpec) { s.Difficulty = big.NewInt(0) }, "invalid genesis difficulty"},
+		{"validators", func(s *GenesisSpec) { s.Validators = nil }, "at least one validator"},
+		{"empty validator", func(s *GenesisSpec) { s.Validators = []string{""} }, "validator address is empty"},
+		{"consensus difficulty", func(s *GenesisSpec) { s.Consensus.InitialDifficulty = big.NewInt(2) }, "does not match consensus initialDifficulty"},
+		{"consensus rules", func(s *GenesisSpec) { s.Consensus.ResourceInterval = 0 }, "invalid genesis consensus"},
+		{"network", func(s *GenesisSpec) { s.Consensus, s.Network = nil, "unknown" }, "unknown network preset"},
+	}
+	for _, tt := range tests {
+		spec := testGenesisSpec()
+		tt.edit(spec)
+		data, _ := json.Marshal(spec)
+		if _, err := LoadGenesisSpec(writeGenesisFile(t, data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+
+	// 알 수 없는 필드는 오타일 수 있으므로 거부
+	unknown := append([]byte(`{"gasLimit":1,`), valid[1:]...)
+	if _, err := LoadGenesisSpec(writeGenesisFile(t, unknown)); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+// consensus를 생략하면 network 프리셋을 사용
+func TestGenesisPresetParams(t *testing.T) {
+	spec := testGenesisSpec()
+	spec.Consensus, spec.Network = nil, params.Devnet.Name
+	spec.Difficulty = new(big.Int).Set(params.Devnet.InitialDifficulty)
+	p, err := spec.ChainParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(&params.Devnet) {
+		t.Fatalf("params %+v, want devnet", p)
+	}
+
+	// network도 생략하면 mainnet이고, 프리셋의 초기 난이도와 다른 genesis는 거부
+	spec.Network = ""
+	if _, err := spec.ChainParams(); err == nil {
+		t.Fatal("devnet difficulty accepted for mainnet")
+	}
+	spec.Difficulty = new(big.Int).Set(params.Mainnet.InitialDifficulty)
+	if p, err = spec.ChainParams(); err != nil || p.Name != params.Mainnet.Name {
+		t.Fatalf("params %+v, %v; want mainnet", p, err)
+	}
+}
+
+func TestWriteGenesisRejectsMismatch(t *testing.T) {
+	chain := newTestChain(t, 0, 0)
+	genesis := chain.GetLastBlock()
+
+	other, _ := testGenesisSpec().ToBlock()
+	if err := chain.WriteGenesis(other); err == nil || !strings.Contains(err.Error(), "genesis mismatch") {
+		t.Fatalf("WriteGenesis of another genesis: %v", err)
+	}
+	if !bytes.Equal(chain.GetGenesisHash(), genesis.Hash) {
+		t.Fatal("stored genesis hash changed")
+	}
+	if err := chain.WriteGenesis(genesis); err != nil {
+		t.Fatalf("WriteGenesis of the same genesis: %v", err)
+	}
+}
+
+// genesis는 채굴하지 않으므로 해시가 내용과 맞는지만 검사
+func TestGenesisVerifyPoW(t *testing.T) {
+	genesis, _ := testGenesisSpec().ToBlock()
+	if err := VerifyPoW(genesis); err != nil {
+		t.Fatalf("spec genesis: %v", err)
+	}
+	genesis.Timestamp++
+	if err := VerifyPoW(genesis); !errors.Is(err, ErrInvalidHash) {
+		t.Fatalf("tampered genesis: %v", err)
+	}
+}
+
+// 기대하는 해시가 없으면 받은 genesis를 믿지 않고, 저장된 spec이 있으면 spec의 genesis만 받음
+func TestWriteGenesisNeedsExpectedHash(t *testing.T) {
+	spec := testGenesisSpec()
+	genesis, _ := spec.ToBlock()
+
+	chain := newEmptyTestChain(t, 0)
+	if err := chain.WriteGenesis(genesis); err == nil {
+		t.Fatal("genesis accepted without an expected hash")
+	}
+
+	data, _ := json.Marshal(spec)
+	if err := chain.Database.Put(genesisSpecKey, data); err != nil {
+		t.Fatal(err)
+	}
+	other := testGenesisSpec()
+	other.ExtraData = HexBytes("other")
+	otherGenesis, _ := other.ToBlock()
+	if err := chain.WriteGenesis(otherGenesis); err == nil || !strings.Contains(err.Error(), "genesis mismatch") {
+		t.Fatalf("genesis of another spec: %v", err)
+	}
+	// 블록 해시는 본문을 덮지 않으므로 해시가 같아도 본문이 다르면 거부
+	forged := *genesis
+	forged.ExtraData = HexBytes("other")
+	if err := chain.WriteGenesis(&forged); err == nil || !strings.Contains(err.Error(), "genesis mismatch") {
+		t.Fatalf("genesis with another body: %v", err)
+	}
+	if err := chain.WriteGenesis(genesis); err != nil {
+		t.Fatal(err)
+	}
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) > 0 {
+		t.Fatalf("spec genesis reported: %v", report.Issues)
+	}
+}
@@ -0,0 +1,507 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// 무결성 검사에서 발견한 문제의 종류
+const (
+	IssueMissingHeight = "missing-height" // 정규 체인 색인에 높이가 빠짐
+	IssueMissingBlock  = "missing-block"  // 블록의 헤더나 본문이 없거나 읽을 수 없음
+	IssueHeightIndex   = "height-index"   // 높이 색인과 블록의 높이가 다름
+	IssueLinkage       = "linkage"        // 이전 해시가 아래 높이의 블록을 가리키지 않음
+	IssueDifficulty    = "difficulty"     // 난이도가 규칙과 다름
+	IssuePoW           = "pow"            // 작업증명이나 해시가 잘못됨
+	IssueTip           = "tip"            // lastHash가 가장 높은 정규 블록이 아님
+	IssueGenesis       = "genesis"        // genesis 해시가 높이 0 블록과 다름
+)
+
+// Issue는 데이터베이스에서 발견한 불일치 하나입니다.
+type Issue struct {
+	Kind   string
+	Height int64
+	Hash   HexBytes
+	Detail string
+}
+
+func (i Issue) String() string {
+	if i.Hash == nil {
+		return fmt.Sprintf("[%s] height %d: %s", i.Kind, i.Height, i.Detail)
+	}
+	return fmt.Sprintf("[%s] height %d, block %x: %s", i.Kind, i.Height, []byte(i.Hash), i.Detail)
+}
+
+// VerifyReport는 VerifyDatabase의 결과입니다.
+type VerifyReport struct {
+	Canonical int64 // 검사한 정규 체인 블록 수
+	Stored    int64 // 저장된 블록 수
+	Issues    []Issue
+}
+
+func (r *VerifyReport) add(kind string, height int64, hash []byte, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Height: height, Hash: hash, Detail: fmt.Sprintf(format, args...)})
+}
+
+// VerifyDatabase는 genesis부터 정규 체인을 따라가며 해시 연결, 높이 색인, 난이도, 작업증명과 tip을 검사하고,
+// 저장된 모든 블록의 헤더, 본문, 높이 색인이 서로 맞는지 확인합니다. 발견한 문제를 모두 보고합니다.
+// 본문을 지웠거나 아직 받지 못한 블록은 작업증명을 검사할 수 없으므로 헤더로 연결과 난이도만 검사합니다.
+// progress는 정규 블록을 하나 검사할 때마다 호출됩니다.
+func (chain *BlockChain) VerifyDatabase(progress func(height int64)) (*VerifyReport, error) {
+	db := chain.Database
+	report := &VerifyReport{}
+
+	var prev *Block
+	best, expected := int64(-1), int64(0)
+	iter := db.NewIterator(canonicalHashPrefix)
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(canonicalHashPrefix)+8 {
+			report.add(IssueHeightIndex, -1, nil, "malformed canonical index key %x", key)
+			continue
+		}
+		height := int64(binary.BigEndian.Uint64(key[len(canonicalHashPrefix):]))
+		hash := append([]byte{}, iter.Value()...)
+
+		if height > expected {
+			report.add(IssueMissingHeight, expected, nil, "no canonical block for heights %d-%d", expected, height-1)
+			prev = nil
+		}
+		expected, best = height+1, height
+		report.Canonical++
+
+		block, err := readBlock(db, hash)
+		if errors.Is(err, ErrBlockPruned) || errors.Is(err, ErrBodyNotSynced) {
+			var header *Header
+			if header, err = readHeader(db, hash); err == nil {
+				block = joinBlock(header, &blockBody{})
+			}
+		}
+		if err != nil {
+			report.add(IssueMissingBlock, height, hash, "%v", err)
+			prev = nil
+			continue
+		}
+		chain.verifyCanonical(report, db, height, block, prev)
+		prev = block
+
+		if progress != nil {
+			progress(height)
+		}
+	}
+	err := iter.Error()
+	iter.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chain.verifyTip(report, db, best); err != nil {
+		return nil, err
+	}
+	if err := verifyStoredBlocks(report, db); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// verifyCanonical은 정규 체인의 height 블록을 바로 아래 블록 prev와 함께 검사합니다.
+// prev를 읽지 못했거나 prev의 높이가 맞지 않으면 연결과 난이도는 건너뜁니다.
+func (chain *BlockChain) verifyCanonical(report *VerifyReport, db storage.Reader, height int64, block, prev *Block) {
+	if block.Height != height {
+		report.add(IssueHeightIndex, height, block.Hash, "indexed at height %d but block height is %d", height, block.Height)
+		// 연결 검사는 같은 문제를 다시 보고하므로 작업증명만 검사
+		prev = nil
+	}
+	if indexed, err := readBlockHeight(db, block.Hash); err != nil {
+		report.add(IssueHeightIndex, height, block.Hash, "%v", err)
+	} else if indexed != height {
+		report.add(IssueHeightIndex, height, block.Hash, "hash index has height %d", indexed)
+	}
+
+	// genesis는 채굴된 블록이 아니므로 VerifyPoW는 해시가 내용과 일치하는지만 검사함
+	if height == 0 {
+		if genesis := chain.GetGenesisHash(); genesis != nil && !bytes.Equal(genesis, block.Hash) {
+			report.add(IssueGenesis, 0, block.Hash, "stored genesis hash is %x", genesis)
+		}
+		if err := VerifyPoW(block); err != nil {
+			report.add(IssueGenesis, 0, block.Hash, "%v", err)
+		}
+		return
+	}
+
+	var err error
+	switch {
+	case isPruned(db, height) || isBackfilling(db, height):
+		if prev != nil && prev.Height == height-1 {
+			err = chain.verifyHeader(block, prev)
+		}
+	case prev != nil && prev.Height == height-1:
+		err = chain.VerifyBlock(block, prev)
+	default:
+		err = VerifyPoW(block)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrPrevHashMismatch):
+		report.add(IssueLinkage, height, block.Hash, "%v", err)
+	case errors.Is(err, ErrInvalidHeight):
+		report.add(IssueHeightIndex, height, block.Hash, "%v", err)
+	case errors.Is(err, ErrInvalidPoW), errors.Is(err, ErrInvalidHash):
+		report.add(IssuePoW, height, block.Hash, "%v", err)
+	default:
+		report.add(IssueDifficulty, height, block.Hash, "%v", err)
+	}
+}
+
+// verifyHeader는 본문을 지운 block이 prev를 잇고 난이도가 맞는지만 검사합니다.
+func (chain *BlockChain) verifyHeader(block, prev *Block) error {
+	if !bytes.Equal(block.PrevHash, prev.Hash) {
+		return fmt.Errorf("%w: prevHash %x, parent %x", ErrPrevHashMismatch, block.PrevHash, prev.Hash)
+	}
+	expected, err := chain.Difficulty(block.Height)
+	if err != nil {
+		return err
+	}
+	if block.Difficulty == nil || block.Difficulty.Cmp(expected) != 0 {
+		return fmt.Errorf("%w: height %d has %v, expected %v", ErrInvalidDifficulty, block.Height, block.Difficulty, expected)
+	}
+	return nil
+}
+
+// verifyTip은 lastHash가 가장 높은 정규 블록을 가리키는지 확인합니다.
+func (chain *BlockChain) verifyTip(report *VerifyReport, db storage.Reader, best int64) error {
+	lastHash, err := db.Get(lastHashKey)
+	if err == storage.ErrNotFound {
+		if best >= 0 {
+			report.add(IssueTip, best, nil, "no last block hash")
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if best < 0 {
+		report.add(IssueTip, -1, lastHash, "last block hash is set but the canonical index is empty")
+		return nil
+	}
+	canonical, err := readCanonicalHash(db, best)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(lastHash, canonical) {
+		height := int64(-1)
+		if block, err := readBlock(db, lastHash); err == nil {
+			height = block.Height
+		}
+		report.add(IssueTip, best, lastHash, "last block hash (height %d) is not the highest canonical block %x", height, canonical)
+	}
+	return nil
+}
+
+// verifyStoredBlocks는 저장된 모든 헤더에 본문과 높이 색인이 있는지, 높이 색인이 없는 블록을 가리키지 않는지 확인합니다.
+// 가지치기로 본문을 지웠거나 스냅샷 복원 뒤 아직 본문을 받지 못한 높이의 헤더는 본문이 없어도 됩니다.
+func verifyStoredBlocks(report *VerifyReport, db storage.Reader) error {
+	iter := db.NewIterator(headerPrefix)
+	defer iter.Release()
+	for iter.Next() {
+		hash := append([]byte{}, iter.Key()[len(headerPrefix):]...)
+		report.Stored++
+
+		header, err := decodeHeader(iter.Value())
+		if err != nil {
+			report.add(IssueMissingBlock, -1, hash, "could not decode header: %v", err)
+			continue
+		}
+		if ok, err := db.Has(bodyKey(hash)); err != nil {
+			return err
+		} else if !ok && !isPruned(db, header.Height) && !isBackfilling(db, header.Height) {
+			report.add(IssueMissingBlock, header.Height, hash, "header without body")
+		}
+		if indexed, err := readBlockHeight(db, hash); err != nil {
+			report.add(IssueHeightIndex, header.Height, hash, "%v", err)
+		} else if indexed != header.Height {
+			report.add(IssueHeightIndex, header.Height, hash, "hash index has height %d", indexed)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	index := db.NewIterator(blockHeightPrefix)
+	defer index.Release()
+	for index.Next() {
+		hash := index.Key()[len(blockHeightPrefix):]
+		if ok, err := db.Has(headerKey(hash)); err != nil {
+			return err
+		} else if !ok {
+			report.add(IssueHeightIndex, -1, append([]byte{}, hash...), "hash index entry without block")
+		}
+	}
+	return index.Error()
+}
+
+// readBlockHeight는 해시→높이 색인을 읽습니다.
+func readBlockHeight(db storage.Reader, hash []byte) (int64, error) {
+	data, err := db.Get(blockHeightKey(hash))
+	if err == storage.ErrNotFound {
+		return 0, fmt.Errorf("no hash index entry")
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("malformed hash index entry %x", data)
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// RepairResult는 RepairDatabase의 결과입니다.
+type RepairResult struct {
+	Tip       *Block
+	Stored    int // 읽을 수 있는 저장된 블록 수
+	Invalid   int // 검증에 실패했거나 genesis에 이어지지 않는 블록 수
+	Rewritten int // 고치거나 지운 색인 항목 수
+}
+
+// RepairDatabase는 저장된 블록으로 genesis에서 시작하는 가장 긴 유효한 체인을 찾아
+// 정규 체인 색인, 해시→높이 색인, tip을 다시 만듭니다. 블록 데이터는 지우지 않습니다.
+// 색인이 아니라 블록 자체를 다시 읽으므로, 중간에 멈춰도 다시 실행하면 됩니다.
+// 메모리에는 블록 해시로 만든 부모→자식 색인과 검사 중인 경로의 해시만 두고, 블록은 검사할 때 하나씩 읽습니다.
+// 본문을 지운 블록은 검증할 수 없으므로 가지치기한 데이터베이스나 본문을 받는 중인 데이터베이스는 고치지 않습니다.
+func (chain *BlockChain) RepairDatabase() (*RepairResult, error) {
+	db := chain.Database
+	result := &RepairResult{}
+
+	if chain.light {
+		return nil, fmt.Errorf("cannot repair a light node database, it stores no block bodies")
+	}
+	if pruned := chain.PrunedHeight(); pruned > 0 {
+		return nil, fmt.Errorf("cannot repair a pruned database (bodies below height %d are deleted), resync it instead", pruned)
+	}
+	if backfill := chain.BackfillHeight(); backfill > 0 {
+		return nil, fmt.Errorf("cannot repair while block bodies below height %d are still being synced after a snapshot restore", backfill)
+	}
+
+	// 저장된 블록의 헤더를 훑으며 이전 해시로 자식 해시를 묶음
+	children := make(map[string][]string)
+	var zeros [][]byte // 높이 0 블록의 해시
+	iter := db.NewIterator(headerPrefix)
+	for iter.Next() {
+		hash := iter.Key()[len(headerPrefix):]
+		header, err := decodeHeader(iter.Value())
+		if err == nil {
+			_, err = db.Get(bodyKey(hash))
+		}
+		if err != nil {
+			log.Warn("skipping unreadable block", "hash", fmt.Sprintf("%x", hash), "err", err)
+			result.Invalid++
+			continue
+		}
+		result.Stored++
+		children[string(header.PrevHash)] = append(children[string(header.PrevHash)], string(hash))
+		if header.Height == 0 {
+			zeros = append(zeros, append([]byte{}, hash...))
+		}
+	}
+	err := iter.Error()
+	iter.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	genesis, err := chain.findGenesis(zeros)
+	if err != nil {
+		return nil, err
+	}
+
+	// genesis부터 깊이 우선으로 내려가며 각 블록을 그 경로의 블록으로 검증
+	// 같은 높이면 현재 정규 체인의 블록을 우선
+	path := [][]byte{} // path[h]는 검사 중인 경로에서 높이 h의 블록 해시
+	headerAt := func(height int64) (*Header, error) {
+		if height < 0 || height >= int64(len(path)) {
+			return nil, fmt.Errorf("%w: height %d", ErrBlockNotFound, height)
+		}
+		return readHeader(db, path[height])
+	}
+	best := genesis
+	valid := 1
+	stack := []string{string(genesis.Hash)}
+	for len(stack) > 0 {
+		hash := []byte(stack[len(stack)-1])
+		stack = stack[:len(stack)-1]
+
+		block := genesis
+		if !bytes.Equal(hash, genesis.Hash) {
+			if block, err = readBlock(db, hash); err != nil {
+				return nil, err
+			}
+			if block.Height < 1 || block.Height > int64(len(path)) {
+				continue
+			}
+			path = path[:block.Height]
+			parent, err := readHeader(db, path[block.Height-1])
+			if err != nil {
+				return nil, err
+			}
+			if err := chain.verifyBlock(block, joinBlock(parent, &blockBody{}), headerAt); err != nil {
+				log.Warn("skipping invalid block", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash), "err", err)
+				continue
+			}
+			valid++
+		}
+		path = append(path, block.Hash)
+
+		if block.Height > best.Height || (block.Height == best.Height && chain.isCanonical(block)) {
+			best = block
+		}
+		stack = append(stack, children[string(block.Hash)]...)
+	}
+	result.Invalid += result.Stored - valid
+	result.Tip = best
+
+	result.Rewritten, err = chain.writeRepairedIndex(best)
+	if err != nil {
+		return nil, err
+	}
+
+	chain.cache.purge()
+	chain.setHead(best)
+	observeHead(best)
+	return result, nil
+}
+
+// findGenesis는 저장된 genesis 해시, 높이 0의 정규 블록, 유일한 높이 0 블록 순서로 genesis를 찾습니다.
+// zeros는 저장된 높이 0 블록의 해시입니다.
+func (chain *BlockChain) findGenesis(zeros [][]byte) (*Block, error) {
+	if hash := chain.GetGenesisHash(); hash != nil {
+		block, err := readBlock(chain.Database, hash)
+		if err != nil {
+			return nil, fmt.Errorf("genesis block %x is missing, the chain cannot be repaired: %v", hash, err)
+		}
+		return block, nil
+	}
+	if hash, err := readCanonicalHash(chain.Database, 0); err == nil {
+		if block, err := readBlock(chain.Database, hash); err == nil && block.Height == 0 {
+			return block, nil
+		}
+	}
+
+	switch len(zeros) {
+	case 0:
+		return nil, fmt.Errorf("no genesis block found, the chain cannot be repaired")
+	case 1:
+		return readBlock(chain.Database, zeros[0])
+	default:
+		return nil, fmt.Errorf("several height 0 blocks and no stored genesis hash, the chain cannot be repaired")
+	}
+}
+
+// isCanonical은 block이 현재 정규 체인 색인에 있는지 확인합니다.
+func (chain *BlockChain) isCanonical(block *Block) bool {
+	hash, err := readCanonicalHash(chain.Database, block.Height)
+	return err == nil && bytes.Equal(hash, block.Hash)
+}
+
+// writeRepairedIndex는 tip에서 genesis까지 거슬러 올라가며 정규 체인 색인을, 저장된 헤더를 다시 훑으며 해시→높이 색인을 만듭니다.
+// 달라진 색인 항목만 배치로 나눠 기록하고, 마지막 배치에서 tip을 기록합니다.
+func (chain *BlockChain) writeRepairedIndex(tip *Block) (int, error) {
+	db := chain.Database
+	batch := db.NewBatch()
+	changed := 0
+
+	flush := func() error {
+		if batch.Len() < migrationBatchWrites {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	put := func(key, value []byte) error {
+		stored, err := db.Get(key)
+		if err == nil && bytes.Equal(stored, value) {
+			return nil
+		} else if err != nil && err != storage.ErrNotFound {
+			return err
+		}
+		batch.Put(key, value)
+		changed++
+		return flush()
+	}
+	// 다시 만들 색인에 없는 prefix 키를 지움
+	prune := func(prefix []byte, keep func(key []byte) (bool, error)) error {
+		iter := db.NewIterator(prefix)
+		defer iter.Release()
+		for iter.Next() {
+			if ok, err := keep(iter.Key()); err != nil {
+				return err
+			} else if ok {
+				continue
+			}
+			batch.Delete(append([]byte{}, iter.Key()...))
+			changed++
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		return iter.Error()
+	}
+
+	err := prune(canonicalHashPrefix, func(key []byte) (bool, error) {
+		if len(key) != len(canonicalHashPrefix)+8 {
+			return false, nil
+		}
+		return binary.BigEndian.Uint64(key[len(canonicalHashPrefix):]) <= uint64(tip.Height), nil
+	})
+	if err == nil {
+		err = prune(blockHeightPrefix, func(key []byte) (bool, error) {
+			return db.Has(headerKey(key[len(blockHeightPrefix):]))
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	iter := db.NewIterator(headerPrefix)
+	for iter.Next() {
+		header, err := decodeHeader(iter.Value())
+		if err != nil {
+			continue
+		}
+		if err := put(blockHeightKey(iter.Key()[len(headerPrefix):]), encodeHeight(header.Height)); err != nil {
+			iter.Release()
+			return 0, err
+		}
+	}
+	err = iter.Error()
+	iter.Release()
+	if err != nil {
+		return 0, err
+	}
+
+	for hash := []byte(tip.Hash); ; {
+		header, err := readHeader(db, hash)
+		if err != nil {
+			return 0, err
+		}
+		if err := put(canonicalHashKey(header.Height), hash); err != nil {
+			return 0, err
+		}
+		if header.Height == 0 {
+			if err := put(genesisHashKey, hash); err != nil {
+				return 0, err
+			}
+			break
+		}
+		hash = header.PrevHash
+	}
+	if err := put(lastHashKey, tip.Hash); err != nil {
+		return 0, err
+	}
+	return changed, batch.Write()
+}
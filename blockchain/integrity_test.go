@@ -0,0 +1,228 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+)
+
+// issueKinds는 보고서의 문제 종류를 센 값입니다.
+func issueKinds(report *VerifyReport) map[string]int {
+	kinds := map[string]int{}
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	return kinds
+}
+
+// storeSideBlock은 block을 정규 체인 색인 없이 저장합니다.
+func storeSideBlock(t *testing.T, chain *BlockChain, block *Block) {
+	t.Helper()
+	batch := chain.Database.NewBatch()
+	if err := writeBlock(batch, block); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyDatabase(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	var checked []int64
+	report, err := chain.VerifyDatabase(func(height int64) { checked = append(checked, height) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("issues in a clean chain: %v", report.Issues)
+	}
+	if report.Canonical != 21 || report.Stored != 21 || len(checked) != 21 {
+		t.Fatalf("checked %d canonical, %d stored, progress %d; want 21", report.Canonical, report.Stored, len(checked))
+	}
+
+	db := chain.Database
+	b5, _ := chain.GetBlockByHeight(5)
+	b12, _ := chain.GetBlockByHeight(12)
+	b15, _ := chain.GetBlockByHeight(15)
+	db.Delete(canonicalHashKey(8))
+	db.Put(blockHeightKey(b5.Hash), encodeHeight(6))
+	db.Delete(bodyKey(b12.Hash))
+	db.Put(lastHashKey, b15.Hash)
+	db.Put(blockHeightKey([]byte("orphan index")), encodeHeight(3))
+
+	report, err = chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := issueKinds(report)
+	for kind, want := range map[string]int{
+		IssueMissingHeight: 1, // 8
+		IssueHeightIndex:   3, // 5의 정규 체인 검사와 저장된 블록 검사, 블록 없는 색인
+		IssueMissingBlock:  2, // 12의 정규 체인 검사와 저장된 블록 검사
+		IssueTip:           1,
+	} {
+		if kinds[kind] != want {
+			t.Errorf("%s: got %d issues, want %d\n%v", kind, kinds[kind], want, report.Issues)
+		}
+	}
+}
+
+func TestVerifyDatabaseReportsInvalidBlocks(t *testing.T) {
+	chain := newTestChain(t, 10, 0)
+	b3, _ := chain.GetBlockByHeight(3)
+	b7, _ := chain.GetBlockByHeight(7)
+
+	// 3의 본문을 작업증명이 맞지 않는 본문으로 바꿈. 블록 해시는 본문을 포함하지 않으므로 같은 키에 기록됨
+	storeSideBlock(t, chain, tamperBody(t, b3))
+	// 6의 자리에 7을 잇지 않는 다른 블록
+	b5, _ := chain.GetBlockByHeight(5)
+	side := newTestBlock(t, b5, "side")
+	storeSideBlock(t, chain, side)
+	chain.Database.Put(canonicalHashKey(6), side.Hash)
+
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := issueKinds(report)
+	if kinds[IssuePoW] != 1 || kinds[IssueLinkage] != 1 {
+		t.Fatalf("issues %v", report.Issues)
+	}
+	for _, issue := range report.Issues {
+		if issue.Kind == IssueLinkage && (issue.Height != 7 || !bytes.Equal(issue.Hash, b7.Hash)) {
+			t.Fatalf("linkage reported for height %d, want block 7", issue.Height)
+		}
+	}
+}
+
+// 난이도가 없는 블록도 검사를 멈추지 않고 보고함
+func TestVerifyDatabaseNilDifficulty(t *testing.T) {
+	chain := newTestChain(t, 10, 0)
+	b4, _ := chain.GetBlockByHeight(4)
+	b5, _ := chain.GetBlockByHeight(5)
+	nilDiff := *b5
+	nilDiff.Difficulty = nil
+	for _, prev := range []*Block{b4, nil} {
+		report := &VerifyReport{}
+		chain.verifyCanonical(report, chain.Database, 5, &nilDiff, prev)
+		if kinds := issueKinds(report); len(report.Issues) != 1 || kinds[IssueDifficulty] != 1 {
+			t.Fatalf("prev %v: issues %v", prev != nil, report.Issues)
+		}
+	}
+
+	// 저장된 헤더의 난이도 필드가 비어 있고 아래 높이가 없어 작업증명만 검사하는 블록
+	b8, _ := chain.GetBlockByHeight(8)
+	e := &encoder{buf: []byte{blockEncodingVersion}}
+	e.int64(b8.Timestamp)
+	e.bytes(b8.Hash)
+	e.bytes(b8.PrevHash)
+	e.int64(b8.Height)
+	e.bytes(nil)
+	e.bytes(b8.Nonce)
+	e.bytes(b8.Miner)
+	chain.Database.Put(headerKey(b8.Hash), e.buf)
+	chain.Database.Delete(canonicalHashKey(7))
+
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reported := false
+	for _, issue := range report.Issues {
+		reported = reported || (issue.Height == 8 && bytes.Equal(issue.Hash, b8.Hash))
+	}
+	if !reported {
+		t.Fatalf("block without difficulty not reported: %v", report.Issues)
+	}
+}
+
+func TestRepairDatabase(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	tip := chain.GetLastBlock()
+	db := chain.Database
+
+	// 현재 체인보다 긴 갈래. 14 위의 블록은 작업증명이 틀려 유효한 갈래는 8-14
+	fork, _ := chain.GetBlockByHeight(7)
+	var branch []*Block
+	for i := 0; i < 17; i++ {
+		fork = newTestBlock(t, fork, "fork")
+		branch = append(branch, fork)
+	}
+	branch[7].Nonce = HexBytes("bad")
+	for _, b := range branch {
+		storeSideBlock(t, chain, b)
+	}
+
+	// 색인을 망가뜨림
+	b3, _ := chain.GetBlockByHeight(3)
+	db.Delete(canonicalHashKey(10))
+	db.Put(canonicalHashKey(30), branch[0].Hash)
+	db.Delete(blockHeightKey(b3.Hash))
+	db.Put(blockHeightKey([]byte("orphan index")), encodeHeight(3))
+	db.Put(lastHashKey, b3.Hash)
+
+	result, err := chain.RepairDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Tip.Hash, tip.Hash) {
+		t.Fatalf("repaired tip at height %d, want %d", result.Tip.Height, tip.Height)
+	}
+	if result.Stored != 21+len(branch) || result.Invalid != len(branch)-7 {
+		t.Fatalf("stored %d, invalid %d", result.Stored, result.Invalid)
+	}
+	if result.Rewritten != 5 {
+		t.Fatalf("rewrote %d index entries, want 5", result.Rewritten)
+	}
+	if got := chain.GetLastBlock(); !bytes.Equal(got.Hash, tip.Hash) {
+		t.Fatalf("head at height %d after repair", got.Height)
+	}
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("issues after repair: %v", report.Issues)
+	}
+
+	// 다시 실행하면 바꿀 것이 없음
+	if result, err := chain.RepairDatabase(); err != nil || result.Rewritten != 0 {
+		t.Fatalf("second repair: %+v, %v", result, err)
+	}
+}
+
+// 더 긴 유효한 갈래가 있으면 그 갈래로 정규 체인을 다시 만듦
+func TestRepairDatabaseSwitchesToLongestChain(t *testing.T) {
+	chain := newTestChain(t, 10, 0)
+	fork, _ := chain.GetBlockByHeight(4)
+	for i := 0; i < 12; i++ {
+		fork = newTestBlock(t, fork, "fork")
+		storeSideBlock(t, chain, fork)
+	}
+
+	result, err := chain.RepairDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Tip.Hash, fork.Hash) || chain.GetBestHeight() != 16 {
+		t.Fatalf("tip at height %d, want fork tip 16", result.Tip.Height)
+	}
+	for h := int64(5); h <= 16; h++ {
+		if b, err := chain.GetBlockByHeight(h); err != nil || string(b.ExtraData) != "fork" {
+			t.Fatalf("height %d not on the fork: %v", h, err)
+		}
+	}
+	if report, err := chain.VerifyDatabase(nil); err != nil || len(report.Issues) != 0 {
+		t.Fatalf("verify after repair: %v, %v", report, err)
+	}
+}
+
+func TestRepairDatabaseRejectsPruned(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	if _, err := chain.Prune(5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.RepairDatabase(); err == nil {
+		t.Fatal("repaired a pruned database")
+	}
+}
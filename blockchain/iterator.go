@@ -1,29 +1,29 @@
 package blockchain
 
 import (
-	"fmt"
-
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/Kim-DaeHan/mining-chain/storage"
 )
 
 type BlockchainIterator struct {
 	currentHash []byte
-	Database    *leveldb.DB
+	Database    storage.Store
 }
 
 func (chain *BlockChain) NewIterator() *BlockchainIterator {
 	return &BlockchainIterator{currentHash: chain.LastHash, Database: chain.Database}
 }
 
-func (iter *BlockchainIterator) NextBlock() *Block {
-	blockData, err := iter.Database.Get(iter.currentHash, nil)
-	if err != nil {
-		fmt.Println("End of chain reached or error occurred:", err)
-		return nil
+// Next는 현재 블록을 반환하고 이전 블록으로 이동합니다. genesis를 지나면 (nil, nil)을 반환합니다.
+func (iter *BlockchainIterator) Next() (*Block, error) {
+	if len(iter.currentHash) == 0 {
+		return nil, nil
 	}
 
-	block := Deserialize(blockData)
+	block, err := readBlock(iter.Database, iter.currentHash)
+	if err != nil {
+		return nil, err
+	}
 	iter.currentHash = block.PrevHash
 
-	return block
+	return block, nil
 }
@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// ErrBodyMismatch는 peer에게서 받은 블록이 저장된 헤더와 맞지 않을 때 반환됩니다.
+var ErrBodyMismatch = errors.New("block does not match the stored header")
+
+// IsLight는 헤더만 저장하는 light 노드의 체인인지 확인합니다.
+//
+// light 노드도 동기화할 때는 헤더가 아니라 블록 전체를 받습니다. 작업증명(ProofOfWork.WorkData)은
+// 본문 필드(MainBlockHeight, MainBlockHash, Validator, ExtraData)까지 포함한 블록 JSON으로 계산하므로
+// 헤더만으로는 작업증명을 검증할 수 없기 때문입니다. 받은 블록은 ImportBlock에서 작업증명과 난이도를 검증한 뒤
+// 헤더만 저장합니다. 헤더만 받는 동기화는 작업증명이 헤더 필드만 덮도록 합의 규칙을 바꿔야 가능합니다.
+func (chain *BlockChain) IsLight() bool {
+	return chain.light
+}
+
+// checkNodeType은 light 노드로 여는 데이터베이스에 표시를 남기고,
+// light 노드가 만든 데이터베이스는 본문이 없으므로 다른 노드 유형으로 열지 못하게 합니다.
+func checkNodeType(db storage.Store) (bool, error) {
+	nodeType := config.GlobalConfig.NodeType
+	light := nodeType == "light"
+
+	marked, err := db.Has(lightKey)
+	if err != nil {
+		return false, err
+	}
+	if marked && !light {
+		return false, fmt.Errorf("database was synced by a light node and has no block bodies, set nodeType to light or remove it to run as %s", nodeType)
+	}
+	if light && !marked {
+		if err := db.Put(lightKey, []byte{1}); err != nil {
+			return false, err
+		}
+	}
+	return light, nil
+}
+
+// putBlock은 블록을 배치에 기록합니다. light 노드는 genesis를 빼고 헤더와 색인만 기록합니다.
+func (chain *BlockChain) putBlock(batch storage.Batch, b *Block) error {
+	if chain.light && b.Height > 0 {
+		return writeHeader(batch, b)
+	}
+	return writeBlock(batch, b)
+}
+
+// loadBlock은 해시로 블록을 읽습니다. light 노드는 저장하지 않은 본문을 peer에게서 받습니다.
+func (chain *BlockChain) loadBlock(hash []byte) (*Block, error) {
+	block, err := readBlock(chain.Database, hash)
+	if !chain.light || !errors.Is(err, ErrBlockPruned) {
+		return block, err
+	}
+
+	header, err := readHeader(chain.Database, hash)
+	if err != nil {
+		return nil, err
+	}
+	if chain.FetchBlock == nil {
+		return nil, fmt.Errorf("%w: height %d, light node has no peers to fetch it from", ErrBlockPruned, header.Height)
+	}
+	return chain.FetchBlock(hash, func(block *Block) error {
+		return checkBody(header, block)
+	})
+}
+
+// checkBody는 peer에게서 받은 블록이 저장된 헤더의 블록인지 확인합니다.
+// 블록 해시는 본문을 포함하지 않으므로 헤더 필드를 비교한 뒤, 본문까지 포함해 계산하는 작업증명으로 본문을 검증합니다.
+func checkBody(header *Header, block *Block) error {
+	if !sameHeader(block.Header(), header) {
+		return fmt.Errorf("%w: block %x", ErrBodyMismatch, []byte(header.Hash))
+	}
+	if err := VerifyPoW(block); err != nil {
+		return fmt.Errorf("%w: %v", ErrBodyMismatch, err)
+	}
+	return nil
+}
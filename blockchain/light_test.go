@@ -0,0 +1,88 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// newLightTestChain은 genesis와 blocks개의 블록을 light 노드처럼 헤더만 저장한 체인을 만듭니다.
+func newLightTestChain(t *testing.T, blocks int) (*BlockChain, []*Block) {
+	t.Helper()
+	chain := newTestChain(t, 0, 0)
+	// checkNodeType처럼 데이터베이스에 light 표시를 남김
+	chain.light = true
+	if err := chain.Database.Put(lightKey, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	var added []*Block
+	for i := 0; i < blocks; i++ {
+		block := newTestBlock(t, chain.GetLastBlock(), "body")
+		if err := chain.ImportBlock(block); err != nil {
+			t.Fatalf("add block %d: %v", block.Height, err)
+		}
+		added = append(added, block)
+	}
+	return chain, added
+}
+
+// light 노드는 본문을 버리기 전에 작업증명을 검증하고 헤더만 저장
+func TestLightImportBlock(t *testing.T) {
+	chain, blocks := newLightTestChain(t, 3)
+
+	for _, b := range blocks {
+		if ok, _ := chain.Database.Has(bodyKey(b.Hash)); ok {
+			t.Fatalf("light node stored the body of block %d", b.Height)
+		}
+		if header, err := chain.GetHeaderByHeight(b.Height); err != nil || !bytes.Equal(header.Hash, b.Hash) {
+			t.Fatalf("header %d: %v, %v", b.Height, header, err)
+		}
+	}
+
+	// 본문을 바꾸면 작업증명이 맞지 않음
+	bad := tamperBody(t, newTestBlock(t, chain.GetLastBlock(), ""))
+	if err := chain.ImportBlock(bad); err == nil {
+		t.Fatal("light node accepted a block with invalid proof of work")
+	}
+	if chain.GetBestHeight() != blocks[len(blocks)-1].Height {
+		t.Fatal("tip moved after rejected block")
+	}
+}
+
+func TestLightFetchBlock(t *testing.T) {
+	chain, blocks := newLightTestChain(t, 2)
+	want := blocks[0]
+
+	if _, err := chain.GetBlock(want.Hash); !errors.Is(err, ErrBlockPruned) {
+		t.Fatalf("GetBlock without peers: %v", err)
+	}
+
+	// peer가 보낸 블록은 check를 통과해야 반환
+	var served *Block
+	chain.FetchBlock = func(hash []byte, check func(*Block) error) (*Block, error) {
+		if err := check(served); err != nil {
+			return nil, err
+		}
+		return served, nil
+	}
+
+	served = tamperBody(t, want)
+	if _, err := chain.GetBlock(want.Hash); !errors.Is(err, ErrBodyMismatch) {
+		t.Fatalf("tampered body: %v", err)
+	}
+
+	other := *blocks[1]
+	served = &other
+	if _, err := chain.GetBlock(want.Hash); !errors.Is(err, ErrBodyMismatch) {
+		t.Fatalf("other block: %v", err)
+	}
+
+	served = want
+	got, err := chain.GetBlock(want.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.ExtraData, want.ExtraData) {
+		t.Fatalf("ExtraData: got %q, want %q", got.ExtraData, want.ExtraData)
+	}
+}
@@ -2,8 +2,8 @@ package blockchain
 
 import (
 	"fmt"
-	"log"
 	"net"
+	"os"
 )
 
 type Node struct {
@@ -15,7 +15,8 @@ type Node struct {
 func (n *Node) NewNode(validator string, listenPort int) *Node {
 	ip, err := GetPreferredIP()
 	if err != nil {
-		log.Fatalf("Failed to get IP: %v", err)
+		log.Error("Failed to get IP", "err", err)
+		os.Exit(1)
 	}
 
 	return &Node{
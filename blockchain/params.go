@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/params"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// localChainParams는 설정의 network 프리셋을 반환합니다. 설정하지 않았으면 nil입니다.
+func localChainParams() (*params.ChainParams, error) {
+	if config.GlobalConfig.Network == "" {
+		return nil, nil
+	}
+	return params.Preset(config.GlobalConfig.Network)
+}
+
+// defaultChainParams는 새 체인에 사용할 파라미터를 반환합니다.
+func defaultChainParams() (*params.ChainParams, error) {
+	local, err := localChainParams()
+	if err != nil || local != nil {
+		return local, err
+	}
+	return params.Preset(params.Mainnet.Name)
+}
+
+// checkLocalParams는 설정의 network가 체인 파라미터와 다르면 오류를 반환합니다.
+func checkLocalParams(stored *params.ChainParams) error {
+	local, err := localChainParams()
+	if err != nil {
+		return err
+	}
+	if local != nil && !local.Equal(stored) {
+		return fmt.Errorf("config network %q disagrees with chain params stored at genesis (%q); refusing to start", config.GlobalConfig.Network, stored.Name)
+	}
+	return nil
+}
+
+// loadChainParams는 데이터베이스에 저장된 체인 파라미터를 읽습니다.
+// 파라미터가 없는 기존 데이터베이스는 설정의 network(기본 mainnet) 프리셋으로 채웁니다.
+func (chain *BlockChain) loadChainParams() error {
+	data, err := chain.Database.Get(chainParamsKey)
+	if err == storage.ErrNotFound {
+		p, err := defaultChainParams()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err := chain.Database.Put(chainParamsKey, data); err != nil {
+			return err
+		}
+		chain.Params = p
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var stored params.ChainParams
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("could not decode stored chain params: %v", err)
+	}
+	if err := stored.Validate(); err != nil {
+		return fmt.Errorf("invalid stored chain params: %v", err)
+	}
+	if err := checkLocalParams(&stored); err != nil {
+		return err
+	}
+
+	chain.Params = &stored
+	return nil
+}
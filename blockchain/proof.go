@@ -6,25 +6,27 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"math/big"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Kim-DaeHan/mining-chain/metrics"
 	"github.com/Kim-DaeHan/mining-chain/utils"
 )
 
-const Difficulty = 30
-
 type ProofOfWork struct {
 	Block  *Block
 	Target *big.Int
 }
 
 func NewProof(b *Block) *ProofOfWork {
-	target := big.NewInt(1)
-
-	target.Lsh(target, uint(256-Difficulty))
+	// 목표값은 HashLimit과 같은 2^256 / 난이도
+	target := new(big.Int).Lsh(big.NewInt(1), 256)
+	if b.Difficulty != nil && b.Difficulty.Sign() > 0 {
+		target.Div(target, b.Difficulty)
+	}
 
 	pow := &ProofOfWork{b, target}
 	return pow
@@ -41,14 +43,25 @@ func (pow *ProofOfWork) InitData(nonce []byte) []byte {
 	return data
 }
 
-func (pow *ProofOfWork) Run() []byte {
+func (pow *ProofOfWork) Run() ([]byte, error) {
+	hashLimit, err := HashLimit(pow.Block)
+	if err != nil {
+		return nil, err
+	}
+	// nonce 앞부분은 모든 시도에서 같으므로 한 번만 계산
+	data, err := pow.WorkData()
+	if err != nil {
+		return nil, err
+	}
+
 	numThreads := runtime.NumCPU()
 	results := make(chan []byte, numThreads)
 	done := make(chan struct{})
 	var once sync.Once
 
-	hashLimit, err := HashLimit(pow.Block)
-	Handle(err)
+	// 해시레이트 측정용
+	var hashes atomic.Uint64
+	start := time.Now()
 
 	for i := 0; i < numThreads; i++ {
 		go func(threadID int) {
@@ -58,12 +71,14 @@ func (pow *ProofOfWork) Run() []byte {
 					return // 다른 고루틴에서 이미 작업이 완료되었으므로 종료
 				default:
 					nonce := utils.GenerateRandomHex64bit()
-					blockRoot := pow.BlockRoot(pow.Block, nonce)
-
-					nonceBytes, err := hex.DecodeString(nonce)
-					Handle(err)
+					blockRoot := utils.ComputeSHA256(data + nonce)
+					hashes.Add(1)
 
 					if hashLimit >= blockRoot {
+						nonceBytes, err := hex.DecodeString(nonce)
+						if err != nil {
+							continue
+						}
 						results <- nonceBytes
 						// 한 번만 done 채널 닫기
 						once.Do(func() { close(done) })
@@ -74,14 +89,9 @@ func (pow *ProofOfWork) Run() []byte {
 		}(i)
 	}
 
-	select {
-	case result := <-results:
-		return result
-	case <-done:
-		// results 채널을 닫지 않음, 필요시 go 루틴에서 닫도록 처리
-	}
-
-	return nil
+	result := <-results
+	metrics.ObserveHashes(hashes.Load(), time.Since(start))
+	return result, nil
 }
 
 func (pow *ProofOfWork) GetHash(block *Block) []byte {
@@ -91,13 +101,7 @@ func (pow *ProofOfWork) GetHash(block *Block) []byte {
 }
 
 func ToHex(num int64) []byte {
-	buff := new(bytes.Buffer)
-	err := binary.Write(buff, binary.BigEndian, num)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	return buff.Bytes()
+	return binary.BigEndian.AppendUint64(nil, uint64(num))
 }
 
 // hashLimit 함수는 난이도 값을 받아서 큰 수 2^256을 난이도 값으로 나눈 결과를 64자리 16진수 문자열로 반환합니다.
@@ -106,8 +110,8 @@ func HashLimit(b *Block) (string, error) {
 
 	a := new(big.Int).Exp(big.NewInt(2), big.NewInt(256), nil)
 
-	// 난이도 값이 0이거나 음수인 경우 오류 반환
-	if diff.Cmp(big.NewInt(1)) < 0 {
+	// 난이도 값이 없거나 0이거나 음수인 경우 오류 반환
+	if diff == nil || diff.Cmp(big.NewInt(1)) < 0 {
 		return "", fmt.Errorf("invalid diff value")
 	}
 
@@ -124,15 +128,38 @@ func computeSHA256(input string) string {
 	return hex.EncodeToString(hash[:])   // 해시를 헥스 문자열로 변환
 }
 
-func (pow *ProofOfWork) BlockRoot(block *Block, nonce string) string {
-
+func (pow *ProofOfWork) BlockRoot(block *Block, nonce string) (string, error) {
 	blockInfo, err := utils.ToJSONString(block)
 	if err != nil {
-		fmt.Println("Error converting block to JSON string:", err)
-		Handle(err)
+		return "", fmt.Errorf("could not convert block to JSON string: %v", err)
 	}
-	// Handle(err)
 	combinedString := string(block.PrevHash) + blockInfo + nonce
-	sha256Hash := utils.ComputeSHA256(combinedString)
-	return sha256Hash
+	return utils.ComputeSHA256(combinedString), nil
+}
+
+// WorkData는 BlockRoot에서 nonce 앞에 오는 데이터입니다. 외부 채굴자는 sha256(WorkData + nonce 16진수)를 계산합니다.
+// 채굴할 때와 같이 Hash와 Nonce가 비어있는 블록을 기준으로 합니다.
+func (pow *ProofOfWork) WorkData() (string, error) {
+	block := *pow.Block
+	block.Hash = HexBytes{}
+	block.Nonce = HexBytes{}
+
+	blockInfo, err := utils.ToJSONString(&block)
+	if err != nil {
+		return "", err
+	}
+	return string(block.PrevHash) + blockInfo, nil
+}
+
+// Validate는 nonce가 블록의 난이도 목표를 만족하는지 검사합니다.
+func (pow *ProofOfWork) Validate(nonce []byte) bool {
+	hashLimit, err := HashLimit(pow.Block)
+	if err != nil {
+		return false
+	}
+	data, err := pow.WorkData()
+	if err != nil {
+		return false
+	}
+	return hashLimit >= utils.ComputeSHA256(data+hex.EncodeToString(nonce))
 }
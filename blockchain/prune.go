@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+const (
+	// 본문을 지울 높이가 이만큼 쌓이면 한 번에 지움. 지울 때마다 블록 캐시를 비우므로 블록마다 지우지 않음
+	pruneInterval = 32
+	// 가지치기 구독의 이벤트 버퍼 크기
+	pruneEventBuffer = 16
+)
+
+// readPrunedHeight는 본문을 남긴 가장 낮은 높이를 반환합니다. 가지치기를 하지 않았으면 0입니다.
+func readPrunedHeight(db storage.Reader) (int64, error) {
+	data, err := db.Get(prunedHeightKey)
+	if err == storage.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid pruned height encoding")
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// isPruned는 height의 정규 블록 본문이 가지치기로 지워졌거나 light 노드라서 저장하지 않았는지 확인합니다.
+// genesis의 본문은 항상 저장합니다.
+func isPruned(db storage.Reader, height int64) bool {
+	if height <= 0 {
+		return false
+	}
+	if light, err := db.Has(lightKey); err == nil && light {
+		return true
+	}
+	pruned, err := readPrunedHeight(db)
+	return err == nil && height < pruned
+}
+
+// PrunedHeight는 본문을 남긴 가장 낮은 높이를 반환합니다. 가지치기를 하지 않았으면 0입니다.
+func (chain *BlockChain) PrunedHeight() int64 {
+	pruned, _ := readPrunedHeight(chain.Database)
+	return pruned
+}
+
+// Prune은 tip에서 retain개 높이보다 오래된 정규 블록(genesis 제외)의 본문을 지웁니다.
+// 헤더와 색인은 남기므로 난이도 계산과 헤더 조회는 계속 할 수 있습니다.
+// 배치마다 지운 높이를 함께 기록하므로 중간에 멈춰도 다음에 이어서 지웁니다. 지운 블록 수를 반환합니다.
+func (chain *BlockChain) Prune(retain int64) (int, error) {
+	if retain <= 0 {
+		return 0, fmt.Errorf("invalid prune retain %d", retain)
+	}
+	db := chain.Database
+
+	from, err := readPrunedHeight(db)
+	if err != nil {
+		return 0, fmt.Errorf("could not read pruned height: %v", err)
+	}
+	from = max(from, 1)
+	to := chain.GetBestHeight() - retain + 1
+	if to <= from {
+		return 0, nil
+	}
+
+	pruned := 0
+	batch := db.NewBatch()
+	for height := from; height < to; height++ {
+		hash, err := readCanonicalHash(db, height)
+		if err != nil {
+			return pruned, err
+		}
+		batch.Delete(bodyKey(hash))
+		pruned++
+
+		if batch.Len() >= migrationBatchWrites {
+			batch.Put(prunedHeightKey, encodeHeight(height+1))
+			if err := batch.Write(); err != nil {
+				return pruned, fmt.Errorf("could not prune blocks: %v", err)
+			}
+			batch.Reset()
+		}
+	}
+	batch.Put(prunedHeightKey, encodeHeight(to))
+	if err := batch.Write(); err != nil {
+		return pruned, fmt.Errorf("could not prune blocks: %v", err)
+	}
+
+	// 캐시에 남은 블록으로 지운 본문을 돌려주지 않도록 비움
+	chain.cache.purge()
+	log.Info("blocks pruned", "count", pruned, "from", from, "to", to-1)
+	return pruned, nil
+}
+
+// RunPruner는 시작할 때와 tip이 바뀌어 지울 높이가 쌓일 때마다 retain개 높이보다 오래된 블록의 본문을 지웁니다.
+// 체인 잠금(Mu)을 잡고 지우므로 블록 추가나 되돌리기와 겹치지 않습니다. 반환하지 않습니다.
+func (chain *BlockChain) RunPruner(retain int64) {
+	prune := func() {
+		chain.Mu.Lock()
+		defer chain.Mu.Unlock()
+		if _, err := chain.Prune(retain); err != nil {
+			log.Error("pruning failed", "err", err)
+		}
+	}
+
+	sub := chain.HeadFeed.Subscribe(pruneEventBuffer)
+	prune()
+	for {
+		select {
+		case ev := <-sub.Chan():
+			if ev.Block.Height-retain+1-max(chain.PrunedHeight(), 1) >= pruneInterval {
+				prune()
+			}
+		case <-sub.Err():
+			// 가지치기 중에 버퍼가 넘쳐 끊기면 다시 구독
+			sub = chain.HeadFeed.Subscribe(pruneEventBuffer)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	b9, _ := chain.GetBlockByHeight(9)
+
+	pruned, err := chain.Prune(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1부터 10까지 지우고 11부터 20까지 남김
+	if pruned != 10 || chain.PrunedHeight() != 11 {
+		t.Fatalf("pruned %d, pruned height %d", pruned, chain.PrunedHeight())
+	}
+	for _, height := range []int64{1, 10} {
+		if _, err := chain.GetBlockByHeight(height); !errors.Is(err, ErrBlockPruned) {
+			t.Errorf("GetBlockByHeight(%d): %v", height, err)
+		}
+	}
+	if _, err := chain.GetBlock(b9.Hash); !errors.Is(err, ErrBlockPruned) {
+		t.Errorf("GetBlock of a pruned block: %v", err)
+	}
+	for _, height := range []int64{0, 11, 20} {
+		if _, err := chain.GetBlockByHeight(height); err != nil {
+			t.Errorf("GetBlockByHeight(%d): %v", height, err)
+		}
+	}
+	if header, err := chain.GetHeaderByHeight(9); err != nil || header.Height != 9 {
+		t.Fatalf("GetHeaderByHeight of a pruned block: %v, %v", header, err)
+	}
+
+	// 다시 지워도 지울 본문이 없음
+	if pruned, err := chain.Prune(10); err != nil || pruned != 0 {
+		t.Fatalf("second prune: %d, %v", pruned, err)
+	}
+
+	// 헤더로 난이도를 계산하므로 지운 뒤에도 블록을 이어 붙일 수 있음
+	extendTestChain(t, chain, 5)
+	if pruned, err := chain.Prune(10); err != nil || pruned != 5 || chain.PrunedHeight() != 16 {
+		t.Fatalf("prune after extending: %d, %v, pruned height %d", pruned, err, chain.PrunedHeight())
+	}
+}
+
+func TestPruneRejectsRetain(t *testing.T) {
+	chain := newTestChain(t, 5, 0)
+	if _, err := chain.Prune(0); err == nil {
+		t.Fatal("prune with retain 0 accepted")
+	}
+	// 남길 높이보다 짧은 체인은 지우지 않음
+	if pruned, err := chain.Prune(10); err != nil || pruned != 0 || chain.PrunedHeight() != 0 {
+		t.Fatalf("pruned %d, %v, pruned height %d", pruned, err, chain.PrunedHeight())
+	}
+}
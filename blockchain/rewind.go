@@ -0,0 +1,132 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Rewind는 height보다 높은 블록을 체인에서 빼고 tip을 height의 블록으로 되돌립니다.
+// 뺀 블록은 되돌린 블록 보관소('r')로 옮기며, 모든 변경은 한 배치로 기록됩니다.
+// 되돌린 블록 수를 반환합니다.
+func (chain *BlockChain) Rewind(height int64) (int, error) {
+	if chain.light {
+		return 0, fmt.Errorf("cannot rewind a light node, it stores no block bodies")
+	}
+	oldHead := chain.GetLastBlock()
+	if height < 0 {
+		return 0, fmt.Errorf("invalid rewind height %d", height)
+	}
+	if height >= oldHead.Height {
+		return 0, fmt.Errorf("cannot rewind to height %d, chain height is %d", height, oldHead.Height)
+	}
+
+	newHead, err := chain.GetBlockByHeight(height)
+	if err != nil {
+		return 0, fmt.Errorf("could not read block at height %d: %w", height, err)
+	}
+
+	batch := chain.Database.NewBatch()
+	for h := oldHead.Height; h > height; h-- {
+		block, err := chain.GetBlockByHeight(h)
+		if err != nil {
+			return 0, fmt.Errorf("could not read block at height %d: %w", h, err)
+		}
+		data, err := block.Serialize()
+		if err != nil {
+			return 0, err
+		}
+		batch.Put(rewoundKey(block.Height, block.Hash), data)
+		batch.Delete(headerKey(block.Hash))
+		batch.Delete(bodyKey(block.Hash))
+		batch.Delete(blockHeightKey(block.Hash))
+		batch.Delete(canonicalHashKey(h))
+	}
+	batch.Put(lastHashKey, newHead.Hash)
+	if err := batch.Write(); err != nil {
+		return 0, fmt.Errorf("could not rewind chain: %v", err)
+	}
+
+	chain.cache.purge()
+	chain.setHead(newHead)
+	observeHead(newHead)
+	chain.ReorgFeed.Send(ChainReorgEvent{OldHeight: oldHead.Height, OldHash: oldHead.Hash, NewHeight: newHead.Height, NewHash: newHead.Hash})
+
+	removed := int(oldHead.Height - height)
+	log.Info("chain rewound", "from", oldHead.Height, "to", height, "removed", removed)
+	return removed, nil
+}
+
+// ReapplyRewound는 되돌린 블록 중 tip을 잇는 블록을 낮은 높이부터 검증하며 다시 추가합니다.
+// 다시 추가한 블록과 이미 체인에 있는 블록은 보관소에서 지우고, 잇지 못하는 블록은 남깁니다.
+// 다시 추가한 블록 수를 반환합니다.
+func (chain *BlockChain) ReapplyRewound() (int, error) {
+	db := chain.Database
+
+	var keys [][]byte
+	var blocks []*Block
+	iter := db.NewIterator(rewoundPrefix)
+	for iter.Next() {
+		block, err := Deserialize(iter.Value())
+		if err != nil {
+			iter.Release()
+			return 0, fmt.Errorf("could not decode rewound block %x: %v", iter.Key(), err)
+		}
+		keys = append(keys, append([]byte{}, iter.Key()...))
+		blocks = append(blocks, block)
+	}
+	err := iter.Error()
+	iter.Release()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for i, block := range blocks {
+		known, err := hasBlock(db, block.Hash)
+		if err != nil {
+			return applied, err
+		}
+
+		if !known {
+			tip := chain.GetLastBlock()
+			if block.Height != tip.Height+1 || !bytes.Equal(block.PrevHash, tip.Hash) {
+				continue
+			}
+			if err := chain.ImportBlock(block); err != nil {
+				return applied, fmt.Errorf("could not reapply block %x at height %d: %w", block.Hash, block.Height, err)
+			}
+			applied++
+		}
+		if err := db.Delete(keys[i]); err != nil {
+			return applied, err
+		}
+	}
+
+	if applied > 0 {
+		log.Info("rewound blocks reapplied", "count", applied, "height", chain.GetBestHeight())
+	}
+	return applied, nil
+}
+
+// DiscardRewound는 다시 적용하지 않을 blocks를 되돌린 블록 보관소에서 지웁니다. 보관소에 없는 블록은 무시합니다.
+func (chain *BlockChain) DiscardRewound(blocks []*Block) error {
+	batch := chain.Database.NewBatch()
+	for _, block := range blocks {
+		batch.Delete(rewoundKey(block.Height, block.Hash))
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("could not discard rewound blocks: %v", err)
+	}
+	return nil
+}
+
+// RewoundCount는 되돌린 블록 보관소의 블록 수를 반환합니다.
+func (chain *BlockChain) RewoundCount() (int, error) {
+	count := 0
+	iter := chain.Database.NewIterator(rewoundPrefix)
+	defer iter.Release()
+	for iter.Next() {
+		count++
+	}
+	return count, iter.Error()
+}
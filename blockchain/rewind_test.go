@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+func TestRewind(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	oldTip := chain.GetLastBlock()
+	removedBlock, _ := chain.GetBlockByHeight(15)
+	sub := chain.ReorgFeed.Subscribe(1)
+	defer sub.Unsubscribe()
+
+	removed, err := chain.Rewind(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 10 || chain.GetBestHeight() != 10 {
+		t.Fatalf("removed %d, height %d", removed, chain.GetBestHeight())
+	}
+	if count, _ := chain.RewoundCount(); count != 10 {
+		t.Fatalf("%d rewound blocks stored, want 10", count)
+	}
+	if _, err := chain.GetBlock(removedBlock.Hash); err == nil {
+		t.Fatal("rewound block still in the chain")
+	}
+	ev := <-sub.Chan()
+	if ev.OldHeight != 20 || !bytes.Equal(ev.OldHash, oldTip.Hash) || ev.NewHeight != 10 || !bytes.Equal(ev.NewHash, chain.GetLastBlock().Hash) {
+		t.Fatalf("reorg event %+v", ev)
+	}
+
+	// 저장된 tip도 되돌린 높이를 가리킴
+	if lastHash, _ := chain.GetLastBlockHash(); !bytes.Equal(lastHash, chain.GetLastBlock().Hash) {
+		t.Fatal("stored tip differs from head")
+	}
+
+	for _, height := range []int64{-1, 10, 11} {
+		if _, err := chain.Rewind(height); err == nil {
+			t.Errorf("rewind to %d accepted at height 10", height)
+		}
+	}
+}
+
+func TestReapplyRewound(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	oldTip := chain.GetLastBlock()
+	if _, err := chain.Rewind(10); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := chain.ReapplyRewound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 10 || !bytes.Equal(chain.GetLastBlock().Hash, oldTip.Hash) {
+		t.Fatalf("applied %d, tip at height %d", applied, chain.GetBestHeight())
+	}
+	if count, _ := chain.RewoundCount(); count != 0 {
+		t.Fatalf("%d rewound blocks left", count)
+	}
+}
+
+// 체인에 이미 있는 블록은 보관소에서 지우고, tip을 잇지 못하는 블록은 남김
+func TestReapplyRewoundPartial(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	b11, _ := chain.GetBlockByHeight(11)
+	if _, err := chain.Rewind(10); err != nil {
+		t.Fatal(err)
+	}
+
+	// 11은 되돌린 블록과 같은 블록을 다시 받음
+	if err := chain.ImportBlock(b11); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := chain.ReapplyRewound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 9 || chain.GetBestHeight() != 20 {
+		t.Fatalf("applied %d, height %d", applied, chain.GetBestHeight())
+	}
+
+	// 다른 갈래로 옮긴 뒤에는 되돌린 블록이 tip을 잇지 못함
+	if _, err := chain.Rewind(15); err != nil {
+		t.Fatal(err)
+	}
+	parent := chain.GetLastBlock()
+	side := newTestBlock(t, parent, "side")
+	if err := chain.ImportBlock(side); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := chain.ReapplyRewound(); err != nil || applied != 0 {
+		t.Fatalf("applied %d, %v on another branch", applied, err)
+	}
+	if count, _ := chain.RewoundCount(); count != 5 {
+		t.Fatalf("%d rewound blocks left, want 5", count)
+	}
+	if !bytes.Equal(chain.GetLastBlock().Hash, side.Hash) {
+		t.Fatal("tip moved off the side branch")
+	}
+}
+
+func TestDiscardRewound(t *testing.T) {
+	chain := newTestChain(t, 20, 0)
+	var discard []*Block
+	for height := int64(11); height <= 13; height++ {
+		block, _ := chain.GetBlockByHeight(height)
+		discard = append(discard, block)
+	}
+	if _, err := chain.Rewind(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.DiscardRewound(discard); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := chain.RewoundCount(); count != 7 {
+		t.Fatalf("%d rewound blocks left, want 7", count)
+	}
+	// 보관소에 없는 블록은 무시
+	if err := chain.DiscardRewound(discard); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRewindLightChain(t *testing.T) {
+	chain, _ := newLightTestChain(t, 3)
+	if _, err := chain.Rewind(1); err == nil {
+		t.Fatal("light chain rewound")
+	}
+}
+
+func TestResetDatabase(t *testing.T) {
+	chain := newTestChain(t, 20, 16)
+	genesis := chain.GetGenesisHash()
+	if _, err := chain.Rewind(15); err != nil {
+		t.Fatal(err)
+	}
+	sub := chain.ReorgFeed.Subscribe(1)
+	defer sub.Unsubscribe()
+
+	if err := chain.ResetDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if chain.GetBestHeight() != 0 || chain.LastHash != nil {
+		t.Fatalf("height %d, last hash %x after reset", chain.GetBestHeight(), chain.LastHash)
+	}
+	if _, err := chain.GetBlockByHeight(0); err == nil {
+		t.Fatal("genesis block left after reset")
+	}
+	for _, prefix := range [][]byte{headerPrefix, bodyPrefix, blockHeightPrefix, canonicalHashPrefix} {
+		iter := chain.Database.NewIterator(prefix)
+		if iter.Next() {
+			t.Fatalf("key %x left after reset", iter.Key())
+		}
+		iter.Release()
+	}
+	// genesis 정보와 되돌린 블록은 남김
+	if !bytes.Equal(chain.GetGenesisHash(), genesis) {
+		t.Fatal("genesis hash removed by reset")
+	}
+	if count, _ := chain.RewoundCount(); count != 5 {
+		t.Fatalf("%d rewound blocks left, want 5", count)
+	}
+	if ev := <-sub.Chan(); ev.OldHeight != 15 || ev.NewHash != nil {
+		t.Fatalf("reorg event %+v", ev)
+	}
+}
+
+// 지우는 중에 멈춰도 빈 체인으로 보이고, 다시 열 때 마저 지움
+func TestResetDatabaseInterrupted(t *testing.T) {
+	chain := newTestChain(t, migrationBatchWrites/2, 0)
+	store := &failingStore{Store: chain.Database, fail: func(write int) bool { return write == 2 }}
+	chain.Database = store
+
+	if err := chain.ResetDatabase(); err == nil {
+		t.Fatal("reset succeeded on a failing store")
+	}
+	if ok, _ := store.Has(resetPendingKey); !ok {
+		t.Fatal("no reset marker after an interrupted reset")
+	}
+	if _, err := store.Get(lastHashKey); err != storage.ErrNotFound {
+		t.Fatalf("last block hash left after an interrupted reset: %v", err)
+	}
+
+	store.fail = nil
+	if err := finishInterrupted(store); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Has(resetPendingKey); ok {
+		t.Fatal("reset marker left after recovery")
+	}
+	iter := store.NewIterator(headerPrefix)
+	defer iter.Release()
+	if iter.Next() {
+		t.Fatalf("header %x left after recovery", iter.Key())
+	}
+}
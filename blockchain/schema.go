@@ -0,0 +1,345 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// 데이터베이스 키 스키마(버전 2). 모든 키는 한 바이트 접두사로 시작합니다.
+//
+//	'h' + hash            → 블록 헤더
+//	'b' + hash            → 블록 본문(헤더를 제외한 나머지 필드)
+//	'n' + hash            → 블록 높이(8바이트 big-endian)
+//	'H' + height(8바이트 big-endian) → 정규 체인의 블록 해시
+//	'm' + 이름            → 메타데이터(lastHash, genesis, genesis-spec, params, schema-version, reset-pending, restore-pending, pruned-height, backfill-height, light)
+//	'r' + height(8바이트 big-endian) + hash → Rewind로 체인에서 뺀 블록(Serialize 형식)
+//
+// pruned 노드는 pruned-height보다 낮은 정규 블록(genesis 제외)의 본문을 지우고 헤더와 색인만 남깁니다.
+// 스냅샷으로 시작한 full 노드는 backfill-height보다 낮은 정규 블록(genesis 제외)의 본문을 아직 받지 못했으며 peer에게서 받아 채웁니다.
+// light 표시가 있는 데이터베이스는 genesis 외의 본문을 저장하지 않습니다.
+//
+// 높이는 big-endian이므로 'H' 접두사로 순회하면 높이 순서대로 나옵니다.
+// 헤더와 본문은 encoding.go의 바이너리 형식입니다(버전 1은 JSON).
+const schemaVersion = 2
+
+var (
+	headerPrefix        = []byte("h")
+	bodyPrefix          = []byte("b")
+	blockHeightPrefix   = []byte("n")
+	canonicalHashPrefix = []byte("H")
+	metaPrefix          = []byte("m")
+	rewoundPrefix       = []byte("r")
+)
+
+// 메타데이터 키
+var (
+	lastHashKey      = metaKey("lastHash")
+	genesisHashKey   = metaKey("genesis")
+	genesisSpecKey   = metaKey("genesis-spec")
+	chainParamsKey   = metaKey("params")
+	schemaVersionKey = metaKey("schema-version")
+	resetPendingKey  = metaKey("reset-pending")
+	// 스냅샷 복원 중 표시. 값은 복원 중인 스냅샷의 내용 해시
+	restorePendingKey = metaKey("restore-pending")
+	prunedHeightKey   = metaKey("pruned-height")
+	backfillHeightKey = metaKey("backfill-height")
+	lightKey          = metaKey("light")
+)
+
+func metaKey(name string) []byte {
+	return append(append([]byte{}, metaPrefix...), name...)
+}
+
+func headerKey(hash []byte) []byte {
+	return append(append([]byte{}, headerPrefix...), hash...)
+}
+
+func bodyKey(hash []byte) []byte {
+	return append(append([]byte{}, bodyPrefix...), hash...)
+}
+
+func blockHeightKey(hash []byte) []byte {
+	return append(append([]byte{}, blockHeightPrefix...), hash...)
+}
+
+func canonicalHashKey(height int64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, canonicalHashPrefix...), uint64(height))
+}
+
+func rewoundKey(height int64, hash []byte) []byte {
+	return append(binary.BigEndian.AppendUint64(append([]byte{}, rewoundPrefix...), uint64(height)), hash...)
+}
+
+func encodeHeight(height int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(height))
+}
+
+// blockBody는 헤더에 없는 블록 필드입니다.
+type blockBody struct {
+	MainBlockHeight int
+	MainBlockHash   HexBytes
+	Validator       HexBytes
+	ExtraData       HexBytes `json:",omitempty"`
+}
+
+func splitBlock(b *Block) (*Header, *blockBody) {
+	return b.Header(), &blockBody{
+		MainBlockHeight: b.MainBlockHeight,
+		MainBlockHash:   b.MainBlockHash,
+		Validator:       b.Validator,
+		ExtraData:       b.ExtraData,
+	}
+}
+
+func joinBlock(h *Header, body *blockBody) *Block {
+	return &Block{
+		Timestamp:       h.Timestamp,
+		Hash:            h.Hash,
+		PrevHash:        h.PrevHash,
+		MainBlockHeight: body.MainBlockHeight,
+		MainBlockHash:   body.MainBlockHash,
+		Nonce:           h.Nonce,
+		Height:          h.Height,
+		Difficulty:      h.Difficulty,
+		Miner:           h.Miner,
+		Validator:       body.Validator,
+		ExtraData:       body.ExtraData,
+	}
+}
+
+// writeBlock은 블록의 헤더, 본문, 해시→높이 색인을 배치에 기록합니다. 정규 체인 색인은 따로 기록합니다.
+func writeBlock(batch storage.Batch, b *Block) error {
+	_, body := splitBlock(b)
+	if err := writeHeader(batch, b); err != nil {
+		return err
+	}
+	batch.Put(bodyKey(b.Hash), encodeBody(body))
+	return nil
+}
+
+// writeHeader는 블록의 헤더와 해시→높이 색인만 배치에 기록합니다.
+func writeHeader(batch storage.Batch, b *Block) error {
+	data, err := encodeHeader(b.Header())
+	if err != nil {
+		return fmt.Errorf("could not encode block %x: %v", []byte(b.Hash), err)
+	}
+	batch.Put(headerKey(b.Hash), data)
+	batch.Put(blockHeightKey(b.Hash), encodeHeight(b.Height))
+	return nil
+}
+
+// writeCanonical은 height의 정규 블록 해시와 tip을 배치에 기록합니다.
+func writeCanonical(batch storage.Batch, b *Block) {
+	batch.Put(canonicalHashKey(b.Height), b.Hash)
+	batch.Put(lastHashKey, b.Hash)
+}
+
+// readHeader는 해시로 블록 헤더를 읽습니다. 없으면 ErrBlockNotFound를 감싼 오류를 반환합니다.
+func readHeader(db storage.Reader, hash []byte) (*Header, error) {
+	headerData, err := db.Get(headerKey(hash))
+	if err == storage.ErrNotFound {
+		return nil, fmt.Errorf("%w: hash %x", ErrBlockNotFound, hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read header %x: %v", hash, err)
+	}
+	header, err := decodeHeader(headerData)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode header %x: %v", hash, err)
+	}
+	return header, nil
+}
+
+// readBlock은 해시로 블록을 읽습니다. 없으면 ErrBlockNotFound를, 본문을 지운 블록이면 ErrBlockPruned를,
+// 스냅샷 복원 뒤 아직 본문을 받지 못한 블록이면 ErrBodyNotSynced를 감싼 오류를 반환합니다.
+func readBlock(db storage.Reader, hash []byte) (*Block, error) {
+	header, err := readHeader(db, hash)
+	if err != nil {
+		return nil, err
+	}
+	bodyData, err := db.Get(bodyKey(hash))
+	if err == storage.ErrNotFound {
+		if isPruned(db, header.Height) {
+			return nil, fmt.Errorf("%w: height %d", ErrBlockPruned, header.Height)
+		}
+		if isBackfilling(db, header.Height) {
+			return nil, fmt.Errorf("%w: height %d", ErrBodyNotSynced, header.Height)
+		}
+		return nil, fmt.Errorf("%w: body of %x", ErrBlockNotFound, hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read body %x: %v", hash, err)
+	}
+
+	body, err := decodeBody(bodyData)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode body %x: %v", hash, err)
+	}
+	return joinBlock(header, body), nil
+}
+
+// hasBlock은 해시의 블록이 저장되어 있는지 확인합니다.
+func hasBlock(db storage.Reader, hash []byte) (bool, error) {
+	return db.Has(blockHeightKey(hash))
+}
+
+// readCanonicalHash는 정규 체인에서 height의 블록 해시를 읽습니다.
+func readCanonicalHash(db storage.Reader, height int64) ([]byte, error) {
+	hash, err := db.Get(canonicalHashKey(height))
+	if err == storage.ErrNotFound {
+		return nil, fmt.Errorf("%w: height %d", ErrBlockNotFound, height)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read block hash at height %d: %v", height, err)
+	}
+	return hash, nil
+}
+
+// readSchemaVersion은 저장된 스키마 버전을 반환합니다. 버전이 없으면 0입니다.
+func readSchemaVersion(db storage.Reader) (uint64, error) {
+	data, err := db.Get(schemaVersionKey)
+	if err == storage.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid schema version encoding")
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func writeSchemaVersion(batch storage.Batch) {
+	batch.Put(schemaVersionKey, binary.BigEndian.AppendUint64(nil, schemaVersion))
+}
+
+// 이전 레이아웃(버전 0)의 키. 블록은 32바이트 해시 자체를 키로 사용
+var (
+	legacyLastHashKey  = []byte("lh")
+	legacyHeightPrefix = []byte("height-")
+	legacyMetaKeys     = map[string][]byte{"genesis": genesisHashKey, "genesis-spec": genesisSpecKey, "params": chainParamsKey}
+)
+
+const (
+	legacyHashLen = 32
+	// 마이그레이션 중 한 배치에 모을 최대 쓰기 수
+	migrationBatchWrites = 1000
+)
+
+// migrations는 스키마 버전별로 키 하나를 현재 스키마로 옮기는 함수입니다. 옮긴 키가 있으면 true를 반환합니다.
+var migrations = map[uint64]func(batch storage.Batch, key, value []byte) (bool, error){
+	0: migrateLegacyKey,
+	1: migrateJSONKey,
+}
+
+// migrateSchema는 스키마 버전을 확인하고 이전 버전의 데이터베이스를 현재 스키마로 옮깁니다.
+// 옮긴 키는 같은 배치에서 지우거나 덮어쓰므로, 중간에 멈춰도 다시 열면 남은 키부터 이어서 옮깁니다.
+func migrateSchema(db storage.Store) error {
+	version, err := readSchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %v", err)
+	}
+	if version == schemaVersion {
+		return nil
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, schemaVersion)
+	}
+	migrate := migrations[version]
+
+	log.Info("migrating database to new key schema", "from", version, "to", schemaVersion)
+	migrated := 0
+	batch := db.NewBatch()
+	iter := db.NewIterator(nil)
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		value := append([]byte{}, iter.Value()...)
+
+		ok, err := migrate(batch, key, value)
+		if err != nil {
+			iter.Release()
+			return fmt.Errorf("could not migrate key %x: %v", key, err)
+		}
+		if !ok {
+			continue
+		}
+		migrated++
+
+		if batch.Len() >= migrationBatchWrites {
+			if err := batch.Write(); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	err = iter.Error()
+	iter.Release()
+	if err != nil {
+		return err
+	}
+
+	writeSchemaVersion(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("database migration complete", "keys", migrated)
+	return nil
+}
+
+// migrateLegacyKey는 접두사가 없던 레이아웃(버전 0)의 키를 새 키로 옮기고 이전 키를 지웁니다.
+func migrateLegacyKey(batch storage.Batch, key, value []byte) (bool, error) {
+	switch {
+	case string(key) == string(legacyLastHashKey):
+		batch.Put(lastHashKey, value)
+	case len(key) > len(legacyHeightPrefix) && string(key[:len(legacyHeightPrefix)]) == string(legacyHeightPrefix):
+		var height int64
+		if _, err := fmt.Sscanf(string(key[len(legacyHeightPrefix):]), "%d", &height); err != nil {
+			return false, err
+		}
+		batch.Put(canonicalHashKey(height), value)
+	case legacyMetaKeys[string(key)] != nil:
+		batch.Put(legacyMetaKeys[string(key)], value)
+	case len(key) == legacyHashLen:
+		block, err := Deserialize(value)
+		if err != nil {
+			return false, err
+		}
+		if err := writeBlock(batch, block); err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+	batch.Delete(key)
+	return true, nil
+}
+
+// migrateJSONKey는 버전 1의 JSON 헤더와 본문을 바이너리 형식으로 다시 씁니다.
+func migrateJSONKey(batch storage.Batch, key, value []byte) (bool, error) {
+	if len(key) != 1+legacyHashLen || len(value) == 0 || value[0] != '{' {
+		return false, nil
+	}
+
+	switch key[0] {
+	case headerPrefix[0]:
+		var header Header
+		if err := json.Unmarshal(value, &header); err != nil {
+			return false, err
+		}
+		data, err := encodeHeader(&header)
+		if err != nil {
+			return false, err
+		}
+		batch.Put(key, data)
+	case bodyPrefix[0]:
+		var body blockBody
+		if err := json.Unmarshal(value, &body); err != nil {
+			return false, err
+		}
+		batch.Put(key, encodeBody(&body))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
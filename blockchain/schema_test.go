@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// 버전 0 데이터베이스: 블록은 해시 자체가 키이고 값은 JSON
+func TestMigrateSchemaV0(t *testing.T) {
+	db := storage.NewMemory()
+	// 한 배치의 쓰기 한도를 넘도록 블록 수를 정함
+	blocks := sampleBlocks(migrationBatchWrites/2 + 10)
+	for _, b := range blocks {
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Put(b.Hash, data)
+		db.Put([]byte(fmt.Sprintf("height-%d", b.Height)), b.Hash)
+	}
+	tip := blocks[len(blocks)-1]
+	db.Put(legacyLastHashKey, tip.Hash)
+	db.Put([]byte("genesis"), blocks[0].Hash)
+	// 알 수 없는 키는 그대로 두어야 함
+	db.Put([]byte("unrelated"), []byte("v"))
+
+	if err := migrateSchema(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := readSchemaVersion(db); err != nil || v != schemaVersion {
+		t.Fatalf("schema version: got %d, %v", v, err)
+	}
+	if got, err := db.Get(lastHashKey); err != nil || !bytes.Equal(got, tip.Hash) {
+		t.Fatalf("lastHash: got %x, %v", got, err)
+	}
+	if got, err := db.Get(genesisHashKey); err != nil || !bytes.Equal(got, blocks[0].Hash) {
+		t.Fatalf("genesis: got %x, %v", got, err)
+	}
+	for _, want := range blocks {
+		hash, err := readCanonicalHash(db, want.Height)
+		if err != nil || !bytes.Equal(hash, want.Hash) {
+			t.Fatalf("canonical hash at %d: got %x, %v", want.Height, hash, err)
+		}
+		got, err := readBlock(db, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("block %d: got %+v, want %+v", want.Height, got, want)
+		}
+		if ok, _ := db.Has(want.Hash); ok {
+			t.Fatalf("legacy block key %x not deleted", want.Hash)
+		}
+	}
+	for _, key := range [][]byte{legacyLastHashKey, []byte("height-1"), []byte("genesis")} {
+		if ok, _ := db.Has(key); ok {
+			t.Fatalf("legacy key %q not deleted", key)
+		}
+	}
+	if ok, _ := db.Has([]byte("unrelated")); !ok {
+		t.Fatal("unrelated key deleted")
+	}
+
+	// 현재 버전이면 아무것도 하지 않음
+	if err := migrateSchema(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 버전 1 데이터베이스: 헤더와 본문이 JSON
+func TestMigrateSchemaV1(t *testing.T) {
+	db := storage.NewMemory()
+	block := sampleBlocks(1)[0]
+	block.ExtraData = HexBytes("extra")
+	header, body := splitBlock(block)
+
+	headerData, _ := json.Marshal(header)
+	bodyData, _ := json.Marshal(body)
+	db.Put(headerKey(block.Hash), headerData)
+	db.Put(bodyKey(block.Hash), bodyData)
+	db.Put(blockHeightKey(block.Hash), encodeHeight(block.Height))
+	db.Put(schemaVersionKey, encodeHeight(1))
+
+	if err := migrateSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := db.Get(headerKey(block.Hash)); len(data) == 0 || data[0] != blockEncodingVersion {
+		t.Fatalf("header not rewritten: %q", data)
+	}
+	got, err := readBlock(db, block.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, block) {
+		t.Fatalf("got %+v, want %+v", got, block)
+	}
+}
+
+func TestMigrateSchemaNewer(t *testing.T) {
+	db := storage.NewMemory()
+	db.Put(schemaVersionKey, encodeHeight(schemaVersion+1))
+	if err := migrateSchema(db); err == nil {
+		t.Fatal("migrated a database with a newer schema")
+	}
+}
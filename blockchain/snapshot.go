@@ -0,0 +1,444 @@
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/params"
+)
+
+// 스냅샷 파일 형식(버전 2):
+//
+//	magic("MCSNAP") version(1) headerLen(4) header(JSON) { recordLen(4) blockHeader(encodeHeader) }* 0(4)
+//	genesisLen(4) genesis(Serialize) tipLen(4) tip(Serialize) contentHash(32)
+//
+// 블록 헤더는 genesis부터 스냅샷 높이까지 순서대로 기록되고, 본문은 genesis와 스냅샷 높이(tip)의 블록만 기록됩니다.
+// 정규 체인 색인과 해시→높이 색인은 헤더에서 그대로 만들어지므로 따로 기록하지 않습니다.
+// 복원한 노드에서 그 사이 블록은 헤더만 남습니다. pruned 노드는 이를 가지치기한 것으로 기록하고,
+// full 노드는 받지 못한 높이(backfill-height)를 기록해 본문을 peer에게서 받아 채웁니다(Backfill).
+// contentHash는 contentHash 앞의 모든 바이트의 sha256이며, 같은 체인의 같은 높이에서는 어느 노드가 만들어도 같습니다.
+const (
+	snapshotMagic   = "MCSNAP"
+	snapshotVersion = 2
+)
+
+// 스냅샷을 복원할 때 반환하는 오류
+var (
+	ErrSnapshotHash      = errors.New("snapshot content hash mismatch")
+	ErrSnapshotUntrusted = errors.New("snapshot hash does not match the trusted checkpoint")
+)
+
+// SnapshotHeader는 스냅샷의 머리말입니다.
+type SnapshotHeader struct {
+	ChainId     string              `json:"chainId"`
+	Height      int64               `json:"height"`
+	Hash        HexBytes            `json:"hash"` // height 블록의 해시
+	GenesisHash HexBytes            `json:"genesisHash"`
+	Params      *params.ChainParams `json:"params"`
+}
+
+// TrustedSnapshot은 설정의 신뢰하는 스냅샷 체크포인트(snapshotHeight, snapshotHash)를 반환합니다.
+// 설정하지 않았으면 ok가 false입니다.
+func TrustedSnapshot() (height int64, hash []byte, ok bool) {
+	cfg := config.GlobalConfig
+	if cfg.SnapshotHash == "" {
+		return 0, nil, false
+	}
+	hash, err := hex.DecodeString(cfg.SnapshotHash)
+	if err != nil {
+		return 0, nil, false
+	}
+	return cfg.SnapshotHeight, hash, true
+}
+
+// CreateSnapshot은 genesis부터 height까지의 블록 헤더와 genesis, height 블록으로 스냅샷을 만들어 w에 기록하고 내용 해시를 반환합니다.
+// 가지치기한 노드도 height 블록의 본문이 남아 있으면 만들 수 있습니다.
+func (chain *BlockChain) CreateSnapshot(w io.Writer, height int64) ([]byte, error) {
+	if best := chain.GetBestHeight(); height < 0 || height > best {
+		return nil, fmt.Errorf("invalid snapshot height %d, best height is %d", height, best)
+	}
+	genesis, err := chain.GetBlockByHeight(0)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := chain.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json.Marshal(&SnapshotHeader{
+		ChainId:     chain.ChainId,
+		Height:      height,
+		Hash:        tip.Hash,
+		GenesisHash: chain.GetGenesisHash(),
+		Params:      chain.Params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(w)
+	h := sha256.New()
+	hw := io.MultiWriter(bw, h)
+
+	io.WriteString(hw, snapshotMagic)
+	hw.Write([]byte{snapshotVersion})
+	if err := writeRecord(hw, header); err != nil {
+		return nil, err
+	}
+	for i := int64(0); i <= height; i++ {
+		header, err := chain.GetHeaderByHeight(i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encodeHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode header at height %d: %v", i, err)
+		}
+		if err := writeRecord(hw, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeRecord(hw, nil); err != nil {
+		return nil, err
+	}
+	for _, block := range []*Block{genesis, tip} {
+		data, err := block.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeRecord(hw, data); err != nil {
+			return nil, err
+		}
+	}
+
+	sum := h.Sum(nil)
+	bw.Write(sum)
+	return sum, bw.Flush()
+}
+
+// snapshotReader는 스냅샷의 블록 헤더를 순서대로 읽으며 내용 해시를 계산합니다.
+type snapshotReader struct {
+	Header SnapshotHeader
+	raw    *bufio.Reader
+	r      io.Reader // raw를 읽으며 해시를 계산
+	h      hash.Hash
+	next   int64
+	prev   []byte
+	first  *Header // genesis 헤더
+	last   *Header // 스냅샷 높이의 헤더
+}
+
+func newSnapshotReader(r io.Reader) (*snapshotReader, error) {
+	raw := bufio.NewReader(r)
+	h := sha256.New()
+	sr := &snapshotReader{raw: raw, r: io.TeeReader(raw, h), h: h}
+
+	magic := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(sr.r, magic); err != nil {
+		return nil, fmt.Errorf("could not read snapshot header: %v", err)
+	}
+	if !bytes.Equal(magic[:len(snapshotMagic)], []byte(snapshotMagic)) {
+		return nil, fmt.Errorf("not a chain snapshot")
+	}
+	if v := magic[len(snapshotMagic)]; v != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", v)
+	}
+
+	data, err := readRecord(sr.r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshot header: %v", err)
+	}
+	if err := json.Unmarshal(data, &sr.Header); err != nil {
+		return nil, fmt.Errorf("could not decode snapshot header: %v", err)
+	}
+	if sr.Header.Params == nil {
+		return nil, fmt.Errorf("snapshot header has no chain params")
+	}
+	return sr, nil
+}
+
+// nextHeader는 다음 블록 헤더를 반환하며 높이와 이전 해시 연결을 검사합니다.
+// 마지막 헤더 뒤에서는 io.EOF를 반환하며, 이어서 finish를 호출해야 합니다.
+func (sr *snapshotReader) nextHeader() (*Header, error) {
+	data, err := readRecord(sr.r)
+	if err == io.EOF {
+		return nil, fmt.Errorf("truncated snapshot")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		if sr.next != sr.Header.Height+1 {
+			return nil, fmt.Errorf("snapshot ends at height %d, header says %d", sr.next-1, sr.Header.Height)
+		}
+		if !bytes.Equal(sr.prev, sr.Header.Hash) {
+			return nil, fmt.Errorf("snapshot ends at block %x, header says %x", sr.prev, []byte(sr.Header.Hash))
+		}
+		return nil, io.EOF
+	}
+
+	header, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.Height != sr.next {
+		return nil, fmt.Errorf("snapshot header at position %d has height %d", sr.next, header.Height)
+	}
+	if header.Height == 0 {
+		if !bytes.Equal(header.Hash, sr.Header.GenesisHash) {
+			return nil, fmt.Errorf("snapshot genesis %x does not match header %x", []byte(header.Hash), []byte(sr.Header.GenesisHash))
+		}
+		sr.first = header
+	} else if !bytes.Equal(header.PrevHash, sr.prev) {
+		return nil, fmt.Errorf("%w: snapshot block %d", ErrPrevHashMismatch, header.Height)
+	}
+	sr.next++
+	sr.prev = header.Hash
+	sr.last = header
+	return header, nil
+}
+
+// finish는 헤더 뒤의 genesis와 스냅샷 높이 블록을 읽어 헤더와 같은지 확인하고, 내용 해시를 확인해 반환합니다.
+// 두 블록의 본문은 VerifyPoW로 검증합니다. genesis는 채굴된 블록이 아니므로 해시가 내용과 맞는지만 확인합니다.
+func (sr *snapshotReader) finish() (genesis, tip *Block, sum []byte, err error) {
+	var blocks [2]*Block
+	for i, header := range []*Header{sr.first, sr.last} {
+		data, err := readRecord(sr.r)
+		if err == io.EOF || (err == nil && len(data) == 0) {
+			return nil, nil, nil, fmt.Errorf("truncated snapshot")
+		} else if err != nil {
+			return nil, nil, nil, err
+		}
+		block, err := Deserialize(data)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !sameHeader(block.Header(), header) {
+			return nil, nil, nil, fmt.Errorf("snapshot block %x does not match its header", []byte(block.Hash))
+		}
+		if err := VerifyPoW(block); err != nil {
+			return nil, nil, nil, err
+		}
+		blocks[i] = block
+	}
+
+	sum = sr.h.Sum(nil)
+	stored := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(sr.raw, stored); err != nil {
+		return nil, nil, nil, fmt.Errorf("truncated snapshot: %v", err)
+	}
+	if !bytes.Equal(sum, stored) {
+		return nil, nil, nil, fmt.Errorf("%w: computed %x, stored %x", ErrSnapshotHash, sum, stored)
+	}
+	return blocks[0], blocks[1], sum, nil
+}
+
+// VerifySnapshot은 스냅샷 전체를 읽어 헤더 연결과 내용 해시를 검사하고 머리말과 내용 해시를 반환합니다.
+func VerifySnapshot(r io.Reader) (*SnapshotHeader, []byte, error) {
+	sr, err := newSnapshotReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		_, err := sr.nextHeader()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	_, _, sum, err := sr.finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &sr.Header, sum, nil
+}
+
+// RestoreSnapshot은 내용 해시가 trusted와 같은 스냅샷의 헤더를 정규 체인으로 기록하고 tip을 스냅샷 높이로 옮깁니다.
+// genesis와 tip 사이 블록은 본문이 없으므로 pruned 노드는 tip 아래를 가지치기한 것으로, full 노드는 본문을 받을 높이로 기록합니다.
+// light 노드는 tip의 본문도 기록하지 않습니다.
+// 먼저 전체를 검증한 뒤 처음부터 다시 읽어 여러 배치로 기록합니다. 앞 배치가 정규 체인 색인을 덮어쓰므로
+// 첫 배치에서 복원 표시를 남기며, 기록에 실패하거나 중간에 멈추면 절반만 기록한 체인을 지우고 빈 체인에서 다시 시작합니다.
+// 이미 가진 블록을 지우지 않도록 블록이 없는 체인에만 복원합니다.
+func (chain *BlockChain) RestoreSnapshot(r io.ReadSeeker, trusted []byte) error {
+	header, sum, err := VerifySnapshot(r)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, trusted) {
+		return fmt.Errorf("%w: snapshot %x, trusted %x", ErrSnapshotUntrusted, sum, trusted)
+	}
+	if err := chain.checkSnapshot(header); err != nil {
+		return err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	sr, err := newSnapshotReader(r)
+	if err != nil {
+		return err
+	}
+
+	db := chain.Database
+	batch := db.NewBatch()
+	batch.Put(restorePendingKey, sum)
+	batch.Delete(lastHashKey)
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("could not write snapshot: %v", err)
+	}
+	chain.head.Store(nil)
+	chain.CurrentBlock, chain.LastHash = nil, nil
+	chain.cache.purge()
+
+	tip, err := chain.writeSnapshot(sr, header)
+	if err != nil {
+		if resetErr := resetDatabase(db, restorePendingKey); resetErr != nil {
+			log.Error("could not remove partially restored snapshot, it is removed when the database is opened again", "err", resetErr)
+		}
+		return fmt.Errorf("could not write snapshot: %v", err)
+	}
+
+	chain.cache.purge()
+	chain.setHead(tip)
+	observeHead(tip)
+	chain.HeadFeed.Send(ChainHeadEvent{Block: tip})
+	log.Info("snapshot restored", "height", tip.Height, "hash", fmt.Sprintf("%x", tip.Hash), "snapshot", fmt.Sprintf("%x", sum))
+	return nil
+}
+
+// writeSnapshot은 스냅샷의 헤더와 색인을 배치로 나눠 기록하고, 마지막 배치에서 tip을 기록하며 복원 표시를 지웁니다.
+func (chain *BlockChain) writeSnapshot(sr *snapshotReader, header *SnapshotHeader) (*Block, error) {
+	batch := chain.Database.NewBatch()
+	for {
+		h, err := sr.nextHeader()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		data, err := encodeHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		batch.Put(headerKey(h.Hash), data)
+		batch.Put(blockHeightKey(h.Hash), encodeHeight(h.Height))
+		batch.Put(canonicalHashKey(h.Height), h.Hash)
+
+		if batch.Len() >= migrationBatchWrites {
+			if err := batch.Write(); err != nil {
+				return nil, err
+			}
+			batch.Reset()
+		}
+	}
+	genesis, tip, _, err := sr.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeBlock(batch, genesis); err != nil {
+		return nil, err
+	}
+	if err := chain.putBlock(batch, tip); err != nil {
+		return nil, err
+	}
+	if !chain.light && tip.Height > 1 {
+		if config.GlobalConfig.NodeType == "pruned" {
+			batch.Put(prunedHeightKey, encodeHeight(tip.Height))
+		} else {
+			// full 노드는 그 사이 본문을 peer에게서 받아 채움
+			batch.Put(backfillHeightKey, encodeHeight(tip.Height))
+		}
+	}
+	batch.Put(genesisHashKey, header.GenesisHash)
+	batch.Put(lastHashKey, tip.Hash)
+	batch.Delete(restorePendingKey)
+	return tip, batch.Write()
+}
+
+// checkSnapshot은 스냅샷이 이 체인의 것이고 체인에 아직 블록이 없는지 확인합니다.
+func (chain *BlockChain) checkSnapshot(header *SnapshotHeader) error {
+	if header.ChainId != chain.ChainId {
+		return fmt.Errorf("snapshot is for chainId %s, not %s", header.ChainId, chain.ChainId)
+	}
+	if !header.Params.Equal(chain.Params) {
+		return fmt.Errorf("snapshot chain params (%q) differ from local chain params (%q)", header.Params.Name, chain.Params.Name)
+	}
+	if genesis := chain.GetGenesisHash(); genesis != nil && !bytes.Equal(genesis, header.GenesisHash) {
+		return fmt.Errorf("genesis mismatch: database has %x, snapshot has %x", genesis, []byte(header.GenesisHash))
+	}
+	if expected := config.GlobalConfig.GenesisHash; expected != "" && expected != hex.EncodeToString(header.GenesisHash) {
+		return fmt.Errorf("genesis mismatch: snapshot has %x, config expects %s", []byte(header.GenesisHash), expected)
+	}
+	if len(chain.LastHash) > 0 {
+		// 복원에 실패하면 기록한 체인을 지우므로 이미 가진 블록을 덮어쓰지 않음
+		return fmt.Errorf("snapshot can only be restored into an empty database, the chain is at height %d (run db reset first)", chain.GetBestHeight())
+	}
+	return nil
+}
+
+// InitEmptyBlockChain은 블록 없이 체인 파라미터만 가진 데이터베이스를 만듭니다.
+// 스냅샷이나 동기화로 genesis부터 받을 노드가 사용합니다. p가 nil이면 설정의 network 프리셋을 사용합니다.
+func InitEmptyBlockChain(chainId string, p *params.ChainParams) (*BlockChain, error) {
+	path := DBPath(chainId)
+	log.Info("init empty blockchain", "chainId", chainId, "path", path)
+	if DBexists(path) {
+		return nil, fmt.Errorf("blockchain already exists: %s", path)
+	}
+
+	var err error
+	if p == nil {
+		p, err = defaultChainParams()
+	} else if err = p.Validate(); err == nil {
+		err = checkLocalParams(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	paramsData, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	db, lock, err := openDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %v", err)
+	}
+	light, err := checkNodeType(db)
+	if err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
+	batch := db.NewBatch()
+	writeSchemaVersion(batch)
+	batch.Put(chainParamsKey, paramsData)
+	if err := batch.Write(); err != nil {
+		db.Close()
+		lock.Release()
+		return nil, err
+	}
+
+	absPath, _ := filepath.Abs(path)
+	chain := BlockChain{
+		ChainId:  chainId,
+		Database: db,
+		Params:   p,
+		Path:     absPath,
+		cache:    newBlockCache(config.GlobalConfig.BlockCache),
+		light:    light,
+		lock:     lock,
+	}
+	return &chain, nil
+}
@@ -0,0 +1,224 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+func createTestSnapshot(t *testing.T, chain *BlockChain, height int64) ([]byte, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	sum, err := chain.CreateSnapshot(&buf, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), sum
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	source := newTestChain(t, 30, 0)
+	data, sum := createTestSnapshot(t, source, 25)
+
+	// 같은 높이의 스냅샷은 항상 같은 내용 해시
+	if _, again := createTestSnapshot(t, source, 25); !bytes.Equal(sum, again) {
+		t.Fatalf("snapshot hash changed: %x != %x", sum, again)
+	}
+	header, verified, err := VerifySnapshot(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verified, sum) || header.Height != 25 {
+		t.Fatalf("VerifySnapshot: height %d, hash %x", header.Height, verified)
+	}
+
+	chain := newEmptyTestChain(t, 64)
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := source.GetBlockByHeight(25)
+	if tip := chain.GetLastBlock(); !bytes.Equal(tip.Hash, want.Hash) {
+		t.Fatalf("tip %x at %d, want %x", tip.Hash, tip.Height, want.Hash)
+	}
+	for _, height := range []int64{0, 25} {
+		if _, err := chain.GetBlockByHeight(height); err != nil {
+			t.Fatalf("block %d: %v", height, err)
+		}
+	}
+	// 그 사이 블록은 헤더만 있고, full 노드는 본문을 받을 높이를 기록함
+	if _, err := chain.GetBlockByHeight(10); !errors.Is(err, ErrBodyNotSynced) {
+		t.Fatalf("block 10: got %v, want ErrBodyNotSynced", err)
+	}
+	if chain.BackfillHeight() != 25 || chain.PrunedHeight() != 0 {
+		t.Fatalf("backfill height %d, pruned height %d", chain.BackfillHeight(), chain.PrunedHeight())
+	}
+	if header, err := chain.GetHeaderByHeight(10); err != nil || header.Height != 10 {
+		t.Fatalf("header 10: %v, %v", header, err)
+	}
+	report, err := chain.VerifyDatabase(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) > 0 {
+		t.Fatalf("restored database has issues: %v", report.Issues)
+	}
+
+	// 스냅샷 이후 블록을 이어 받을 수 있어야 함
+	next, _ := source.GetBlockByHeight(26)
+	if err := chain.ImportBlock(next); err != nil {
+		t.Fatalf("import block after snapshot: %v", err)
+	}
+}
+
+func TestSnapshotRejects(t *testing.T) {
+	source := newTestChain(t, 20, 0)
+	data, sum := createTestSnapshot(t, source, 15)
+
+	chain := newEmptyTestChain(t, 0)
+	other := append([]byte{}, sum...)
+	other[0] ^= 0xff
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), other); !errors.Is(err, ErrSnapshotUntrusted) {
+		t.Fatalf("untrusted snapshot: %v", err)
+	}
+
+	// 내용 해시 바로 앞 tip 블록의 바이트를 바꿈
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-33] ^= 0xff
+	if _, _, err := VerifySnapshot(bytes.NewReader(tampered)); err == nil {
+		t.Fatal("tampered snapshot verified")
+	}
+	if _, _, err := VerifySnapshot(bytes.NewReader(data[:len(data)-10])); err == nil {
+		t.Fatal("truncated snapshot verified")
+	}
+	if chain.GetBestHeight() != 0 || len(chain.LastHash) != 0 {
+		t.Fatal("rejected snapshot changed the chain")
+	}
+
+	// 블록을 가진 체인에는 스냅샷보다 낮아도 복원하지 않음
+	for _, blocks := range []int{0, 5} {
+		local := newTestChain(t, blocks, 0)
+		tip := local.GetLastBlock()
+		if err := local.RestoreSnapshot(bytes.NewReader(data), sum); err == nil {
+			t.Fatalf("restored a snapshot over a chain at height %d", blocks)
+		}
+		if !bytes.Equal(local.GetLastBlock().Hash, tip.Hash) {
+			t.Fatal("refused snapshot changed the chain")
+		}
+	}
+	if err := source.RestoreSnapshot(bytes.NewReader(data), sum); err == nil {
+		t.Fatal("restored a snapshot below the chain height")
+	}
+}
+
+// pruned 노드는 스냅샷 아래를 가지치기한 것으로 기록
+func TestSnapshotRestorePruned(t *testing.T) {
+	saved := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = saved })
+	config.GlobalConfig.NodeType = "pruned"
+
+	source := newTestChain(t, 20, 0)
+	data, sum := createTestSnapshot(t, source, 15)
+	chain := newEmptyTestChain(t, 0)
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err != nil {
+		t.Fatal(err)
+	}
+	if chain.PrunedHeight() != 15 || chain.BackfillHeight() != 0 {
+		t.Fatalf("pruned height %d, backfill height %d", chain.PrunedHeight(), chain.BackfillHeight())
+	}
+	if _, err := chain.GetBlockByHeight(10); !errors.Is(err, ErrBlockPruned) {
+		t.Fatalf("block 10: got %v, want ErrBlockPruned", err)
+	}
+}
+
+// failingStore는 fail이 true를 돌려주는 번째의 배치 쓰기를 실패시키는 저장소입니다.
+type failingStore struct {
+	storage.Store
+	writes int
+	fail   func(write int) bool
+}
+
+type failingBatch struct {
+	storage.Batch
+	store *failingStore
+}
+
+func (s *failingStore) NewBatch() storage.Batch {
+	return &failingBatch{Batch: s.Store.NewBatch(), store: s}
+}
+
+func (b *failingBatch) Write() error {
+	b.store.writes++
+	if b.store.fail != nil && b.store.fail(b.store.writes) {
+		return errors.New("disk full")
+	}
+	return b.Batch.Write()
+}
+
+func TestSnapshotRestoreInterrupted(t *testing.T) {
+	// 헤더 기록이 여러 배치에 나뉘도록 높이를 정함
+	source := newTestChain(t, migrationBatchWrites/3*2, 0)
+	data, sum := createTestSnapshot(t, source, source.GetBestHeight())
+
+	chain := newEmptyTestChain(t, 0)
+
+	// 복원 표시와 첫 헤더 배치를 기록한 뒤로 실패하면, 정리도 실패해 표시가 남음
+	store := &failingStore{Store: chain.Database, fail: func(write int) bool { return write > 2 }}
+	chain.Database = store
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err == nil {
+		t.Fatal("restore succeeded on a failing store")
+	}
+	if ok, _ := store.Has(restorePendingKey); !ok {
+		t.Fatal("no restore marker after an interrupted restore")
+	}
+	// 절반만 기록한 체인을 tip으로 쓰지 않음
+	if _, err := store.Get(lastHashKey); err != storage.ErrNotFound {
+		t.Fatalf("last block hash left after an interrupted restore: %v", err)
+	}
+
+	// 다시 열면 절반만 기록한 체인을 지움
+	store.fail = nil
+	if err := finishInterrupted(store); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Has(restorePendingKey); ok {
+		t.Fatal("restore marker left after recovery")
+	}
+	for _, prefix := range [][]byte{headerPrefix, bodyPrefix, blockHeightPrefix, canonicalHashPrefix} {
+		iter := store.NewIterator(prefix)
+		if iter.Next() {
+			t.Fatalf("key %x left after recovery", iter.Key())
+		}
+		iter.Release()
+	}
+
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err != nil {
+		t.Fatal(err)
+	}
+	if best := chain.GetBestHeight(); best != source.GetBestHeight() {
+		t.Fatalf("height %d after restore, want %d", best, source.GetBestHeight())
+	}
+}
+
+// 기록에 실패해도 정리할 수 있으면 표시 없이 빈 체인이 남음
+func TestSnapshotRestoreFailureCleansUp(t *testing.T) {
+	source := newTestChain(t, migrationBatchWrites/3*2, 0)
+	data, sum := createTestSnapshot(t, source, source.GetBestHeight())
+
+	chain := newEmptyTestChain(t, 0)
+	store := &failingStore{Store: chain.Database, fail: func(write int) bool { return write == 3 }}
+	chain.Database = store
+	if err := chain.RestoreSnapshot(bytes.NewReader(data), sum); err == nil {
+		t.Fatal("restore succeeded on a failing store")
+	}
+	if ok, _ := store.Has(restorePendingKey); ok {
+		t.Fatal("restore marker left after cleanup")
+	}
+	iter := store.NewIterator(headerPrefix)
+	defer iter.Release()
+	if iter.Next() {
+		t.Fatalf("header %x left after cleanup", iter.Key())
+	}
+}
@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// VerifyBlock이 반환하는 오류
+var (
+	ErrInvalidDifficulty = errors.New("unexpected block difficulty")
+	ErrInvalidPoW        = errors.New("invalid proof of work")
+	ErrInvalidHash       = errors.New("block hash does not match its contents")
+)
+
+// VerifyBlock은 parent 바로 위에 오는 block의 높이, 이전 해시, 난이도, 작업증명, 해시를 검사합니다.
+// 난이도는 정규 체인의 블록으로 계산하므로 parent는 정규 체인의 블록이어야 합니다.
+func (chain *BlockChain) VerifyBlock(block, parent *Block) error {
+	return chain.verifyBlock(block, parent, chain.GetHeaderByHeight)
+}
+
+// verifyBlock은 headerAt이 돌려주는 체인의 블록 헤더로 난이도를 계산해 block을 검사합니다.
+func (chain *BlockChain) verifyBlock(block, parent *Block, headerAt func(int64) (*Header, error)) error {
+	if block.Height != parent.Height+1 {
+		return fmt.Errorf("%w: height %d on parent %d", ErrInvalidHeight, block.Height, parent.Height)
+	}
+	if !bytes.Equal(block.PrevHash, parent.Hash) {
+		return fmt.Errorf("%w: prevHash %x, parent %x", ErrPrevHashMismatch, block.PrevHash, parent.Hash)
+	}
+
+	expected, err := calcDifficulty(chain.Params, block.Height, headerAt)
+	if err != nil {
+		return err
+	}
+	if block.Difficulty == nil || block.Difficulty.Cmp(expected) != 0 {
+		return fmt.Errorf("%w: height %d has %v, expected %v", ErrInvalidDifficulty, block.Height, block.Difficulty, expected)
+	}
+
+	return VerifyPoW(block)
+}
+
+// VerifyPoW는 블록의 nonce가 난이도 목표를 만족하고 해시가 내용과 일치하는지 검사합니다.
+// genesis는 채굴하지 않고 nonce가 spec 해시이므로(GenesisSpec.ToBlock) 해시가 내용과 일치하는지만 검사합니다.
+func VerifyPoW(block *Block) error {
+	if block.Difficulty == nil || block.Difficulty.Sign() <= 0 {
+		return fmt.Errorf("%w: block %x has difficulty %v", ErrInvalidDifficulty, block.Hash, block.Difficulty)
+	}
+	pow := NewProof(block)
+	if block.Height == 0 {
+		if len(block.PrevHash) != 0 {
+			return fmt.Errorf("%w: genesis %x has prevHash %x", ErrPrevHashMismatch, block.Hash, block.PrevHash)
+		}
+		if !bytes.Equal(block.Hash, pow.GetHash(block)) {
+			return fmt.Errorf("%w: genesis %x", ErrInvalidHash, block.Hash)
+		}
+		return nil
+	}
+	if !pow.Validate(block.Nonce) {
+		return fmt.Errorf("%w: block %x", ErrInvalidPoW, block.Hash)
+	}
+	if !bytes.Equal(block.Hash, pow.GetHash(block)) {
+		return fmt.Errorf("%w: block %x", ErrInvalidHash, block.Hash)
+	}
+	return nil
+}
+
+// VerifyBranch는 정규 블록에서 갈라지는 갈래 blocks를 낮은 높이부터 검사합니다. blocks[0]의 부모는 정규 체인에 있어야 하고
+// 각 블록은 앞 블록 바로 위에 와야 합니다. 난이도는 갈라진 높이까지는 정규 체인, 그 위는 갈래의 블록으로 계산합니다.
+// 검사를 통과한 앞쪽 블록 수와 처음 실패한 블록의 오류를 반환합니다.
+func (chain *BlockChain) VerifyBranch(blocks []*Block) (int, error) {
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+	parent, err := chain.GetHeader(blocks[0].PrevHash)
+	if err != nil {
+		return 0, fmt.Errorf("could not read branch parent: %w", err)
+	}
+	canonical, err := chain.GetHeaderByHeight(parent.Height)
+	if err != nil || !bytes.Equal(canonical.Hash, parent.Hash) {
+		return 0, fmt.Errorf("branch parent %x at height %d is not on the canonical chain", []byte(parent.Hash), parent.Height)
+	}
+
+	forkHeight := parent.Height
+	branch := map[int64]*Header{}
+	headerAt := func(height int64) (*Header, error) {
+		if height <= forkHeight {
+			return chain.GetHeaderByHeight(height)
+		}
+		if header, ok := branch[height]; ok {
+			return header, nil
+		}
+		return nil, fmt.Errorf("%w: branch has no block at height %d", ErrBlockNotFound, height)
+	}
+
+	prev := joinBlock(parent, &blockBody{})
+	for i, block := range blocks {
+		if err := chain.verifyBlock(block, prev, headerAt); err != nil {
+			return i, err
+		}
+		branch[block.Height] = block.Header()
+		prev = block
+	}
+	return len(blocks), nil
+}
@@ -0,0 +1,46 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestBranch는 chain의 height 블록에서 갈라지는 n개 블록의 갈래를 만듭니다.
+func newTestBranch(t *testing.T, chain *BlockChain, height int64, n int) []*Block {
+	t.Helper()
+	parent, err := chain.GetBlockByHeight(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var branch []*Block
+	for i := 0; i < n; i++ {
+		parent = newTestBlock(t, parent, "fork")
+		branch = append(branch, parent)
+	}
+	return branch
+}
+
+func TestVerifyBranch(t *testing.T) {
+	chain := newTestChain(t, 10, 0)
+
+	// 난이도 조정 주기를 넘는 갈래도 갈래의 블록으로 난이도를 계산
+	branch := newTestBranch(t, chain, 5, 8)
+	if n, err := chain.VerifyBranch(branch); n != len(branch) || err != nil {
+		t.Fatalf("valid branch: %d, %v", n, err)
+	}
+
+	bad := append(append(append([]*Block{}, branch[:3]...), tamperBody(t, branch[3])), branch[4:]...)
+	if n, err := chain.VerifyBranch(bad); n != 3 || !errors.Is(err, ErrInvalidPoW) {
+		t.Fatalf("tampered branch: %d, %v", n, err)
+	}
+
+	// 블록을 건너뛰는 갈래
+	if n, err := chain.VerifyBranch([]*Block{branch[0], branch[2]}); n != 1 || !errors.Is(err, ErrInvalidHeight) {
+		t.Fatalf("gapped branch: %d, %v", n, err)
+	}
+
+	// 정규 체인이 아닌 블록에서 갈라지는 갈래
+	if n, err := chain.VerifyBranch(branch[1:]); n != 0 || err == nil {
+		t.Fatalf("branch off a side block: %d, %v", n, err)
+	}
+}
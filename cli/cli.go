@@ -1,25 +1,114 @@
 package cli
 
 import (
+	"fmt"
+	"os"
 	"sort"
 
 	"github.com/Kim-DaeHan/mining-chain/cli/utils/nodecmd"
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/logger"
 	"github.com/urfave/cli/v2"
 )
 
+// configFlags는 설정 키에 대응하는 전역 플래그입니다. 지정한 플래그만 설정을 덮어씁니다.
+var configFlags = map[string]string{
+	"datadir":     "dataDir",
+	"dbengine":    "dbEngine",
+	"chainid":     "chainId",
+	"port":        "port",
+	"rpcport":     "rpcPort",
+	"rpcaddr":     "rpcAddr",
+	"nodetype":    "nodeType",
+	"pruneretain": "pruneRetain",
+	"mining":      "mining",
+	"network":     "network",
+	"genesishash": "genesisHash",
+	"maxpeers":    "maxPeers",
+	"metrics":     "metrics",
+	"loglevel":    "logLevel",
+	"logformat":   "logFormat",
+	"logfile":     "logFile",
+}
+
 func InitializeApp() *cli.App {
 	app := &cli.App{
 		Name:    "xphere-proofnode",
 		Usage:   "CLI for managing the xphere-proofnode blockchain",
 		Version: "1.0.0",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "datadir", Usage: "Data directory for the databases (default: dataDir in config or " + config.DefaultDataDir + ")"},
+			&cli.StringFlag{Name: "dbengine", Usage: "Storage backend (leveldb, pebble, memory)"},
+			&cli.StringFlag{Name: "config", Usage: "Path to the config file in JSON, TOML or YAML (default: <datadir>/config.json)", EnvVars: []string{config.EnvPrefix + "CONFIG"}},
+			&cli.IntFlag{Name: "chainid", Usage: "Chain ID"},
+			&cli.IntFlag{Name: "port", Usage: "P2P listen port"},
+			&cli.IntFlag{Name: "rpcport", Usage: "RPC listen port"},
+			&cli.StringFlag{Name: "rpcaddr", Usage: "RPC listen address"},
+			&cli.StringFlag{Name: "rpc", Usage: "RPC endpoint for rpc commands, e.g. http://host:8545 (default: rpcAddr and rpcPort in config)"},
+			&cli.StringFlag{Name: "rpctoken", Usage: "Bearer token or JWT for rpc commands (default: rpcTokens in config)", EnvVars: []string{config.EnvPrefix + "RPC_TOKEN"}},
+			&cli.StringFlag{Name: "nodetype", Usage: "Node type (full-node, cn, pruned, light)"},
+			&cli.Int64Flag{Name: "pruneretain", Usage: "Number of recent heights a pruned node keeps full blocks for"},
+			&cli.BoolFlag{Name: "mining", Usage: "Enable mining"},
+			&cli.StringFlag{Name: "network", Usage: "Chain params preset (mainnet, testnet, devnet)"},
+			&cli.StringFlag{Name: "genesishash", Usage: "Expected genesis hash"},
+			&cli.IntFlag{Name: "maxpeers", Usage: "Maximum number of known peers"},
+			&cli.BoolFlag{Name: "metrics", Usage: "Expose Prometheus metrics on the RPC server at /metrics"},
+			&cli.StringFlag{Name: "loglevel", Usage: "Default log level (debug, info, warn, error)"},
+			&cli.StringFlag{Name: "logformat", Usage: "Log output format (text, json)"},
+			&cli.StringFlag{Name: "logfile", Usage: "Write logs to a rotating file instead of stderr"},
+		},
+		Before: loadConfig,
 		Commands: []*cli.Command{
 			nodecmd.InitDB,
 			nodecmd.Start,
 			nodecmd.CreateBlockchain,
 			nodecmd.GenesisProofBlock,
 			nodecmd.RPCCommands,
+			nodecmd.ConfigCommands,
+			nodecmd.Export,
+			nodecmd.Import,
+			nodecmd.DBCommands,
+			nodecmd.ChainCommands,
+			nodecmd.SnapshotCommands,
 		},
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 	return app
 }
+
+// loadConfig는 기본값 → 설정 파일 → 환경 변수 → 플래그 순으로 설정을 구성합니다.
+// 설정 파일은 --config, 없으면 <datadir>/config.json을 사용합니다.
+func loadConfig(c *cli.Context) error {
+	src := config.Sources{Flags: map[string]string{}}
+	for flag, key := range configFlags {
+		if c.IsSet(flag) {
+			src.Flags[key] = fmt.Sprint(c.Value(flag))
+		}
+	}
+
+	dataDir := c.String("datadir")
+	if dataDir == "" {
+		dataDir = os.Getenv(config.EnvName("dataDir"))
+	}
+
+	src.Path = c.String("config")
+	if src.Path == "" {
+		src.Path = config.DefaultConfigPath(dataDir)
+		if _, err := os.Stat(src.Path); os.IsNotExist(err) {
+			fmt.Printf("Config file is not exist %s\n", src.Path)
+			src.Path = ""
+		}
+	}
+
+	if err := config.Load(src); err != nil {
+		return err
+	}
+
+	if err := logger.Setup(config.GlobalConfig.LogOptions()); err != nil {
+		return err
+	}
+	config.OnReload(func(cfg config.Config) {
+		logger.SetLevels(cfg.LogLevel, cfg.LogLevels)
+	})
+	return nil
+}
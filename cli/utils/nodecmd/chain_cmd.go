@@ -15,13 +15,35 @@ var (
 		Name:  "initDB",
 		Usage: "Initialize database",
 		Action: func(c *cli.Context) error {
+			if genesisPath := c.String("genesis"); genesisPath != "" {
+				spec, err := blockchain.LoadGenesisSpec(genesisPath)
+				if err != nil {
+					return err
+				}
+				if spec.ChainId != config.GlobalConfig.ChainId {
+					return fmt.Errorf("genesis chainId %d does not match config chainId %d", spec.ChainId, config.GlobalConfig.ChainId)
+				}
+				chain, err := blockchain.InitBlockChainFromGenesis(spec)
+				if err != nil {
+					return err
+				}
+				defer chain.Close()
+				fmt.Printf("Genesis block created: %x\n", chain.LastHash)
+				return nil
+			}
+
 			chainId := strconv.Itoa(config.GlobalConfig.ChainId)
 			validatorAddress := c.String("validator")
-			blockchain.InitBlockChain(validatorAddress, chainId)
+			chain, err := blockchain.InitBlockChain(validatorAddress, chainId)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
 			return nil
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "validator", Usage: "Set validator address"},
+			&cli.StringFlag{Name: "genesis", Usage: "Initialize from a genesis JSON file"},
 		},
 	}
 	Start = &cli.Command{
@@ -30,8 +52,24 @@ var (
 		Action: func(c *cli.Context) error {
 			chainId := strconv.Itoa(config.GlobalConfig.ChainId)
 			validatorAddress := c.String("validator")
-			chain := blockchain.ContinueBlockChain(chainId)
-			defer chain.Database.Close()
+
+			var chain *blockchain.BlockChain
+			var err error
+			_, _, trusted := blockchain.TrustedSnapshot()
+			if (trusted || config.GlobalConfig.NodeType == "light") && !blockchain.DBexists(blockchain.DBPath(chainId)) {
+				// 스냅샷 없이 genesis부터 받는 노드는 peer의 genesis를 확인할 해시가 필요함
+				if !trusted && config.GlobalConfig.GenesisHash == "" {
+					return fmt.Errorf("a light node syncing from genesis needs genesisHash in config")
+				}
+				// 처음 시작하는 노드는 peer에게서 스냅샷이나 블록을 받음
+				chain, err = blockchain.InitEmptyBlockChain(chainId, nil)
+			} else {
+				chain, err = blockchain.ContinueBlockChain(chainId)
+			}
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
 			network.StartServer(chain, validatorAddress)
 			return nil
 		},
@@ -51,8 +89,11 @@ var (
 				fmt.Println("Validator address is required")
 				return nil
 			}
-			chain := blockchain.InitBlockChain(validatorAddress, chainId)
-			defer chain.Database.Close()
+			chain, err := blockchain.InitBlockChain(validatorAddress, chainId)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
 			fmt.Println("Blockchain created successfully")
 			return nil
 		},
@@ -67,10 +108,18 @@ var (
 		Action: func(c *cli.Context) error {
 			chainId := strconv.Itoa(config.GlobalConfig.ChainId)
 			validatorAddress := c.String("address")
-			chain := blockchain.ContinueBlockChain(chainId)
-			defer chain.Database.Close()
-			block := blockchain.Genesis(validatorAddress)
-			chain.AddBlock(block)
+			chain, err := blockchain.ContinueBlockChain(chainId)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+			block, err := blockchain.Genesis(validatorAddress, chain.Params)
+			if err != nil {
+				return err
+			}
+			if err := chain.ImportBlock(block); err != nil {
+				return err
+			}
 			fmt.Println("Genesis block created")
 			return nil
 		},
@@ -78,4 +127,55 @@ var (
 			&cli.StringFlag{Name: "address", Usage: "Set validator for genesis block"},
 		},
 	}
+
+	ChainCommands = &cli.Command{
+		Name:        "chain",
+		Usage:       "Rewind the local chain or reapply rewound blocks",
+		Subcommands: []*cli.Command{RewindChain, ReapplyChain},
+	}
+
+	RewindChain = &cli.Command{
+		Name:  "rewind",
+		Usage: "Remove blocks above a height and keep them so they can be reapplied",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "to-height", Usage: "Height of the new chain tip", Required: true},
+		},
+		Action: func(c *cli.Context) error {
+			chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			removed, err := chain.Rewind(c.Int64("to-height"))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d blocks, new tip: height %d, hash %x\n", removed, chain.GetBestHeight(), chain.LastHash)
+			return nil
+		},
+	}
+
+	ReapplyChain = &cli.Command{
+		Name:  "reapply",
+		Usage: "Validate and re-add rewound blocks that extend the chain tip",
+		Action: func(c *cli.Context) error {
+			chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			applied, err := chain.ReapplyRewound()
+			if err != nil {
+				return err
+			}
+			left, err := chain.RewoundCount()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Reapplied %d blocks, height %d, %d rewound blocks left\n", applied, chain.GetBestHeight(), left)
+			return nil
+		},
+	}
 )
@@ -0,0 +1,28 @@
+package nodecmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/urfave/cli/v2"
+)
+
+var ConfigCommands = &cli.Command{
+	Name:        "config",
+	Usage:       "Inspect the node configuration",
+	Subcommands: []*cli.Command{DumpConfig},
+}
+
+var DumpConfig = &cli.Command{
+	Name:  "dump",
+	Usage: "Print the effective configuration after defaults, file, environment and flags",
+	Action: func(c *cli.Context) error {
+		configJSON, err := json.MarshalIndent(config.GlobalConfig, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(configJSON))
+		return nil
+	},
+}
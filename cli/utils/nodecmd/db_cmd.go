@@ -0,0 +1,112 @@
+package nodecmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/urfave/cli/v2"
+)
+
+var DBCommands = &cli.Command{
+	Name:        "db",
+	Usage:       "Check, repair and reset the chain database",
+	Subcommands: []*cli.Command{VerifyDB, RepairDB, PruneDB, ResetDB},
+}
+
+var VerifyDB = &cli.Command{
+	Name:  "verify",
+	Usage: "Check hash linkage, height indexes, proof of work and the tip, and report every inconsistency",
+	Action: func(c *cli.Context) error {
+		chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		// tip이 잘못되었을 수 있으므로 전체 수는 알 수 없음
+		p := newProgress("Verified", 0)
+		report, err := chain.VerifyDatabase(p.update)
+		if err != nil {
+			return err
+		}
+		p.done()
+
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+		fmt.Printf("Checked %d canonical blocks, %d stored blocks\n", report.Canonical, report.Stored)
+		if len(report.Issues) > 0 {
+			return fmt.Errorf("found %d inconsistencies, run \"db repair\" to rebuild the indexes", len(report.Issues))
+		}
+		fmt.Println("No inconsistencies found")
+		return nil
+	},
+}
+
+var RepairDB = &cli.Command{
+	Name:  "repair",
+	Usage: "Rebuild the height index and tip from the longest valid chain of stored blocks",
+	Action: func(c *cli.Context) error {
+		chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		result, err := chain.RepairDatabase()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Read %d stored blocks, %d not on a valid chain\n", result.Stored, result.Invalid)
+		fmt.Printf("Rewrote %d index entries\n", result.Rewritten)
+		fmt.Printf("Tip: height %d, hash %x\n", result.Tip.Height, result.Tip.Hash)
+		return nil
+	},
+}
+
+var PruneDB = &cli.Command{
+	Name:  "prune",
+	Usage: "Delete the bodies of blocks older than the most recent heights, keeping headers and indexes",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{Name: "retain", Usage: "Number of recent heights to keep full blocks for (default: pruneRetain in config)"},
+	},
+	Action: func(c *cli.Context) error {
+		retain := config.GlobalConfig.PruneRetain
+		if c.IsSet("retain") {
+			retain = c.Int64("retain")
+		}
+
+		chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		pruned, err := chain.Prune(retain)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Pruned %d blocks, full blocks kept from height %d\n", pruned, max(chain.PrunedHeight(), 1))
+		return nil
+	},
+}
+
+var ResetDB = &cli.Command{
+	Name:  "reset",
+	Usage: "Delete every block and index, keeping the genesis settings and rewound blocks, so the chain is synced again from peers",
+	Action: func(c *cli.Context) error {
+		chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		if err := chain.ResetDatabase(); err != nil {
+			return err
+		}
+		fmt.Println("Database reset, the chain is synced again on the next start")
+		return nil
+	},
+}
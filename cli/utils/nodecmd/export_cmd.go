@@ -0,0 +1,282 @@
+package nodecmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/klauspost/compress/zstd"
+	"github.com/urfave/cli/v2"
+)
+
+// 압축 형식별 파일 시작 바이트
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+var (
+	Export = &cli.Command{
+		Name:      "export",
+		Usage:     "Export blocks to a file",
+		ArgsUsage: "<file>",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "from", Usage: "First block height"},
+			&cli.Int64Flag{Name: "to", Value: -1, Usage: "Last block height (default: best height)"},
+			&cli.StringFlag{Name: "compress", Usage: "Compression (none, gzip, zstd) (default: from the file extension .gz or .zst)"},
+		},
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+			if path == "" {
+				return fmt.Errorf("export file is required")
+			}
+			compression := c.String("compress")
+			if compression == "" {
+				compression = compressionFromExt(path)
+			}
+
+			chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			from, to := c.Int64("from"), c.Int64("to")
+			if to < 0 {
+				to = chain.GetBestHeight()
+			}
+
+			// 끝까지 기록한 뒤에 이름을 바꾸므로 중단되어도 불완전한 파일이 남지 않음
+			tmp := path + ".tmp"
+			file, err := os.Create(tmp)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp)
+
+			w, err := compressWriter(file, compression)
+			if err != nil {
+				file.Close()
+				return err
+			}
+
+			p := newProgress("Exported", to-from+1)
+			err = chain.Export(w, from, to, p.update)
+			if err == nil {
+				err = w.Close()
+			}
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				return err
+			}
+			p.done()
+			return nil
+		},
+	}
+
+	Import = &cli.Command{
+		Name:      "import",
+		Usage:     "Import blocks from an export file, validating each block",
+		ArgsUsage: "<file>",
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+			if path == "" {
+				return fmt.Errorf("import file is required")
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			r, err := decompressReader(file)
+			if err != nil {
+				return err
+			}
+			reader, err := blockchain.NewExportReader(r)
+			if err != nil {
+				return err
+			}
+			header := reader.Header
+
+			chainId := strconv.Itoa(config.GlobalConfig.ChainId)
+			if header.ChainId != chainId {
+				return fmt.Errorf("export is for chainId %s, config chainId is %s", header.ChainId, chainId)
+			}
+
+			p := newProgress("Imported", header.To-header.From+1)
+			var chain *blockchain.BlockChain
+			if blockchain.DBexists(blockchain.DBPath(chainId)) {
+				chain, err = blockchain.ContinueBlockChain(chainId)
+				if err != nil {
+					return err
+				}
+				if genesis := chain.GetGenesisHash(); genesis != nil && !bytes.Equal(genesis, header.GenesisHash) {
+					chain.Close()
+					return fmt.Errorf("genesis mismatch: database has %x, export has %x", genesis, header.GenesisHash)
+				}
+			} else {
+				genesis, err := reader.Next()
+				if err != nil {
+					return fmt.Errorf("could not read genesis block: %v", err)
+				}
+				chain, err = blockchain.InitBlockChainFromExport(&header, genesis)
+				if err != nil {
+					return err
+				}
+				p.update(genesis.Height)
+			}
+			defer chain.Close()
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return importBlocks(ctx, chain, reader, p)
+		},
+	}
+)
+
+// importBlocks는 파일의 블록을 검증하며 체인에 추가합니다.
+// 이미 같은 블록이 있는 높이는 건너뛰므로 중단된 가져오기를 같은 명령으로 이어서 할 수 있습니다.
+func importBlocks(ctx context.Context, chain *blockchain.BlockChain, reader *blockchain.ExportReader, p *progress) error {
+	skipped := 0
+
+	for {
+		if ctx.Err() != nil {
+			fmt.Printf("Import interrupted at height %d, run the same command again to resume\n", chain.GetBestHeight())
+			return ctx.Err()
+		}
+
+		block, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if block.Height <= chain.GetBestHeight() && len(chain.LastHash) > 0 {
+			local, err := chain.GetBlockByHeight(block.Height)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(local.Hash, block.Hash) {
+				return fmt.Errorf("block %x at height %d conflicts with local block %x", block.Hash, block.Height, local.Hash)
+			}
+			skipped++
+		} else if err := chain.ImportBlock(block); err != nil {
+			return fmt.Errorf("invalid block %x at height %d: %w", block.Hash, block.Height, err)
+		}
+		p.update(block.Height)
+	}
+
+	p.done()
+	if skipped > 0 {
+		fmt.Printf("Skipped %d blocks already in the database\n", skipped)
+	}
+	return nil
+}
+
+func compressionFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return "gzip"
+	case ".zst":
+		return "zstd"
+	}
+	return "none"
+}
+
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "none":
+		return nopWriteCloser{w}, nil
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compression %q (expected none, gzip or zstd)", compression)
+}
+
+// decompressReader는 파일 시작 바이트로 압축 형식을 판별합니다.
+func decompressReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return br, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// progress는 진행 상황을 초당 한 번만 출력합니다. total이 0이면 전체 수를 출력하지 않습니다.
+type progress struct {
+	verb   string
+	total  int64
+	count  int64
+	height int64
+	start  time.Time
+	last   time.Time
+}
+
+func newProgress(verb string, total int64) *progress {
+	now := time.Now()
+	return &progress{verb: verb, total: total, start: now, last: now}
+}
+
+func (p *progress) update(height int64) {
+	p.count++
+	p.height = height
+	if time.Since(p.last) >= time.Second {
+		p.last = time.Now()
+		p.print()
+	}
+}
+
+func (p *progress) done() {
+	p.print()
+}
+
+func (p *progress) print() {
+	elapsed := time.Since(p.start)
+	rate := float64(p.count) / max(elapsed.Seconds(), 0.001)
+	count := strconv.FormatInt(p.count, 10)
+	if p.total > 0 {
+		count += "/" + strconv.FormatInt(p.total, 10)
+	}
+	fmt.Printf("%s %s blocks, height %d, %.0f blocks/s, elapsed %s\n", p.verb, count, p.height, rate, elapsed.Round(time.Millisecond))
+}
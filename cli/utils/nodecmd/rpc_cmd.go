@@ -1,13 +1,15 @@
 package nodecmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"net/rpc"
+	"net"
+	"strconv"
 
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/client"
 	"github.com/Kim-DaeHan/mining-chain/config"
-	"github.com/Kim-DaeHan/mining-chain/network"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,129 +17,179 @@ var RPCCommands = &cli.Command{
 	Name:  "rpc",
 	Usage: "RPC commands for managing the blockchain node",
 	Subcommands: []*cli.Command{
-		GetBlockNumber, GetBlockList, GetLastBlockHash,
-		GetBlock, GetBlockHashes,
-		GetWork, GetHashRate, Coinbase, IsMining, AddPeer,
+		GetBlockNumber, GetBlockList, GetLastBlockHash, GetBestHeight,
+		GetBlock, GetHeaders, GetBlockHashes,
+		GetWork, SubmitWork, GetHashRate, Coinbase, IsMining, AddPeer,
 		GetDataDir, GetNodeInfo, GetPeer, RemovePeer,
 		SetXpbase, GetNodeHashRate, GetDifficulty,
+		Rewind, ReapplyRewound,
 	},
 }
+
+// rpcEndpoint는 --rpc 플래그, 없으면 설정의 rpcAddr와 rpcPort로 만든 엔드포인트를 반환합니다.
+func rpcEndpoint(c *cli.Context) string {
+	if endpoint := c.String("rpc"); endpoint != "" {
+		return endpoint
+	}
+
+	cfg := config.GlobalConfig
+	host := cfg.RPCAddr
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	scheme := "http"
+	if cfg.RPCTLSCert != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(cfg.RPCPort))
+}
+
+// rpcToken은 --rpctoken 플래그, 없으면 설정의 admin 또는 miner 토큰을 반환합니다.
+func rpcToken(c *cli.Context) string {
+	if token := c.String("rpctoken"); token != "" {
+		return token
+	}
+	if token := config.GlobalConfig.RPCTokens["admin"]; token != "" {
+		return token
+	}
+	return config.GlobalConfig.RPCTokens["miner"]
+}
+
+// withClient는 RPC 서버에 연결해 fn을 실행합니다.
+func withClient(c *cli.Context, fn func(ctx context.Context, rpc *client.Client) error) error {
+	ctx := c.Context
+	rpc, err := client.Dial(ctx, rpcEndpoint(c), client.WithToken(rpcToken(c)))
+	if err != nil {
+		return fmt.Errorf("error connecting to RPC: %v", err)
+	}
+	defer rpc.Close()
+
+	return fn(ctx, rpc)
+}
+
+func printJSON(label string, v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(label, string(data))
+}
+
 var (
 	GetBlockNumber = &cli.Command{
 		Name:  "getBlockNumber",
 		Usage: "Get the current block number",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
-
-			req := network.GetBlockNumberArgs{}
-			var res network.GetBlockNumberRes
-			if err = client.Call("RPCServer.GetBlockNumber", req, &res); err != nil {
-				log.Fatalf("Error calling GetBlockNumber: %v", err)
-			}
-			fmt.Printf("Block Number: %d\n", res.Height)
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				height, err := rpc.BlockNumber(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetBlockNumber: %v", err)
+				}
+				fmt.Printf("Block Number: %d\n", height)
+				return nil
+			})
 		},
 	}
 	GetBlockList = &cli.Command{
 		Name:  "getBlockList",
-		Usage: "Retrieve and display the list of all blocks",
+		Usage: "Retrieve and display a page of blocks by height",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "from", Usage: "First block height"},
+			&cli.Int64Flag{Name: "to", Usage: "Last block height (default: latest)"},
+			&cli.IntFlag{Name: "limit", Value: 20, Usage: "Maximum number of blocks to return"},
+		},
 		Action: func(c *cli.Context) error {
-			fmt.Println("Get All Block List")
-
-			rpchost := fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort)
-			fmt.Printf("cli request getBlockList rpchost : %s\n", rpchost)
-
-			client, err := rpc.DialHTTP("tcp", rpchost)
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-
-			req := network.GetBlockListArgs{}
-			var res network.GetBlockListRes
-
-			err = client.Call("RPCServer.GetBlockList", req, &res)
-			if err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			for _, block := range res.Block {
-				fmt.Println("Block: ", block)
-			}
-
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				blocks, next, err := rpc.BlockList(ctx, c.Int64("from"), c.Int64("to"), c.Int("limit"))
+				if err != nil {
+					return fmt.Errorf("error calling GetBlockList: %v", err)
+				}
+
+				for _, block := range blocks {
+					fmt.Println("Block: ", block)
+				}
+				if next >= 0 {
+					fmt.Printf("Next page: --from %d\n", next)
+				}
+				return nil
+			})
 		},
 	}
 
 	GetLastBlockHash = &cli.Command{
 		Name:  "getLastBlockHash",
 		Usage: "Get the hash of the last block",
-		Flags: []cli.Flag{
-			&cli.StringFlag{Name: "block hash", Usage: "Provide block hash you want block"},
-		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
-
-			req := network.GetLastBlockHashArgs{}
-			var res network.GetLastBlockHashRes
-			if err = client.Call("RPCServer.GetLastBlockHash", req, &res); err != nil {
-				log.Fatalf("Error calling GetLastBlockHash: %v", err)
-			}
-			fmt.Printf("Last Block Hash: %s\n", res.Hash)
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				hash, err := rpc.LastBlockHash(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetLastBlockHash: %v", err)
+				}
+				fmt.Printf("Last Block Hash: %s\n", hash)
+				return nil
+			})
 		},
 	}
 
 	GetBestHeight = &cli.Command{
 		Name:  "getBestHeight",
 		Usage: "Get the best block height",
-		Flags: []cli.Flag{
-			&cli.StringFlag{Name: "block hash", Usage: "Provide block hash you want block"},
-		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
-
-			req := network.GetBestHeightArgs{}
-			var res network.GetBestHeightRes
-			if err = client.Call("RPCServer.GetBestHeight", req, &res); err != nil {
-				log.Fatalf("Error calling GetBestHeight: %v", err)
-			}
-			fmt.Printf("Best Height: %d\n", res.Height)
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				height, err := rpc.BestHeight(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetBestHeight: %v", err)
+				}
+				fmt.Printf("Best Height: %d\n", height)
+				return nil
+			})
 		},
 	}
 
 	GetBlock = &cli.Command{
 		Name:  "getBlock",
-		Usage: "Get block details by hash",
+		Usage: "Get block details by hash or height",
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "hash", Usage: "Hash of the block to retrieve"},
+			&cli.StringFlag{Name: "height", Usage: "Height of the block to retrieve, or latest/earliest"},
 		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
+			if c.IsSet("hash") == c.IsSet("height") {
+				return fmt.Errorf("exactly one of --hash or --height is required")
+			}
+
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				var block *blockchain.Block
+				var err error
+				if c.IsSet("hash") {
+					block, err = rpc.BlockByHash(ctx, c.String("hash"))
+				} else {
+					block, err = rpc.BlockByNumber(ctx, c.String("height"))
+				}
+				if err != nil {
+					return fmt.Errorf("error calling GetBlock: %v", err)
+				}
+				fmt.Println(block)
+				return nil
+			})
+		},
+	}
 
-			req := network.GetBlockArgs{Hash: c.String("hash")}
-			var res network.GetBlockRes
-			if err = client.Call("RPCServer.GetBlock", req, &res); err != nil {
-				log.Fatalf("Error calling GetBlock: %v", err)
-			}
-			fmt.Printf("Block Hash: %x\nNonce: %d\n", res.Block.Hash, res.Block.Nonce)
-			return nil
+	GetHeaders = &cli.Command{
+		Name:  "getHeaders",
+		Usage: "Get block headers starting at a height",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "from", Usage: "First block height"},
+			&cli.IntFlag{Name: "count", Value: 20, Usage: "Number of headers to return (capped by the server)"},
+		},
+		Action: func(c *cli.Context) error {
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				headers, err := rpc.HeadersRange(ctx, c.Int64("from"), c.Int("count"))
+				if err != nil {
+					return fmt.Errorf("error calling GetHeadersRange: %v", err)
+				}
+				for _, h := range headers {
+					fmt.Printf("%d %x prev=%x difficulty=%d\n", h.Height, h.Hash, h.PrevHash, h.Difficulty)
+				}
+				return nil
+			})
 		},
 	}
 
@@ -145,22 +197,16 @@ var (
 		Name:  "getBlockHashes",
 		Usage: "Get all block hashes",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
-
-			req := network.GetBlockHashesArgs{}
-			var res network.GetBlockHashesRes
-			if err = client.Call("RPCServer.GetBlockHashes", req, &res); err != nil {
-				log.Fatalf("Error calling GetBlockHashes: %v", err)
-			}
-
-			for i, hash := range res.Hash {
-				fmt.Printf("Block %d: %s\n", i, hash)
-			}
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				hashes, err := rpc.BlockHashes(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetBlockHashes: %v", err)
+				}
+				for i, hash := range hashes {
+					fmt.Printf("Block %d: %s\n", i, hash)
+				}
+				return nil
+			})
 		},
 	}
 
@@ -168,20 +214,33 @@ var (
 		Name:  "getWork",
 		Usage: "Retrieve mining work info",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				work, err := rpc.Work(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetWork: %v", err)
+				}
+				printJSON("Work Info:", work)
+				return nil
+			})
+		},
+	}
 
-			req := network.GetWorkArgs{}
-			var res network.GetWorkRes
-			if err = client.Call("RPCServer.GetWork", req, &res); err != nil {
-				log.Fatalf("Error calling GetWork: %v", err)
-			}
-			workInfoJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("Work Info:", string(workInfoJSON))
-			return nil
+	SubmitWork = &cli.Command{
+		Name:  "submitWork",
+		Usage: "Submit a nonce for work returned by getWork",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "powhash", Usage: "currentPowHash returned by getWork", Required: true},
+			&cli.StringFlag{Name: "nonce", Usage: "Nonce in hex", Required: true},
+		},
+		Action: func(c *cli.Context) error {
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				accepted, err := rpc.SubmitWork(ctx, c.String("powhash"), c.String("nonce"))
+				if err != nil {
+					return fmt.Errorf("error calling SubmitWork: %v", err)
+				}
+				fmt.Println("Accepted:", accepted)
+				return nil
+			})
 		},
 	}
 
@@ -189,41 +248,29 @@ var (
 		Name:  "getHashRate",
 		Usage: "Get current hash rate",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Fatalf("Error connecting to RPC: %v", err)
-			}
-			defer client.Close()
-
-			req := network.GetHashRateArgs{}
-			var res network.GetHashRateRes
-			if err = client.Call("RPCServer.GetHashRate", req, &res); err != nil {
-				log.Fatalf("Error calling GetHashRate: %v", err)
-			}
-			hashRateJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("HashRate:", string(hashRateJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				hashrate, err := rpc.HashRate(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetHashRate: %v", err)
+				}
+				fmt.Println("HashRate:", hashrate)
+				return nil
+			})
 		},
 	}
+
 	Coinbase = &cli.Command{
 		Name:  "coinbase",
 		Usage: "Get Coinbase address",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.CoinbaseArgs{}
-			var res network.CoinbaseRes
-			if err = client.Call("RPCServer.Coinbase", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			coinbaseJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("Coinbase:", string(coinbaseJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				address, err := rpc.Coinbase(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling Coinbase: %v", err)
+				}
+				fmt.Println("Coinbase:", address)
+				return nil
+			})
 		},
 	}
 
@@ -231,21 +278,14 @@ var (
 		Name:  "isMining",
 		Usage: "Check if node is currently mining",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.MiningArgs{}
-			var res network.MiningRes
-			if err = client.Call("RPCServer.Mining", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			miningJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("isMining:", string(miningJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				mining, err := rpc.Mining(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling Mining: %v", err)
+				}
+				fmt.Println("isMining:", mining)
+				return nil
+			})
 		},
 	}
 
@@ -256,21 +296,14 @@ var (
 			&cli.StringFlag{Name: "address", Usage: "Address of peer to add", Required: true},
 		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.AddPeerArgs{PeerAddress: c.String("address")}
-			var res network.AddPeerRes
-			if err = client.Call("RPCServer.AddPeer", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			addPeerJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("add peer result:", string(addPeerJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				success, err := rpc.AddPeer(ctx, c.String("address"))
+				if err != nil {
+					return fmt.Errorf("error calling AddPeer: %v", err)
+				}
+				fmt.Println("add peer result:", success)
+				return nil
+			})
 		},
 	}
 
@@ -278,21 +311,14 @@ var (
 		Name:  "getDataDir",
 		Usage: "Get the data directory path",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.GetDataDirArgs{}
-			var res network.GetDataDirRes
-			if err = client.Call("RPCServer.GetDataDir", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			getDataDirJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("dataDirectory:", string(getDataDirJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				dataDir, err := rpc.DataDir(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetDataDir: %v", err)
+				}
+				fmt.Println("dataDirectory:", dataDir)
+				return nil
+			})
 		},
 	}
 
@@ -300,21 +326,14 @@ var (
 		Name:  "getNodeInfo",
 		Usage: "Get information about the node",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.GetNodeInfoArgs{}
-			var res network.GetNodeInfoRes
-			if err = client.Call("RPCServer.GetNodeInfo", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			getNodeInfoJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("nodeInfo:", string(getNodeInfoJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				info, err := rpc.NodeInfo(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetNodeInfo: %v", err)
+				}
+				printJSON("nodeInfo:", info)
+				return nil
+			})
 		},
 	}
 
@@ -322,21 +341,14 @@ var (
 		Name:  "getPeer",
 		Usage: "Get information about connected peers",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.GetPeerArgs{}
-			var res network.GetPeerRes
-			if err = client.Call("RPCServer.GetPeer", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			getPeerJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("peers:", string(getPeerJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				peers, err := rpc.Peers(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetPeer: %v", err)
+				}
+				printJSON("peers:", peers)
+				return nil
+			})
 		},
 	}
 
@@ -347,21 +359,14 @@ var (
 			&cli.StringFlag{Name: "address", Usage: "Address of peer to remove", Required: true},
 		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.RemovePeerArgs{PeerAddress: c.String("address")}
-			var res network.RemovePeerRes
-			if err = client.Call("RPCServer.RemovePeer", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			removePeerJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("remove peer result:", string(removePeerJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				success, err := rpc.RemovePeer(ctx, c.String("address"))
+				if err != nil {
+					return fmt.Errorf("error calling RemovePeer: %v", err)
+				}
+				fmt.Println("remove peer result:", success)
+				return nil
+			})
 		},
 	}
 
@@ -372,21 +377,14 @@ var (
 			&cli.StringFlag{Name: "address", Usage: "Address to set as XPBase", Required: true},
 		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.SetXpbaseArgs{Address: c.String("address")}
-			var res network.SetXpbaseRes
-			if err = client.Call("RPCServer.SetXpbase", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			setXpbaseJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("set xpbase result:", string(setXpbaseJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				success, err := rpc.SetXpbase(ctx, c.String("address"))
+				if err != nil {
+					return fmt.Errorf("error calling SetXpbase: %v", err)
+				}
+				fmt.Println("set xpbase result:", success)
+				return nil
+			})
 		},
 	}
 
@@ -394,21 +392,14 @@ var (
 		Name:  "getNodeHashRate",
 		Usage: "Get the node's hash rate",
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
-
-			req := network.GetNodeHashRateArgs{}
-			var res network.GetNodeHashRateRes
-			if err = client.Call("RPCServer.GetNodeHashRate", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
-
-			nodeHashRateJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("NodeHashRate:", string(nodeHashRateJSON))
-			return nil
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				hashrate, err := rpc.NodeHashRate(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling GetNodeHashRate: %v", err)
+				}
+				fmt.Println("NodeHashRate:", hashrate)
+				return nil
+			})
 		},
 	}
 
@@ -419,21 +410,47 @@ var (
 			&cli.Int64Flag{Name: "height", Usage: "Height to check difficulty", Required: true},
 		},
 		Action: func(c *cli.Context) error {
-			client, err := rpc.DialHTTP("tcp", fmt.Sprintf("localhost:%d", config.GlobalConfig.RPCPort))
-			if err != nil {
-				log.Panic("Error dialing RPC:", err)
-			}
-			defer client.Close()
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				difficulty, err := rpc.Difficulty(ctx, c.Int64("height"))
+				if err != nil {
+					return fmt.Errorf("error calling GetDifficulty: %v", err)
+				}
+				fmt.Println("Difficulty:", difficulty)
+				return nil
+			})
+		},
+	}
 
-			req := network.GetDifficultyArgs{Height: c.Int64("height")}
-			var res network.GetDifficultyRes
-			if err = client.Call("RPCServer.GetDifficulty", req, &res); err != nil {
-				log.Panic("Error calling RPC:", err)
-			}
+	Rewind = &cli.Command{
+		Name:  "rewind",
+		Usage: "Rewind the running node to a block height, keeping the removed blocks",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "to-height", Usage: "Height of the new chain tip", Required: true},
+		},
+		Action: func(c *cli.Context) error {
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				removed, hash, err := rpc.Rewind(ctx, c.Int64("to-height"))
+				if err != nil {
+					return fmt.Errorf("error calling Rewind: %v", err)
+				}
+				fmt.Printf("Removed %d blocks, new tip: %s\n", removed, hash)
+				return nil
+			})
+		},
+	}
 
-			difficultyJSON, _ := json.MarshalIndent(res, "", "  ")
-			fmt.Println("Difficulty:", string(difficultyJSON))
-			return nil
+	ReapplyRewound = &cli.Command{
+		Name:  "reapplyRewound",
+		Usage: "Re-add rewound blocks that extend the running node's chain tip",
+		Action: func(c *cli.Context) error {
+			return withClient(c, func(ctx context.Context, rpc *client.Client) error {
+				applied, height, err := rpc.ReapplyRewound(ctx)
+				if err != nil {
+					return fmt.Errorf("error calling ReapplyRewound: %v", err)
+				}
+				fmt.Printf("Reapplied %d blocks, height: %d\n", applied, height)
+				return nil
+			})
 		},
 	}
 )
@@ -0,0 +1,130 @@
+package nodecmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/urfave/cli/v2"
+)
+
+var SnapshotCommands = &cli.Command{
+	Name:        "snapshot",
+	Usage:       "Create and restore chain snapshots for fast bootstrap",
+	Subcommands: []*cli.Command{CreateSnapshot, RestoreSnapshot},
+}
+
+var CreateSnapshot = &cli.Command{
+	Name:      "create",
+	Usage:     "Write a snapshot of the header chain up to a height and print its content hash",
+	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{Name: "height", Value: -1, Usage: "Snapshot height (default: best height)"},
+	},
+	Action: func(c *cli.Context) error {
+		path := c.Args().First()
+		if path == "" {
+			return fmt.Errorf("snapshot file is required")
+		}
+
+		chain, err := blockchain.ContinueBlockChain(strconv.Itoa(config.GlobalConfig.ChainId))
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		height := c.Int64("height")
+		if height < 0 {
+			height = chain.GetBestHeight()
+		}
+
+		tmp := path + ".tmp"
+		file, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp)
+
+		sum, err := chain.CreateSnapshot(file, height)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+
+		fmt.Printf("Snapshot at height %d written to %s\n", height, path)
+		fmt.Printf("snapshotHeight: %d\nsnapshotHash: %x\n", height, sum)
+		return nil
+	},
+}
+
+var RestoreSnapshot = &cli.Command{
+	Name:      "restore",
+	Usage:     "Restore a snapshot whose content hash matches the trusted checkpoint",
+	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "hash", Usage: "Trusted snapshot hash (default: snapshotHash from config)"},
+	},
+	Action: func(c *cli.Context) error {
+		path := c.Args().First()
+		if path == "" {
+			return fmt.Errorf("snapshot file is required")
+		}
+		trustedHex := c.String("hash")
+		if trustedHex == "" {
+			trustedHex = config.GlobalConfig.SnapshotHash
+		}
+		if trustedHex == "" {
+			return fmt.Errorf("a trusted snapshot hash is required (--hash or snapshotHash in config)")
+		}
+		trusted, err := hex.DecodeString(trustedHex)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot hash: %v", err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// 데이터베이스를 만들기 전에 스냅샷을 검증
+		header, sum, err := blockchain.VerifySnapshot(file)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(sum, trusted) {
+			return fmt.Errorf("%w: snapshot %x, trusted %x", blockchain.ErrSnapshotUntrusted, sum, trusted)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		chainId := strconv.Itoa(config.GlobalConfig.ChainId)
+		var chain *blockchain.BlockChain
+		if blockchain.DBexists(blockchain.DBPath(chainId)) {
+			chain, err = blockchain.ContinueBlockChain(chainId)
+		} else {
+			chain, err = blockchain.InitEmptyBlockChain(chainId, header.Params)
+		}
+		if err != nil {
+			return err
+		}
+		defer chain.Close()
+
+		if err := chain.RestoreSnapshot(file, trusted); err != nil {
+			return err
+		}
+		fmt.Printf("Snapshot restored, height %d, hash %x\n", chain.GetBestHeight(), chain.LastHash)
+		return nil
+	},
+}
@@ -0,0 +1,464 @@
+// Package client는 노드 RPC 서버에 접속하는 Go 클라이언트입니다.
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/rpc"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/network"
+)
+
+// 기본 호출 제한 시간과 재시도 설정
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultRetries    = 2
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+// rpc.HandleHTTP와 같은 CONNECT 응답 상태
+const connectedStatus = "200 Connected to Go RPC"
+
+// ErrUnauthorized는 서버가 토큰을 거부했을 때 반환됩니다.
+var ErrUnauthorized = errors.New("rpc: unauthorized")
+
+// 요청이 전달된 뒤 연결이 끊겨도 다시 보낼 수 있는 읽기 전용 메서드.
+// 그 밖의 메서드는 서버가 이미 처리했을 수 있으므로 연결 전에 실패한 경우에만 재시도
+var readOnlyMethods = map[string]bool{
+	"GetBlockNumber":   true,
+	"GetBestHeight":    true,
+	"GetLastBlockHash": true,
+	"GetBlock":         true,
+	"GetBlockByNumber": true,
+	"GetHeadersRange":  true,
+	"GetBlockHashes":   true,
+	"GetBlockList":     true,
+	"GetDifficulty":    true,
+	"GetHashRate":      true,
+	"GetNodeHashRate":  true,
+	"Coinbase":         true,
+	"Mining":           true,
+	"GetPeer":          true,
+	"GetDataDir":       true,
+	"GetNodeInfo":      true,
+}
+
+// Client는 노드 RPC 서버의 gob RPC 엔드포인트를 호출합니다.
+// 연결이 끊어지면 다음 호출에서 다시 연결하며, 연결 오류와 읽기 전용 메서드의 전송 오류는 정해진 횟수만큼 재시도합니다.
+type Client struct {
+	addr       string
+	useTLS     bool
+	tlsConfig  *tls.Config
+	token      string
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+
+	mu  sync.Mutex
+	rpc *rpc.Client
+}
+
+// Option은 Client 설정을 바꿉니다.
+type Option func(*Client)
+
+// WithToken은 Authorization 헤더로 보낼 bearer 토큰 또는 JWT를 설정합니다.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithTimeout은 ctx에 deadline이 없을 때 적용할 호출별 제한 시간을 설정합니다.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithRetries는 연결 오류와 읽기 전용 메서드의 전송 오류를 재시도할 횟수와 간격을 설정합니다.
+func WithRetries(retries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryDelay = delay
+	}
+}
+
+// WithTLSConfig는 https 엔드포인트에 사용할 TLS 설정을 지정합니다.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) { c.tlsConfig = cfg }
+}
+
+// Dial은 endpoint("host:port", "http://host:port" 또는 "https://host:port")에 연결합니다.
+func Dial(ctx context.Context, endpoint string, opts ...Option) (*Client, error) {
+	c := &Client{
+		timeout:    DefaultTimeout,
+		retries:    DefaultRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+
+	switch {
+	case strings.HasPrefix(endpoint, "https://"):
+		c.useTLS = true
+		c.addr = strings.TrimPrefix(endpoint, "https://")
+	case strings.HasPrefix(endpoint, "http://"):
+		c.addr = strings.TrimPrefix(endpoint, "http://")
+	default:
+		c.addr = endpoint
+	}
+	c.addr = strings.TrimSuffix(c.addr, "/")
+	if _, _, err := net.SplitHostPort(c.addr); err != nil {
+		return nil, fmt.Errorf("invalid rpc endpoint %q: %v", endpoint, err)
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if _, err := c.client(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close는 연결을 닫습니다.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rpc == nil {
+		return nil
+	}
+	err := c.rpc.Close()
+	c.rpc = nil
+	return err
+}
+
+// client는 현재 연결을 반환하며, 없으면 새로 연결합니다.
+func (c *Client) client(ctx context.Context) (*rpc.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rpc != nil {
+		return c.rpc, nil
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.rpc = rpc.NewClient(conn)
+	return c.rpc, nil
+}
+
+// connect는 TCP(또는 TLS) 연결을 열고 CONNECT 요청으로 gob RPC 연결을 엽니다.
+func (c *Client) connect(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to rpc server %s: %w", c.addr, err)
+	}
+
+	if c.useTLS {
+		cfg := c.tlsConfig
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg = cfg.Clone()
+			cfg.ServerName, _, _ = net.SplitHostPort(c.addr)
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("tls handshake with %s failed: %w", c.addr, err)
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	request := "CONNECT " + rpc.DefaultRPCPath + " HTTP/1.0\r\n"
+	if c.token != "" {
+		request += "Authorization: Bearer " + c.token + "\r\n"
+	}
+	if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected response from rpc server %s: %w", c.addr, err)
+	}
+	if resp.Status != connectedStatus {
+		conn.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
+		}
+		return nil, fmt.Errorf("unexpected response from rpc server %s: %s", c.addr, resp.Status)
+	}
+	return conn, nil
+}
+
+// reset은 끊어진 연결을 버려 다음 호출에서 다시 연결하도록 합니다.
+func (c *Client) reset(broken *rpc.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rpc == broken {
+		c.rpc.Close()
+		c.rpc = nil
+	}
+}
+
+// call은 RPCServer의 메서드를 호출합니다. 서버가 반환한 오류는 재시도하지 않으며,
+// 읽기 전용이 아닌 메서드는 요청을 보낸 뒤의 전송 오류도 재시도하지 않습니다.
+func (c *Client) call(ctx context.Context, method string, args, reply interface{}) error {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(c.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		var client *rpc.Client
+		client, err = c.client(ctx)
+		if err == nil {
+			call := client.Go("RPCServer."+method, args, reply, make(chan *rpc.Call, 1))
+			select {
+			case <-call.Done:
+				err = call.Error
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if !isTransportError(err) {
+				return serverError(err)
+			}
+			c.reset(client)
+			if !readOnlyMethods[method] {
+				return err
+			}
+		}
+		if errors.Is(err, ErrUnauthorized) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// serverError는 서버가 보낸 "block not found", "block pruned", "block body not synced yet" 오류를
+// blockchain.ErrBlockNotFound, blockchain.ErrBlockPruned, blockchain.ErrBodyNotSynced로 감쌉니다.
+func serverError(err error) error {
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) {
+		return err
+	}
+	for _, target := range []error{blockchain.ErrBlockNotFound, blockchain.ErrBlockPruned, blockchain.ErrBodyNotSynced} {
+		if strings.HasPrefix(string(serverErr), target.Error()) {
+			return fmt.Errorf("%w%s", target, strings.TrimPrefix(string(serverErr), target.Error()))
+		}
+	}
+	return err
+}
+
+// isTransportError는 연결 문제로 발생해 재시도할 수 있는 오류인지 판단합니다.
+func isTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
+
+// BlockNumber는 현재 블록 높이를 반환합니다.
+func (c *Client) BlockNumber(ctx context.Context) (int64, error) {
+	var res network.GetBlockNumberRes
+	err := c.call(ctx, "GetBlockNumber", &network.GetBlockNumberArgs{}, &res)
+	return res.Height, err
+}
+
+// BestHeight는 현재 블록 높이를 반환합니다.
+func (c *Client) BestHeight(ctx context.Context) (int64, error) {
+	var res network.GetBestHeightRes
+	err := c.call(ctx, "GetBestHeight", &network.GetBestHeightArgs{}, &res)
+	return res.Height, err
+}
+
+// LastBlockHash는 마지막 블록 해시(16진수)를 반환합니다.
+func (c *Client) LastBlockHash(ctx context.Context) (string, error) {
+	var res network.GetLastBlockHashRes
+	err := c.call(ctx, "GetLastBlockHash", &network.GetLastBlockHashArgs{}, &res)
+	return res.Hash, err
+}
+
+// BlockByHash는 해시(16진수)로 블록을 조회합니다. 블록이 없으면 blockchain.ErrBlockNotFound를 반환합니다.
+func (c *Client) BlockByHash(ctx context.Context, hash string) (*blockchain.Block, error) {
+	var res network.GetBlockRes
+	if err := c.call(ctx, "GetBlock", &network.GetBlockArgs{Hash: hash}, &res); err != nil {
+		return nil, err
+	}
+	return &res.Block, nil
+}
+
+// BlockByNumber는 높이(10진수 또는 0x 16진수), "latest" 또는 "earliest"로 블록을 조회합니다.
+func (c *Client) BlockByNumber(ctx context.Context, number string) (*blockchain.Block, error) {
+	var res network.GetBlockByNumberRes
+	if err := c.call(ctx, "GetBlockByNumber", &network.GetBlockByNumberArgs{Number: number}, &res); err != nil {
+		return nil, err
+	}
+	return &res.Block, nil
+}
+
+// HeadersRange는 from부터 최대 count개의 블록 헤더를 반환합니다. 서버 최대값보다 적게 반환될 수 있습니다.
+func (c *Client) HeadersRange(ctx context.Context, from int64, count int) ([]blockchain.Header, error) {
+	var res network.GetHeadersRangeRes
+	err := c.call(ctx, "GetHeadersRange", &network.GetHeadersRangeArgs{From: from, Count: count}, &res)
+	return res.Headers, err
+}
+
+// BlockHashes는 모든 블록 해시를 높이 순서대로 반환합니다.
+func (c *Client) BlockHashes(ctx context.Context) ([]string, error) {
+	var res network.GetBlockHashesRes
+	err := c.call(ctx, "GetBlockHashes", &network.GetBlockHashesArgs{}, &res)
+	return res.Hash, err
+}
+
+// BlockList는 from부터 to까지의 블록 중 한 페이지와 다음 페이지 시작 높이(없으면 -1)를 반환합니다.
+func (c *Client) BlockList(ctx context.Context, from, to int64, limit int) ([]blockchain.Block, int64, error) {
+	var res network.GetBlockListRes
+	err := c.call(ctx, "GetBlockList", &network.GetBlockListArgs{From: from, To: to, Limit: limit}, &res)
+	return res.Block, res.Next, err
+}
+
+// Difficulty는 height에서의 난이도를 반환합니다.
+func (c *Client) Difficulty(ctx context.Context, height int64) (*big.Int, error) {
+	var res network.GetDifficultyRes
+	err := c.call(ctx, "GetDifficulty", &network.GetDifficultyArgs{Height: height}, &res)
+	return res.Difficulty, err
+}
+
+// Work는 외부 채굴용 작업을 받아옵니다.
+func (c *Client) Work(ctx context.Context) (*network.GetWorkRes, error) {
+	var res network.GetWorkRes
+	if err := c.call(ctx, "GetWork", &network.GetWorkArgs{}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SubmitWork는 Work로 받은 작업의 nonce를 제출합니다. nonce가 목표값을 만족하지 않으면 false를 반환합니다.
+func (c *Client) SubmitWork(ctx context.Context, powHash, nonce string) (bool, error) {
+	var res network.SubmitWorkRes
+	err := c.call(ctx, "SubmitWork", &network.SubmitWorkArgs{PowHash: powHash, Nonce: nonce}, &res)
+	return res.Accepted, err
+}
+
+// HashRate는 네트워크 해시레이트를 반환합니다.
+func (c *Client) HashRate(ctx context.Context) (int, error) {
+	var res network.GetHashRateRes
+	err := c.call(ctx, "GetHashRate", &network.GetHashRateArgs{}, &res)
+	return res.Hashrate, err
+}
+
+// NodeHashRate는 노드의 해시레이트를 반환합니다.
+func (c *Client) NodeHashRate(ctx context.Context) (int, error) {
+	var res network.GetNodeHashRateRes
+	err := c.call(ctx, "GetNodeHashRate", &network.GetNodeHashRateArgs{}, &res)
+	return res.Hashrate, err
+}
+
+// Coinbase는 채굴 보상 주소를 반환합니다.
+func (c *Client) Coinbase(ctx context.Context) (string, error) {
+	var res network.CoinbaseRes
+	err := c.call(ctx, "Coinbase", &network.CoinbaseArgs{}, &res)
+	return res.CoinbaseAddress, err
+}
+
+// Mining은 노드가 채굴 중인지 반환합니다.
+func (c *Client) Mining(ctx context.Context) (bool, error) {
+	var res network.MiningRes
+	err := c.call(ctx, "Mining", &network.MiningArgs{}, &res)
+	return res.IsMining, err
+}
+
+// SetXpbase는 채굴 보상 주소를 설정합니다.
+func (c *Client) SetXpbase(ctx context.Context, address string) (bool, error) {
+	var res network.SetXpbaseRes
+	err := c.call(ctx, "SetXpbase", &network.SetXpbaseArgs{Address: address}, &res)
+	return res.Success, err
+}
+
+// AddPeer는 peer를 추가합니다.
+func (c *Client) AddPeer(ctx context.Context, address string) (bool, error) {
+	var res network.AddPeerRes
+	err := c.call(ctx, "AddPeer", &network.AddPeerArgs{PeerAddress: address}, &res)
+	return res.Success, err
+}
+
+// RemovePeer는 peer를 제거합니다.
+func (c *Client) RemovePeer(ctx context.Context, address string) (bool, error) {
+	var res network.RemovePeerRes
+	err := c.call(ctx, "RemovePeer", &network.RemovePeerArgs{PeerAddress: address}, &res)
+	return res.Success, err
+}
+
+// Rewind는 노드의 tip을 height로 되돌리고 뺀 블록 수와 새 tip 해시를 반환합니다.
+func (c *Client) Rewind(ctx context.Context, height int64) (int, string, error) {
+	var res network.RewindRes
+	err := c.call(ctx, "Rewind", &network.RewindArgs{Height: height}, &res)
+	return res.Removed, res.Hash, err
+}
+
+// ReapplyRewound는 되돌린 블록을 다시 추가하고 추가한 블록 수와 새 높이를 반환합니다.
+func (c *Client) ReapplyRewound(ctx context.Context) (int, int64, error) {
+	var res network.ReapplyRewoundRes
+	err := c.call(ctx, "ReapplyRewound", &network.ReapplyRewoundArgs{}, &res)
+	return res.Applied, res.Height, err
+}
+
+// Peers는 연결된 peer 목록을 반환합니다.
+func (c *Client) Peers(ctx context.Context) ([]network.Peer, error) {
+	var res network.GetPeerRes
+	err := c.call(ctx, "GetPeer", &network.GetPeerArgs{}, &res)
+	return res.Peers, err
+}
+
+// DataDir은 노드의 데이터베이스 경로를 반환합니다.
+func (c *Client) DataDir(ctx context.Context) (string, error) {
+	var res network.GetDataDirRes
+	err := c.call(ctx, "GetDataDir", &network.GetDataDirArgs{}, &res)
+	return res.DataDirectory, err
+}
+
+// NodeInfo는 노드 정보를 반환합니다.
+func (c *Client) NodeInfo(ctx context.Context) (*network.GetNodeInfoRes, error) {
+	var res network.GetNodeInfoRes
+	if err := c.call(ctx, "GetNodeInfo", &network.GetNodeInfoArgs{}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startDroppingServer는 CONNECT에 응답한 뒤 요청을 읽자마자 연결을 끊는 서버를 띄우고, 받은 요청 수를 세는 카운터를 반환합니다.
+func startDroppingServer(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var requests atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if _, err := http.ReadRequest(r); err != nil {
+					return
+				}
+				io.WriteString(conn, "HTTP/1.0 "+connectedStatus+"\n\n")
+				if _, err := r.ReadByte(); err != nil {
+					return
+				}
+				requests.Add(1)
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), &requests
+}
+
+func TestRetryReadOnlyMethods(t *testing.T) {
+	addr, requests := startDroppingServer(t)
+	c, err := Dial(context.Background(), addr, WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.BestHeight(context.Background()); err == nil {
+		t.Fatal("expected transport error")
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("GetBestHeight sent %d times, want 3", n)
+	}
+}
+
+func TestNoRetryForSubmitWork(t *testing.T) {
+	addr, requests := startDroppingServer(t)
+	c, err := Dial(context.Background(), addr, WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.SubmitWork(context.Background(), "0x00", "0x00"); err == nil {
+		t.Fatal("expected transport error")
+	}
+	// 서버가 요청을 읽은 뒤에 끊겼으므로 다시 보내지 않아야 함
+	time.Sleep(50 * time.Millisecond)
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("SubmitWork sent %d times, want 1", n)
+	}
+}
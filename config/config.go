@@ -1,54 +1,221 @@
 package config
 
 import (
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
-	"math/big"
-	"os"
+	"path/filepath"
+
+	"github.com/Kim-DaeHan/mining-chain/logger"
+	"github.com/Kim-DaeHan/mining-chain/params"
 )
 
+// Config는 노드별 설정입니다.
+// 난이도 조정 같은 합의 파라미터는 params.ChainParams로 genesis 시점에 데이터베이스에 저장됩니다.
 type Config struct {
-	ChainId                 int     `json:"chainId"`
-	Port                    int     `json:"port"`
-	RPCPort                 int     `json:"rpcPort"`
-	NodeType                string  `json:"nodeType"`
-	Mining                  bool    `json:"mining"`
-	DEFAULT_DIFFICULTY      big.Int // 하드코딩된 값
-	DIFFICULTY_CHANGE_CYCLE int64   // 하드코딩된 값
-	RESOURCE_INTERVAL       int64   // 하드코딩된 값
-	MAX_DIFFICULTY_WEIGHT   float64 // 하드코딩된 값
-	MIN_DIFFICULTY_WEIGHT   float64 // 하드코딩된 값
+	ChainId     int    `json:"chainId"`
+	Port        int    `json:"port"`
+	RPCPort     int    `json:"rpcPort"`
+	NodeType    string `json:"nodeType"`
+	Mining      bool   `json:"mining"`
+	GenesisHash string `json:"genesisHash"` // 비어있지 않으면 저장된 genesis 해시와 비교
+	Network     string `json:"network"`     // 체인 파라미터 프리셋(mainnet, testnet, devnet)
+	DataDir     string `json:"dataDir"`     // 블록 데이터베이스와 잠금 파일이 위치하는 디렉터리
+	DBEngine    string `json:"dbEngine"`    // 저장소 백엔드(leveldb, pebble, memory)
+	MaxPeers    int    `json:"maxPeers"`    // SIGHUP으로 다시 읽을 수 있음
+	BlockCache  int    `json:"blockCache"`  // 메모리에 캐시할 블록 수(0이면 캐시하지 않음)
+	PruneRetain int64  `json:"pruneRetain"` // pruned 노드가 본문까지 남길 최근 높이 수
+
+	// 신뢰하는 스냅샷 체크포인트. 설정하면 이 높이보다 낮은 노드는 처음 시작할 때 peer에게서 스냅샷을 받고,
+	// 스냅샷 내용 해시가 snapshotHash와 같을 때만 사용
+	SnapshotHeight int64  `json:"snapshotHeight"`
+	SnapshotHash   string `json:"snapshotHash"`
+	Metrics        bool   `json:"metrics"` // RPC HTTP 서버에 /metrics 엔드포인트를 노출
+
+	// RPC 서버 설정. rpcTokens와 rpcJWTSecret이 모두 비어있으면 admin/miner 메서드는 로컬 접속에서만 허용
+	RPCAddr        string            `json:"rpcAddr"`    // 바인드 주소
+	RPCTLSCert     string            `json:"rpcTLSCert"` // 인증서와 키를 모두 지정하면 HTTPS로 제공
+	RPCTLSKey      string            `json:"rpcTLSKey"`
+	RPCTokens      map[string]string `json:"rpcTokens"`      // 그룹(admin, miner)별 bearer 토큰. admin 토큰은 모든 그룹 허용
+	RPCJWTSecret   string            `json:"rpcJWTSecret"`   // HS256 JWT 서명 키(32바이트 이상)
+	RPCCorsOrigins []string          `json:"rpcCorsOrigins"` // 허용할 Origin 목록("*"는 모두 허용)
+	RPCRateLimit   int               `json:"rpcRateLimit"`   // IP별 초당 요청 수(0이면 제한 없음)
+	RPCRateBurst   int               `json:"rpcRateBurst"`   // IP별 순간 최대 요청 수(0이면 rpcRateLimit)
+
+	// 로그 설정. logLevel, logLevels는 SIGHUP으로 다시 읽을 수 있음
+	LogLevel      string            `json:"logLevel"`
+	LogLevels     map[string]string `json:"logLevels"` // 모듈별 레벨(network, blockchain, mining, rpc, config)
+	LogFormat     string            `json:"logFormat"` // text 또는 json
+	LogFile       string            `json:"logFile"`   // 비어있으면 stderr
+	LogMaxSizeMB  int               `json:"logMaxSizeMB"`
+	LogMaxBackups int               `json:"logMaxBackups"`
 }
 
-// 전역 설정 파일 경로
-const configPath = "./tmp/config.json"
+// 기본 데이터 디렉터리와 그 안의 설정 파일 이름
+const (
+	DefaultDataDir = "./tmp"
+	configFileName = "config.json"
+)
+
+// 노드 유형. pruned 노드는 최근 pruneRetain개 높이의 블록만 본문까지 남기고 그 이전은 헤더만 남김.
+// light 노드는 헤더만 저장하고 채굴하지 않으며, 블록 본문은 필요할 때 peer에게서 받음
+var nodeTypes = []string{"full-node", "cn", "pruned", "light"}
+
+// pruned 노드가 남길 최소 높이 수
+const minPruneRetain = 64
+
+// 저장소 백엔드. storage.Engines와 같음
+var dbEngines = []string{"leveldb", "pebble", "memory"}
+
+// 인증이 필요한 RPC 메서드 그룹. public 그룹은 항상 허용
+var rpcAuthGroups = []string{"admin", "miner"}
+
+// JWT 서명 키 최소 길이
+const minJWTSecretLen = 32
+
+// Defaults는 설정 파일이 없을 때 사용하는 기본값을 반환합니다.
+func Defaults() Config {
+	return Config{
+		ChainId:     1,           // 하드 코딩된 기본값
+		Port:        8080,        // 하드 코딩된 기본값
+		RPCPort:     8545,        // 하드 코딩된 기본값
+		NodeType:    "full-node", // 하드 코딩된 기본값
+		Mining:      false,       // 하드 코딩된 기본값
+		DataDir:     DefaultDataDir,
+		DBEngine:    "leveldb",
+		MaxPeers:    25,
+		BlockCache:  2048,
+		PruneRetain: 10000,
+		RPCAddr:     "localhost",
+
+		LogLevel:      "info",
+		LogFormat:     "text",
+		LogMaxSizeMB:  100,
+		LogMaxBackups: 5,
+	}
+}
 
 // 기본값을 가진 전역 Config 변수
-var GlobalConfig = Config{
-	ChainId:                 1,                   // 하드 코딩된 기본값
-	Port:                    8080,                // 하드 코딩된 기본값
-	RPCPort:                 8545,                // 하드 코딩된 기본값
-	NodeType:                "full-node",         // 하드 코딩된 기본값
-	Mining:                  false,               // 하드 코딩된 기본값
-	DEFAULT_DIFFICULTY:      *big.NewInt(500000), // 하드 코딩된 값
-	DIFFICULTY_CHANGE_CYCLE: 20,                  // 하드 코딩된 값
-	RESOURCE_INTERVAL:       20,                  // 하드 코딩된 값 (초 단위)
-	MAX_DIFFICULTY_WEIGHT:   4.0,                 // 하드 코딩된 값
-	MIN_DIFFICULTY_WEIGHT:   0.25,                // 하드 코딩된 값
+var GlobalConfig = Defaults()
+
+// DefaultConfigPath는 dataDir 안의 설정 파일 경로를 반환합니다.
+func DefaultConfigPath(dataDir string) string {
+	if dataDir == "" {
+		dataDir = DefaultDataDir
+	}
+	return filepath.Join(dataDir, configFileName)
+}
+
+// Validate는 설정 값의 범위와 조합을 검사합니다.
+func (c *Config) Validate() error {
+	if c.ChainId <= 0 {
+		return fmt.Errorf("chainId must be positive, got %d", c.ChainId)
+	}
+	if err := validatePort("port", c.Port); err != nil {
+		return err
+	}
+	if err := validatePort("rpcPort", c.RPCPort); err != nil {
+		return err
+	}
+	if c.Port == c.RPCPort {
+		return fmt.Errorf("port and rpcPort must differ, both are %d", c.Port)
+	}
+	if !contains(nodeTypes, c.NodeType) {
+		return fmt.Errorf("unknown nodeType %q (expected one of %v)", c.NodeType, nodeTypes)
+	}
+	if c.Network != "" {
+		if _, err := params.Preset(c.Network); err != nil {
+			return err
+		}
+	}
+	if c.DataDir == "" {
+		return fmt.Errorf("dataDir must not be empty")
+	}
+	if !contains(dbEngines, c.DBEngine) {
+		return fmt.Errorf("unknown dbEngine %q (expected one of %v)", c.DBEngine, dbEngines)
+	}
+	if c.NodeType == "pruned" && c.PruneRetain < minPruneRetain {
+		return fmt.Errorf("pruneRetain must be at least %d, got %d", minPruneRetain, c.PruneRetain)
+	}
+	if c.BlockCache < 0 {
+		return fmt.Errorf("blockCache must not be negative, got %d", c.BlockCache)
+	}
+	if c.SnapshotHash != "" {
+		if b, err := hex.DecodeString(c.SnapshotHash); err != nil || len(b) != 32 {
+			return fmt.Errorf("snapshotHash must be 32 bytes of hex")
+		}
+		if c.SnapshotHeight <= 0 {
+			return fmt.Errorf("snapshotHeight must be positive when snapshotHash is set")
+		}
+	} else if c.SnapshotHeight != 0 {
+		return fmt.Errorf("snapshotHeight requires snapshotHash")
+	}
+	if c.MaxPeers <= 0 {
+		return fmt.Errorf("maxPeers must be positive, got %d", c.MaxPeers)
+	}
+	if c.RPCAddr == "" {
+		return fmt.Errorf("rpcAddr must not be empty")
+	}
+	if (c.RPCTLSCert == "") != (c.RPCTLSKey == "") {
+		return fmt.Errorf("rpcTLSCert and rpcTLSKey must be set together")
+	}
+	for group, token := range c.RPCTokens {
+		if !contains(rpcAuthGroups, group) {
+			return fmt.Errorf("unknown rpcTokens group %q (expected one of %v)", group, rpcAuthGroups)
+		}
+		if token == "" {
+			return fmt.Errorf("rpcTokens.%s must not be empty", group)
+		}
+	}
+	if c.RPCJWTSecret != "" && len(c.RPCJWTSecret) < minJWTSecretLen {
+		return fmt.Errorf("rpcJWTSecret must be at least %d bytes", minJWTSecretLen)
+	}
+	if c.RPCRateLimit < 0 || c.RPCRateBurst < 0 {
+		return fmt.Errorf("invalid rpc rate limit: rpcRateLimit=%d rpcRateBurst=%d", c.RPCRateLimit, c.RPCRateBurst)
+	}
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	for module, level := range c.LogLevels {
+		if !logger.IsModule(module) {
+			return fmt.Errorf("unknown log module %q (expected one of %v)", module, logger.Modules)
+		}
+		if _, err := logger.ParseLevel(level); err != nil {
+			return fmt.Errorf("logLevels.%s: %v", module, err)
+		}
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("logFormat must be text or json, got %q", c.LogFormat)
+	}
+	if c.LogMaxSizeMB <= 0 || c.LogMaxBackups < 0 {
+		return fmt.Errorf("invalid log rotation: logMaxSizeMB=%d logMaxBackups=%d", c.LogMaxSizeMB, c.LogMaxBackups)
+	}
+	return nil
 }
 
-// 설정 파일을 로드하는 함수
-func LoadConfig() error {
-	file, err := os.Open(configPath)
-	if err != nil {
-		return fmt.Errorf("could not open config file: %v", err)
+// LogOptions는 로거 설정을 반환합니다.
+func (c *Config) LogOptions() logger.Options {
+	return logger.Options{
+		Level:      c.LogLevel,
+		Levels:     c.LogLevels,
+		Format:     c.LogFormat,
+		File:       c.LogFile,
+		MaxSizeMB:  c.LogMaxSizeMB,
+		MaxBackups: c.LogMaxBackups,
 	}
-	defer file.Close()
+}
 
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&GlobalConfig)
-	if err != nil {
-		return fmt.Errorf("could not decode config file: %v", err)
+func validatePort(name string, port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("%s must be between 1 and 65535, got %d", name, port)
 	}
 	return nil
 }
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
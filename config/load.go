@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 환경 변수 접두사. chainId는 MININGCHAIN_CHAIN_ID로 설정합니다.
+const EnvPrefix = "MININGCHAIN_"
+
+// cliEnvVars는 설정 키가 아니라 CLI 플래그에 대응하는 환경 변수입니다.
+var cliEnvVars = map[string]bool{
+	EnvPrefix + "CONFIG":    true,
+	EnvPrefix + "RPC_TOKEN": true,
+}
+
+// Sources는 설정을 구성하는 계층입니다.
+// 기본값 → 설정 파일 → MININGCHAIN_* 환경 변수 → CLI 플래그 순으로 덮어씁니다.
+type Sources struct {
+	Path  string            // 비어있으면 설정 파일을 읽지 않음
+	Flags map[string]string // json 키 → 값
+}
+
+var loadedSources Sources
+
+// Resolve는 모든 계층을 합쳐 검증된 설정을 반환합니다.
+// 알 수 없는 키가 있으면 오류를 반환합니다.
+func Resolve(src Sources) (Config, error) {
+	cfg := Defaults()
+
+	if src.Path != "" {
+		if err := decodeFile(src.Path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return cfg, err
+	}
+
+	keys := make([]string, 0, len(src.Flags))
+	for key := range src.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := setField(&cfg, key, src.Flags[key]); err != nil {
+			return cfg, fmt.Errorf("flag %s: %v", key, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %v", err)
+	}
+	return cfg, nil
+}
+
+// Load는 설정을 해석해 GlobalConfig에 반영하고, SIGHUP 재로딩을 위해 계층 정보를 기억합니다.
+func Load(src Sources) error {
+	cfg, err := Resolve(src)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	GlobalConfig = cfg
+	loadedSources = src
+	mu.Unlock()
+	return nil
+}
+
+// decodeFile은 확장자(.json, .toml, .yaml, .yml)에 따라 설정 파일을 읽습니다.
+// TOML/YAML은 JSON으로 바꾼 뒤 같은 규칙으로 엄격하게 디코딩합니다.
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var raw map[string]interface{}
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("could not decode config file: %v", err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return err
+		}
+	case ".yaml", ".yml":
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("could not decode config file: %v", err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("could not decode config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv는 MININGCHAIN_* 환경 변수를 반영합니다. 설정에 없는 변수는 오류입니다.
+func applyEnv(cfg *Config, environ []string) error {
+	envKeys := make(map[string]string)
+	for _, key := range fieldKeys() {
+		envKeys[EnvName(key)] = key
+	}
+
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		key, known := envKeys[name]
+		if !known {
+			if cliEnvVars[name] {
+				continue
+			}
+			return fmt.Errorf("unknown config environment variable %s", name)
+		}
+		if err := setField(cfg, key, value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// EnvName은 json 키에 대응하는 환경 변수 이름을 반환합니다. (rpcPort → MININGCHAIN_RPC_PORT)
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	runes := []rune(key)
+	for i, r := range runes {
+		// 소문자→대문자 경계와 약어 뒤 단어 시작(TLSCert → TLS_CERT)에서 구분
+		lowerBefore := i > 0 && !unicode.IsUpper(runes[i-1])
+		wordAfterAcronym := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(r) && (lowerBefore || wordAfterAcronym) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func fieldKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func jsonKey(f reflect.StructField) string {
+	key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if key == "-" {
+		return ""
+	}
+	return key
+}
+
+// setField는 json 키로 지정한 필드에 문자열 값을 변환해 넣습니다.
+func setField(cfg *Config, key, value string) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if jsonKey(t.Field(i)) != key {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", value)
+			}
+			field.SetInt(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", value)
+			}
+			field.SetBool(b)
+		case reflect.Map:
+			// "network=debug,rpc=warn" 형식
+			m := make(map[string]string)
+			for _, pair := range strings.Split(value, ",") {
+				if pair == "" {
+					continue
+				}
+				k, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("invalid key=value pair %q", pair)
+				}
+				m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+			}
+			field.Set(reflect.ValueOf(m))
+		case reflect.Slice:
+			// "a,b,c" 형식
+			var list []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			field.Set(reflect.ValueOf(list))
+		default:
+			return fmt.Errorf("unsupported config type %s", field.Kind())
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown config key %q", key)
+}
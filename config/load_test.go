@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile은 임시 디렉터리에 name으로 설정 파일을 기록하고 경로를 반환합니다.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 기본값 → 설정 파일 → 환경 변수 → CLI 플래그 순으로 덮어씀
+func TestResolveLayering(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"port": 9000, "maxPeers": 10, "blockCache": 16}`)
+	t.Setenv("MININGCHAIN_PORT", "9001")
+	t.Setenv("MININGCHAIN_MAX_PEERS", "20")
+	t.Setenv("MININGCHAIN_CONFIG", path) // CLI 플래그용 변수는 설정 키가 아니어도 허용
+
+	cfg, err := Resolve(Sources{Path: path, Flags: map[string]string{"port": "9002"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Defaults()
+	want.Port, want.MaxPeers, want.BlockCache = 9002, 20, 16
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("got %+v\nwant %+v", cfg, want)
+	}
+}
+
+// 같은 설정은 JSON, TOML, YAML 어느 형식으로 읽어도 같음
+func TestDecodeFileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"chainId": 7, "mining": true, "rpcCorsOrigins": ["a", "b"], "logLevels": {"network": "debug"}}`,
+		"config.toml": "chainId = 7\nmining = true\nrpcCorsOrigins = [\"a\", \"b\"]\n[logLevels]\nnetwork = \"debug\"\n",
+		"config.yaml": "chainId: 7\nmining: true\nrpcCorsOrigins: [a, b]\nlogLevels:\n  network: debug\n",
+	}
+	want := Defaults()
+	want.ChainId, want.Mining = 7, true
+	want.RPCCorsOrigins = []string{"a", "b"}
+	want.LogLevels = map[string]string{"network": "debug"}
+
+	for name, content := range files {
+		cfg := Defaults()
+		if err := decodeFile(writeConfigFile(t, name, content), &cfg); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s: got %+v\nwant %+v", name, cfg, want)
+		}
+	}
+}
+
+func TestResolveRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		flag map[string]string
+		want string
+	}{
+		{name: "unknown file key", file: `{"maxPeer": 3}`, want: "unknown field"},
+		{name: "unknown env", env: map[string]string{"MININGCHAIN_MAX_PEER": "3"}, want: "unknown config environment variable"},
+		{name: "bad env value", env: map[string]string{"MININGCHAIN_PORT": "x"}, want: "MININGCHAIN_PORT: invalid integer"},
+		{name: "unknown flag", flag: map[string]string{"maxPeer": "3"}, want: "unknown config key"},
+		{name: "invalid result", flag: map[string]string{"rpcPort": "8080"}, want: "port and rpcPort must differ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			src := Sources{Flags: tt.flag}
+			if tt.file != "" {
+				src.Path = writeConfigFile(t, "config.json", tt.file)
+			}
+			if _, err := Resolve(src); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for key, want := range map[string]string{
+		"chainId":      "MININGCHAIN_CHAIN_ID",
+		"rpcPort":      "MININGCHAIN_RPC_PORT",
+		"rpcTLSCert":   "MININGCHAIN_RPC_TLS_CERT",
+		"rpcJWTSecret": "MININGCHAIN_RPC_JWT_SECRET",
+		"logMaxSizeMB": "MININGCHAIN_LOG_MAX_SIZE_MB",
+	} {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+	// 모든 설정 키의 환경 변수 이름이 서로 달라야 함
+	seen := map[string]string{}
+	for _, key := range fieldKeys() {
+		name := EnvName(key)
+		if other, ok := seen[name]; ok {
+			t.Errorf("%s and %s share %s", key, other, name)
+		}
+		seen[name] = key
+	}
+}
+
+func TestSetFieldLists(t *testing.T) {
+	cfg := Defaults()
+	if err := setField(&cfg, "logLevels", "network=debug, rpc = warn,"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setField(&cfg, "rpcCorsOrigins", " a ,,b"); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"network": "debug", "rpc": "warn"}; !reflect.DeepEqual(cfg.LogLevels, want) {
+		t.Errorf("logLevels %v, want %v", cfg.LogLevels, want)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(cfg.RPCCorsOrigins, want) {
+		t.Errorf("rpcCorsOrigins %v, want %v", cfg.RPCCorsOrigins, want)
+	}
+	if err := setField(&cfg, "logLevels", "network"); err == nil {
+		t.Error("pair without value accepted")
+	}
+	if err := setField(&cfg, "mining", "maybe"); err == nil {
+		t.Error("invalid boolean accepted")
+	}
+}
+
+// 다시 읽을 때는 실행 중 바꿔도 안전한 값만 반영
+func TestReloadAppliesSafeSettings(t *testing.T) {
+	savedConfig, savedSources, savedHandlers := GlobalConfig, loadedSources, reloadHandlers
+	t.Cleanup(func() { GlobalConfig, loadedSources, reloadHandlers = savedConfig, savedSources, savedHandlers })
+
+	path := writeConfigFile(t, "config.json", `{"port": 9000, "maxPeers": 10}`)
+	if err := Load(Sources{Path: path}); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded []Config
+	OnReload(func(c Config) { reloaded = append(reloaded, c) })
+
+	if err := os.WriteFile(path, []byte(`{"port": 9100, "maxPeers": 30, "logLevel": "debug"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if MaxPeers() != 30 || GlobalConfig.LogLevel != "debug" {
+		t.Fatalf("maxPeers %d, logLevel %s not reloaded", MaxPeers(), GlobalConfig.LogLevel)
+	}
+	if GlobalConfig.Port != 9000 {
+		t.Fatalf("port changed to %d without restart", GlobalConfig.Port)
+	}
+	if len(reloaded) != 1 || reloaded[0].MaxPeers != 30 {
+		t.Fatalf("reload handlers got %+v", reloaded)
+	}
+
+	// 잘못된 설정은 반영하지 않음
+	os.WriteFile(path, []byte(`{"maxPeers": 0}`), 0o644)
+	if err := Reload(); err == nil {
+		t.Fatal("invalid config reloaded")
+	}
+	if MaxPeers() != 30 {
+		t.Fatalf("maxPeers changed to %d by a failed reload", MaxPeers())
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/Kim-DaeHan/mining-chain/logger"
+)
+
+var log = logger.New(logger.Config)
+
+var (
+	mu             sync.RWMutex
+	reloadHandlers []func(Config)
+)
+
+// MaxPeers는 현재 적용 중인 최대 피어 수를 반환합니다. SIGHUP으로 바뀔 수 있습니다.
+func MaxPeers() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return GlobalConfig.MaxPeers
+}
+
+// OnReload는 설정이 다시 로드된 뒤 호출될 함수를 등록합니다.
+func OnReload(fn func(Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	reloadHandlers = append(reloadHandlers, fn)
+}
+
+// Reload는 같은 계층으로 설정을 다시 해석하고, 실행 중 바꿔도 안전한 값만 반영합니다.
+// 그 외 값의 변경은 재시작이 필요하므로 무시하고 로그로 알립니다.
+func Reload() error {
+	mu.RLock()
+	src := loadedSources
+	mu.RUnlock()
+
+	next, err := Resolve(src)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	applied := GlobalConfig
+	applied.MaxPeers = next.MaxPeers
+	applied.LogLevel = next.LogLevel
+	applied.LogLevels = next.LogLevels
+	if !reflect.DeepEqual(applied, next) {
+		log.Warn("config reload: only safe settings are applied; restart the node to apply other changes")
+	}
+	GlobalConfig = applied
+	handlers := append([]func(Config){}, reloadHandlers...)
+	mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(applied)
+	}
+	return nil
+}
+
+// WatchSIGHUP은 SIGHUP을 받을 때마다 설정을 다시 로드합니다.
+func WatchSIGHUP() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	go func() {
+		for range sigs {
+			if err := Reload(); err != nil {
+				log.Error("config reload failed", "err", err)
+				continue
+			}
+			log.Info("config reloaded", "maxPeers", MaxPeers())
+		}
+	}()
+}
@@ -0,0 +1,97 @@
+package event
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSlowConsumer는 버퍼가 가득 차 구독이 끊어졌을 때 Err 채널로 전달됩니다.
+var ErrSlowConsumer = errors.New("event: subscriber buffer full, subscription dropped")
+
+// Feed는 구독자마다 버퍼를 두는 이벤트 전파기입니다.
+// Send는 절대 막히지 않으며, 버퍼가 가득 찬 느린 구독자는 구독이 끊어집니다.
+// 0 값으로 바로 사용할 수 있습니다.
+type Feed[T any] struct {
+	mu   sync.Mutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// Subscription은 Feed 하나에 대한 구독입니다.
+type Subscription[T any] struct {
+	feed *Feed[T]
+	ch   chan T
+	err  chan error
+	once sync.Once
+}
+
+// Subscribe는 buffer 크기의 버퍼를 가진 구독을 만듭니다.
+func (f *Feed[T]) Subscribe(buffer int) *Subscription[T] {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &Subscription[T]{
+		feed: f,
+		ch:   make(chan T, buffer),
+		err:  make(chan error, 1),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[*Subscription[T]]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Send는 모든 구독자에게 v를 전달하고 전달된 구독자 수를 반환합니다.
+func (f *Feed[T]) Send(v T) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sent := 0
+	for sub := range f.subs {
+		select {
+		case sub.ch <- v:
+			sent++
+		default:
+			delete(f.subs, sub)
+			sub.close(ErrSlowConsumer)
+		}
+	}
+	return sent
+}
+
+// Len은 현재 구독자 수를 반환합니다.
+func (f *Feed[T]) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// Chan은 이벤트를 받는 채널입니다. 구독이 끝나도 닫히지 않으므로 Err와 함께 select 해야 합니다.
+func (s *Subscription[T]) Chan() <-chan T {
+	return s.ch
+}
+
+// Err는 구독이 끝나면 닫힙니다. 느린 구독자로 끊긴 경우 ErrSlowConsumer를 먼저 전달합니다.
+func (s *Subscription[T]) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe는 구독을 해지합니다. 여러 번 호출해도 안전합니다.
+func (s *Subscription[T]) Unsubscribe() {
+	s.feed.mu.Lock()
+	delete(s.feed.subs, s)
+	s.feed.mu.Unlock()
+	s.close(nil)
+}
+
+func (s *Subscription[T]) close(err error) {
+	s.once.Do(func() {
+		if err != nil {
+			s.err <- err
+		}
+		close(s.err)
+	})
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 로그 모듈 이름
+const (
+	Network    = "network"
+	Blockchain = "blockchain"
+	Mining     = "mining"
+	RPC        = "rpc"
+	Config     = "config"
+)
+
+// Modules는 레벨을 따로 지정할 수 있는 모듈 목록입니다.
+var Modules = []string{Network, Blockchain, Mining, RPC, Config}
+
+// Options는 로거 출력 설정입니다.
+type Options struct {
+	Level      string            // 기본 레벨(debug, info, warn, error)
+	Levels     map[string]string // 모듈별 레벨
+	Format     string            // text 또는 json
+	File       string            // 비어있으면 stderr
+	MaxSizeMB  int               // 로그 파일 회전 크기
+	MaxBackups int               // 보관할 이전 로그 파일 수
+}
+
+var (
+	root       atomic.Pointer[slog.Handler]
+	output     io.Closer
+	mu         sync.Mutex
+	baseLevel  slog.LevelVar
+	moduleVars = map[string]*slog.LevelVar{}
+)
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	root.Store(&h)
+	for _, m := range Modules {
+		moduleVars[m] = new(slog.LevelVar)
+	}
+}
+
+// New는 module 이름이 붙은 로거를 반환합니다.
+// Setup 이전에 만든 로거도 이후 설정을 그대로 따릅니다.
+func New(module string) *slog.Logger {
+	mu.Lock()
+	if _, ok := moduleVars[module]; !ok {
+		lv := new(slog.LevelVar)
+		lv.Set(baseLevel.Level())
+		moduleVars[module] = lv
+	}
+	level := moduleVars[module]
+	mu.Unlock()
+
+	return slog.New(&moduleHandler{level: level}).With("module", module)
+}
+
+// Setup은 출력 형식, 파일, 레벨을 적용합니다.
+func Setup(opts Options) error {
+	var w io.Writer = os.Stderr
+	var closer io.Closer
+	if opts.File != "" {
+		file, err := newRotatingFile(opts.File, opts.MaxSizeMB, opts.MaxBackups)
+		if err != nil {
+			return err
+		}
+		w, closer = file, file
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	switch opts.Format {
+	case "", "text":
+		h = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		h = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	if err := SetLevels(opts.Level, opts.Levels); err != nil {
+		return err
+	}
+
+	root.Store(&h)
+
+	mu.Lock()
+	prev := output
+	output = closer
+	mu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+	return nil
+}
+
+// SetLevels는 기본 레벨과 모듈별 레벨을 바꿉니다. 실행 중에 호출해도 안전합니다.
+func SetLevels(level string, levels map[string]string) error {
+	base, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	parsed := make(map[string]slog.Level, len(levels))
+	for module, l := range levels {
+		lv, err := ParseLevel(l)
+		if err != nil {
+			return fmt.Errorf("module %s: %v", module, err)
+		}
+		parsed[module] = lv
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	baseLevel.Set(base)
+	for module, lv := range moduleVars {
+		if l, ok := parsed[module]; ok {
+			lv.Set(l)
+		} else {
+			lv.Set(base)
+		}
+	}
+	return nil
+}
+
+// ParseLevel은 레벨 이름을 slog.Level로 바꿉니다. 빈 문자열은 info입니다.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return l, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// IsModule은 module이 알려진 모듈 이름인지 확인합니다.
+func IsModule(module string) bool {
+	for _, m := range Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleHandler는 모듈 레벨로 거른 뒤 현재 루트 핸들러로 넘깁니다.
+type moduleHandler struct {
+	level *slog.LevelVar
+	attrs []slog.Attr
+	group string
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	handler := *root.Load()
+	if len(h.attrs) > 0 {
+		handler = handler.WithAttrs(h.attrs)
+	}
+	if h.group != "" {
+		handler = handler.WithGroup(h.group)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &next
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	if next.group != "" {
+		name = next.group + "." + name
+	}
+	next.group = name
+	return &next
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupFile은 path에 json으로 기록하도록 로거를 설정하고, 테스트가 끝나면 기본 설정으로 되돌립니다.
+func setupFile(t *testing.T, opts Options) string {
+	t.Helper()
+	opts.File = filepath.Join(t.TempDir(), "node.log")
+	opts.Format = "json"
+	if err := Setup(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Setup(Options{}) })
+	return opts.File
+}
+
+// readRecords는 json 로그 파일의 기록을 읽습니다.
+func readRecords(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestModuleLevels(t *testing.T) {
+	// Setup 전에 만든 로거도 이후 설정을 따름
+	network := New(Network)
+	path := setupFile(t, Options{Level: "warn", Levels: map[string]string{Network: "debug"}})
+	mining := New(Mining)
+
+	network.Debug("network debug")
+	mining.Info("mining info")
+	mining.Warn("mining warn")
+
+	records := readRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("%d records, want 2: %v", len(records), records)
+	}
+	if records[0]["msg"] != "network debug" || records[0]["module"] != Network {
+		t.Fatalf("first record %v", records[0])
+	}
+	if records[1]["msg"] != "mining warn" || records[1]["module"] != Mining {
+		t.Fatalf("second record %v", records[1])
+	}
+
+	// 실행 중에 레벨을 바꿀 수 있음
+	if err := SetLevels("info", nil); err != nil {
+		t.Fatal(err)
+	}
+	network.Debug("dropped")
+	mining.Info("mining info again")
+	if records := readRecords(t, path); len(records) != 3 || records[2]["msg"] != "mining info again" {
+		t.Fatalf("records after SetLevels: %v", records)
+	}
+}
+
+func TestSetupRejects(t *testing.T) {
+	t.Cleanup(func() { Setup(Options{}) })
+	for _, opts := range []Options{
+		{Level: "verbose"},
+		{Levels: map[string]string{Network: "loud"}},
+		{Format: "xml"},
+	} {
+		if err := Setup(opts); err == nil {
+			t.Errorf("Setup(%+v) accepted", opts)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	r, err := newRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.maxSize = 10
+
+	// 10바이트를 넘을 때마다 밀어내고, 이전 파일은 2개까지만 남김
+	for i := 0; i < 4; i++ {
+		if _, err := fmt.Fprintf(r, "line %d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]string{"": "line 3\n", ".1": "line 2\n", ".2": "line 1\n"} {
+		data, err := os.ReadFile(path + name)
+		if err != nil || string(data) != want {
+			t.Errorf("%s: %q, %v; want %q", path+name, data, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("more backups than maxBackups: %v", err)
+	}
+}
+
+// 다시 열면 기존 크기에 이어서 기록
+func TestRotatingFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 8)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newRotatingFile(path, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.maxSize = 10
+
+	if _, err := r.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	// 이전 파일을 남기지 않으면 지우고 새로 시작
+	if data, _ := os.ReadFile(path); string(data) != "abc" {
+		t.Fatalf("log file %q after rotation", data)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("backup kept with maxBackups 0: %v", err)
+	}
+}
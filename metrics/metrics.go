@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "miningchain"
+
+// Registry는 노드의 모든 지표를 담는 레지스트리입니다.
+var Registry = prometheus.NewRegistry()
+
+var (
+	ChainHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "chain", Name: "height",
+		Help: "Height of the current chain tip.",
+	})
+	Difficulty = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "chain", Name: "difficulty",
+		Help: "Difficulty of the current chain tip.",
+	})
+	BlocksAccepted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "chain", Name: "blocks_accepted_total",
+		Help: "Blocks written to the chain.",
+	})
+	BlocksRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "chain", Name: "blocks_rejected_total",
+		Help: "Blocks rejected by reason.",
+	}, []string{"reason"})
+
+	HashRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "miner", Name: "hashrate",
+		Help: "Hashes per second measured over the last proof-of-work run.",
+	})
+	Hashes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "miner", Name: "hashes_total",
+		Help: "Proof-of-work hashes computed by this node.",
+	})
+
+	Peers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "p2p", Name: "peers",
+		Help: "Known peers excluding this node.",
+	})
+	BytesIn = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "p2p", Name: "bytes_in_total",
+		Help: "Bytes received per message command.",
+	}, []string{"command"})
+	BytesOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "p2p", Name: "bytes_out_total",
+		Help: "Bytes sent per message command.",
+	}, []string{"command"})
+
+	Syncing = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "sync", Name: "active",
+		Help: "1 while the node is syncing blocks from a peer.",
+	})
+	SyncTargetHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "sync", Name: "target_height",
+		Help: "Best height announced by the peer being synced from.",
+	})
+	OrphanBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "sync", Name: "orphan_blocks",
+		Help: "Blocks waiting in the orphan pool for their parent.",
+	})
+)
+
+// 마지막 블록 타임스탬프(유닉스 초)
+var headTimestamp atomic.Int64
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ChainHeight, Difficulty, BlocksAccepted, BlocksRejected,
+		HashRate, Hashes,
+		Peers, BytesIn, BytesOut,
+		Syncing, SyncTargetHeight, OrphanBlocks,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "chain", Name: "head_age_seconds",
+			Help: "Seconds since the timestamp of the current chain tip.",
+		}, headAge),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "sync", Name: "progress_ratio",
+			Help: "Local height divided by the sync target height.",
+		}, syncProgress),
+	)
+}
+
+// SetHead는 새 체인 tip의 높이, 타임스탬프, 난이도를 기록합니다.
+func SetHead(height, timestamp int64, difficulty float64) {
+	ChainHeight.Set(float64(height))
+	Difficulty.Set(difficulty)
+	headTimestamp.Store(timestamp)
+	syncState.height.Store(height)
+}
+
+func headAge() float64 {
+	ts := headTimestamp.Load()
+	if ts == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(ts, 0)).Seconds()
+}
+
+var syncState struct {
+	height atomic.Int64
+	target atomic.Int64
+}
+
+// SetSyncTarget은 동기화 대상 높이를 기록합니다.
+func SetSyncTarget(target int64) {
+	syncState.target.Store(target)
+	SyncTargetHeight.Set(float64(target))
+}
+
+func syncProgress() float64 {
+	target := syncState.target.Load()
+	if target <= 0 {
+		return 1
+	}
+	return math.Min(1, float64(syncState.height.Load())/float64(target))
+}
+
+// 마지막으로 측정한 초당 해시 수(float64 비트)
+var localHashRate atomic.Uint64
+
+// ObserveHashes는 작업증명 한 번에 계산한 해시 수와 걸린 시간을 기록합니다.
+func ObserveHashes(count uint64, elapsed time.Duration) {
+	Hashes.Add(float64(count))
+	if elapsed > 0 {
+		rate := float64(count) / elapsed.Seconds()
+		HashRate.Set(rate)
+		localHashRate.Store(math.Float64bits(rate))
+	}
+}
+
+// LocalHashRate는 마지막으로 측정한 이 노드의 초당 해시 수를 반환합니다.
+func LocalHashRate() float64 {
+	return math.Float64frombits(localHashRate.Load())
+}
+
+// Handler는 /metrics 엔드포인트 핸들러를 반환합니다.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape는 /metrics 응답 본문을 반환합니다.
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("status %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestHandler(t *testing.T) {
+	t.Cleanup(func() { SetSyncTarget(0) })
+	SetHead(40, time.Now().Unix(), 1000)
+	SetSyncTarget(80)
+	ObserveHashes(500, time.Second)
+
+	body := scrape(t)
+	for _, want := range []string{
+		"miningchain_chain_height 40",
+		"miningchain_chain_difficulty 1000",
+		"miningchain_sync_target_height 80",
+		"miningchain_sync_progress_ratio 0.5",
+		"miningchain_miner_hashrate 500",
+		"miningchain_chain_head_age_seconds",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if rate := LocalHashRate(); rate != 500 {
+		t.Fatalf("local hash rate %v", rate)
+	}
+}
+
+// 동기화 대상이 없거나 이미 넘었으면 진행률은 1
+func TestSyncProgress(t *testing.T) {
+	t.Cleanup(func() { SetSyncTarget(0) })
+	SetHead(40, 0, 1)
+	for _, target := range []int64{0, 20} {
+		SetSyncTarget(target)
+		if progress := syncProgress(); progress != 1 {
+			t.Errorf("target %d: progress %v", target, progress)
+		}
+	}
+	if age := headAge(); age != 0 {
+		t.Fatalf("head age %v without a head timestamp", age)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"time"
 
 	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/logger"
 )
 
+var log = logger.New(logger.Mining)
+
 type Mining struct {
 	chainId string
 }
@@ -20,38 +23,49 @@ func Run(ctx context.Context,
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Println("Mining stopped.")
+			log.Info("Mining stopped.")
 			return
 		default:
 			lastBlock := chain.GetLastBlock()
 
 			chain.Mu.Lock()
+			difficulty, err := chain.Difficulty(lastBlock.Height + 1)
+			chain.Mu.Unlock()
+			if err != nil {
+				log.Error("could not compute difficulty", "height", lastBlock.Height+1, "err", err)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+
 			block := &blockchain.Block{
 				Timestamp:  time.Now().Unix(),
 				PrevHash:   lastBlock.Hash,
 				Height:     lastBlock.Height + 1,
-				Difficulty: chain.Difficulty(lastBlock.Height + 1),
+				Difficulty: difficulty,
 				Miner:      blockchain.HexBytes(validator),
 				Validator:  blockchain.HexBytes(validator),
 			}
-			chain.Mu.Unlock()
 
 			// 경합으로 인한 분기 최소화
 			time.Sleep(1 * time.Second)
 
 			// Mining work
 			pow := blockchain.NewProof(block)
-			nonceByte := pow.Run()
+			nonceByte, err := pow.Run()
+			if err != nil {
+				log.Error("mining failed", "height", block.Height, "err", err)
+				continue
+			}
 
 			select {
 			case <-ctx.Done():
 				// 채굴 도중 중단 요청 확인
-				fmt.Println("Mining interrupted before block completion.")
+				log.Debug("Mining interrupted before block completion.")
 				return
 			default:
 				block.Nonce = nonceByte
 				block.Hash = pow.GetHash(block)
-				fmt.Println("miningBlock: ", block)
+				log.Info("block mined", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash), "difficulty", block.Difficulty)
 
 				miningBlockChan <- block
 			}
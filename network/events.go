@@ -0,0 +1,30 @@
+package network
+
+import "github.com/Kim-DaeHan/mining-chain/event"
+
+// SyncEvent는 동기화 상태가 바뀔 때 발생합니다.
+type SyncEvent struct {
+	Syncing       bool
+	CurrentHeight int64
+	TargetHeight  int64
+}
+
+// 피어 이벤트 종류
+const (
+	PeerAdded   = "added"
+	PeerDropped = "dropped"
+)
+
+// PeerEvent는 알려진 노드 목록이 바뀔 때 발생합니다.
+type PeerEvent struct {
+	Type string
+	Peer string
+}
+
+var (
+	SyncFeed event.Feed[SyncEvent]
+	PeerFeed event.Feed[PeerEvent]
+
+	// 마지막으로 알린 동기화 대상 높이
+	syncTarget int64
+)
@@ -0,0 +1,203 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+const (
+	// 새 블록을 알릴 최대 peer 수. 나머지 peer에게는 알림을 받은 peer가 다시 알림
+	gossipFanout = 8
+	// peer마다 기억할 최대 인벤토리 수
+	maxKnownInventory = 1024
+	// getdata로 요청한 블록이 오지 않으면 다른 peer에게 다시 요청하기까지의 시간
+	inventoryRequestTimeout = 10 * time.Second
+)
+
+// 인벤토리 종류
+const invBlock = "block"
+
+// knownSet은 가장 오래된 항목부터 지우는 크기 제한 집합입니다.
+type knownSet struct {
+	items map[string]struct{}
+	order []string
+}
+
+func (s *knownSet) has(key string) bool {
+	_, ok := s.items[key]
+	return ok
+}
+
+func (s *knownSet) add(key string) {
+	if s.has(key) {
+		return
+	}
+	s.items[key] = struct{}{}
+	s.order = append(s.order, key)
+	if len(s.order) > maxKnownInventory {
+		delete(s.items, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+var (
+	inventoryMu        sync.Mutex
+	knownInventory     = map[string]*knownSet{} // peer 주소 → 그 peer가 가진 것으로 아는 블록 해시
+	requestedInventory = map[string]time.Time{} // getdata로 요청한 블록 해시와 요청 시각
+	relayInventory     = map[string]struct{}{}  // 체인에 추가되면 다른 peer에게 알릴 블록 해시
+)
+
+// markKnownLocked는 peer가 hash를 가진 것으로 기록합니다. inventoryMu를 잡고 호출해야 합니다.
+func markKnownLocked(peer string, hash []byte) {
+	set, ok := knownInventory[peer]
+	if !ok {
+		set = &knownSet{items: map[string]struct{}{}}
+		knownInventory[peer] = set
+	}
+	set.add(string(hash))
+}
+
+func markKnown(peer string, hash []byte) {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	markKnownLocked(peer, hash)
+}
+
+// forgetPeer는 목록에서 빠진 peer의 인벤토리를 지웁니다.
+func forgetPeer(peer string) {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	delete(knownInventory, peer)
+}
+
+// requestInventory는 hash를 아직 요청하지 않았거나 요청이 만료되었으면 요청한 것으로 기록하고 true를 반환합니다.
+func requestInventory(hash []byte) bool {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+
+	if at, ok := requestedInventory[string(hash)]; ok && time.Since(at) < inventoryRequestTimeout {
+		return false
+	}
+	if len(requestedInventory) >= maxKnownInventory {
+		for key, at := range requestedInventory {
+			if time.Since(at) >= inventoryRequestTimeout {
+				delete(requestedInventory, key)
+			}
+		}
+	}
+	requestedInventory[string(hash)] = time.Now()
+	return true
+}
+
+// takeRelay는 hash가 다른 peer에게 알릴 블록이었는지 확인하고 기록을 지웁니다.
+func takeRelay(hash []byte) bool {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	_, ok := relayInventory[string(hash)]
+	delete(relayInventory, string(hash))
+	return ok
+}
+
+// forgetRelay는 체인에 추가하지 않고 버린 고아 블록을 알릴 블록 기록에서 지웁니다.
+func forgetRelay(dropped []*orphanBlock) {
+	if len(dropped) == 0 {
+		return
+	}
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	for _, o := range dropped {
+		delete(relayInventory, string(o.block.Hash))
+	}
+}
+
+// announceBlock은 블록을 가진 것으로 알려지지 않은 peer 중 최대 gossipFanout개를 무작위로 골라 inv로 알립니다.
+// light 노드는 블록을 내줄 수 없으므로 알리지 않습니다.
+func announceBlock(chain *blockchain.BlockChain, block *blockchain.Block) {
+	if chain.IsLight() {
+		return
+	}
+
+	inventoryMu.Lock()
+	var targets []string
+	for _, node := range KnownNodes {
+		if node == nodeAddress {
+			continue
+		}
+		if set, ok := knownInventory[node]; ok && set.has(string(block.Hash)) {
+			continue
+		}
+		targets = append(targets, node)
+	}
+	rand.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	targets = targets[:min(len(targets), gossipFanout)]
+	for _, node := range targets {
+		markKnownLocked(node, block.Hash)
+	}
+	inventoryMu.Unlock()
+
+	for _, node := range targets {
+		log.Debug("Announcing block", "peer", node, "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash))
+		SendInv(node, invBlock, [][]byte{block.Hash})
+	}
+}
+
+// 인벤토리 알림을 처리하는 함수. 없는 블록만 알린 peer에게 getdata로 요청
+func HandleInv(request []byte, chain *blockchain.BlockChain) {
+	var buff bytes.Buffer
+	var payload Inv
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode inv", "err", err)
+		return
+	}
+	if payload.Type != invBlock {
+		log.Warn("unknown inventory type", "type", payload.Type, "peer", payload.AddrFrom)
+		return
+	}
+
+	for _, hash := range payload.Items {
+		markKnown(payload.AddrFrom, hash)
+		if _, err := chain.GetHeader(hash); err == nil {
+			continue
+		}
+		// 다른 peer에게 이미 요청한 블록은 기다림
+		if !requestInventory(hash) {
+			continue
+		}
+		SendGetData(payload.AddrFrom, invBlock, hash)
+	}
+
+	SyncKnownNodes(payload.AddrFrom)
+}
+
+// getdata 요청을 처리하는 함수. 요청한 블록을 block 메시지로 보냄
+func HandleGetData(request []byte, chain *blockchain.BlockChain) {
+	var buff bytes.Buffer
+	var payload GetData
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode getdata", "err", err)
+		return
+	}
+	if payload.Type != invBlock || chain.IsLight() {
+		return
+	}
+
+	block, err := chain.GetBlock(payload.ID)
+	if err != nil {
+		log.Debug("requested block not available", "hash", fmt.Sprintf("%x", payload.ID), "peer", payload.AddrFrom, "err", err)
+		return
+	}
+	markKnown(payload.AddrFrom, block.Hash)
+	SendBlock(payload.AddrFrom, &block)
+}
@@ -0,0 +1,131 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// resetInventory는 패키지의 인벤토리 상태를 비웁니다.
+func resetInventory(t *testing.T) {
+	reset := func() {
+		inventoryMu.Lock()
+		defer inventoryMu.Unlock()
+		knownInventory = map[string]*knownSet{}
+		requestedInventory = map[string]time.Time{}
+		relayInventory = map[string]struct{}{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func isKnown(peer string, hash []byte) bool {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	set, ok := knownInventory[peer]
+	return ok && set.has(string(hash))
+}
+
+func TestKnownSetEviction(t *testing.T) {
+	s := &knownSet{items: map[string]struct{}{}}
+	for i := 0; i <= maxKnownInventory; i++ {
+		s.add(fmt.Sprint(i))
+	}
+	s.add("1")
+	if s.has("0") {
+		t.Fatal("oldest item not evicted")
+	}
+	if !s.has("1") || !s.has(fmt.Sprint(maxKnownInventory)) {
+		t.Fatal("recent items evicted")
+	}
+	if len(s.items) != maxKnownInventory || len(s.order) != maxKnownInventory {
+		t.Fatalf("size %d/%d, want %d", len(s.items), len(s.order), maxKnownInventory)
+	}
+}
+
+// inv로 알린 블록 중 없는 블록만 요청하고, 이미 요청한 블록은 다른 peer에게 다시 요청하지 않음
+func TestInvRequestsUnknownBlocksOnce(t *testing.T) {
+	resetInventory(t)
+	chain := newTestChain(t, 3)
+	peer, other := newTestPeer(t), newTestPeer(t)
+	useKnownNodes(t, peer.addr, other.addr)
+
+	known := chain.GetLastBlock()
+	unknown := newTestBlock(t, known, "")
+	inv := Inv{AddrFrom: peer.addr, Type: invBlock, Items: [][]byte{known.Hash, unknown.Hash}}
+	HandleInv(message("inv", inv), chain)
+
+	var req GetData
+	peer.expect(t, "getdata", &req)
+	if req.Type != invBlock || !bytes.Equal(req.ID, unknown.Hash) {
+		t.Fatalf("requested %s %x, want block %x", req.Type, req.ID, []byte(unknown.Hash))
+	}
+	peer.expectNothing(t)
+	if !isKnown(peer.addr, known.Hash) || !isKnown(peer.addr, unknown.Hash) {
+		t.Fatal("announced blocks not marked known for the peer")
+	}
+
+	inv.AddrFrom = other.addr
+	HandleInv(message("inv", inv), chain)
+	other.expectNothing(t)
+	if !isKnown(other.addr, unknown.Hash) {
+		t.Fatal("announced block not marked known for the second peer")
+	}
+
+	// 알 수 없는 종류는 무시
+	HandleInv(message("inv", Inv{AddrFrom: peer.addr, Type: "tx", Items: [][]byte{[]byte("x")}}), chain)
+	peer.expectNothing(t)
+}
+
+// 블록을 가진 것으로 아는 peer에게는 알리지 않음
+func TestAnnounceSkipsKnownPeers(t *testing.T) {
+	resetInventory(t)
+	chain := newTestChain(t, 1)
+	peer, other := newTestPeer(t), newTestPeer(t)
+	useKnownNodes(t, nodeAddress, peer.addr, other.addr)
+
+	block := chain.GetLastBlock()
+	markKnown(peer.addr, block.Hash)
+	announceBlock(chain, block)
+
+	var inv Inv
+	other.expect(t, "inv", &inv)
+	if len(inv.Items) != 1 || !bytes.Equal(inv.Items[0], block.Hash) {
+		t.Fatalf("announced %x, want %x", inv.Items, []byte(block.Hash))
+	}
+	peer.expectNothing(t)
+
+	// 알린 peer도 블록을 가진 것으로 기록
+	announceBlock(chain, block)
+	other.expectNothing(t)
+}
+
+func TestGetDataServesBlock(t *testing.T) {
+	resetInventory(t)
+	chain := newTestChain(t, 2)
+	peer := newTestPeer(t)
+	useKnownNodes(t, peer.addr)
+
+	want := chain.GetLastBlock()
+	HandleGetData(message("getdata", GetData{AddrFrom: peer.addr, Type: invBlock, ID: want.Hash}), chain)
+
+	var payload Block
+	peer.expect(t, "block", &payload)
+	got, err := blockchain.Deserialize(payload.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Hash, want.Hash) {
+		t.Fatalf("served %x, want %x", []byte(got.Hash), []byte(want.Hash))
+	}
+	if !isKnown(peer.addr, want.Hash) {
+		t.Fatal("served block not marked known for the peer")
+	}
+
+	// 없는 블록은 보내지 않음
+	HandleGetData(message("getdata", GetData{AddrFrom: peer.addr, Type: invBlock, ID: []byte("missing")}), chain)
+	peer.expectNothing(t)
+}
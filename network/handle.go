@@ -5,11 +5,11 @@ import (
 	"encoding/gob"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"time"
 
 	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
 )
 
 func HandleBlockList(request []byte, chain *blockchain.BlockChain) {
@@ -20,18 +20,23 @@ func HandleBlockList(request []byte, chain *blockchain.BlockChain) {
 	dec := gob.NewDecoder(&buff)
 	err := dec.Decode(&payload)
 	if err != nil {
-		log.Panic(err)
+		log.Warn("could not decode blocklist", "err", err)
+		return
 	}
 
-	fmt.Printf("Received blocklist with %d blocks\n", len(payload.Blocks))
+	log.Debug("Received blocklist", "blocks", len(payload.Blocks), "from", payload.AddrFrom)
 
 	isAppendBlockList = true
-	isSync = true
+	setSync(true)
 	syncChan <- true
 
 	// 낮은 높이부터 순서대로 blocksInTransit에 블록을 추가
 	for _, blockData := range payload.Blocks {
-		block := blockchain.Deserialize(blockData)
+		block, err := blockchain.Deserialize(blockData)
+		if err != nil {
+			log.Warn("invalid block in blocklist", "from", payload.AddrFrom, "err", err)
+			continue
+		}
 
 		// block.Hash가 blocksInTransit에 이미 존재하는지 확인
 		isDuplicate := false
@@ -44,16 +49,17 @@ func HandleBlockList(request []byte, chain *blockchain.BlockChain) {
 
 		// 중복되지 않으면 blocksInTransit에 추가
 		if !isDuplicate {
+			setBlockSource(block.Hash, payload.AddrFrom)
 			blocksInTransit = append(blocksInTransit, block)
 			tempBlockList = append(tempBlockList, block)
-			fmt.Printf("Added block %x at height %d to blocksInTransit\n", block.Hash, block.Height)
+			log.Debug("Added block to blocksInTransit", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash))
 		} else {
-			fmt.Printf("Block %x at height %d is a duplicate and was not added\n", block.Hash, block.Height)
+			log.Debug("Duplicate block was not added", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash))
 		}
 	}
 
 	// 모든 블록이 체인에 추가되었다는 메시지 출력
-	fmt.Println("blocksInTransit 에 리스트가 추가됨 길이는:: ", len(blocksInTransit))
+	log.Debug("blocksInTransit 에 리스트가 추가됨", "length", len(blocksInTransit))
 
 	if len(tempBlockList) == payload.Length {
 		tempBlockList = nil
@@ -77,16 +83,14 @@ func HandleKnownNodes(request []byte) {
 	// Addr 구조체로 디코딩
 	err := dec.Decode(&payload)
 	if err != nil {
-		// 에러 발생 시 패닉
-		log.Panic(err)
-
+		log.Warn("could not decode known nodes", "err", err)
+		return
 	}
 
 	// KnownNodes에 새로운 노드 주소 추가
-	KnownNodes = append(KnownNodes, payload.AddrList...)
-	KnownNodes = RemoveDuplicatesNodes(KnownNodes)
+	addKnownNodes(payload.AddrList...)
 	// 알려진 노드 개수 출력
-	fmt.Printf("there are %d known nodes\n", len(KnownNodes))
+	log.Debug("known nodes updated", "count", len(KnownNodes))
 
 }
 
@@ -94,35 +98,43 @@ func HandleKnownNodes(request []byte) {
 func HandleBlock(request []byte, chain *blockchain.BlockChain) {
 	var buff bytes.Buffer
 	var payload Block
-	var blockHeight int64
 
 	buff.Write(request[commandLength:])
 	dec := gob.NewDecoder(&buff)
 	err := dec.Decode(&payload)
 	if err != nil {
-		log.Panic(err)
+		log.Warn("could not decode block message", "err", err)
+		return
 	}
 
-	blockData := payload.Block
-	block := blockchain.Deserialize(blockData)
-
-	if chain.CurrentBlock != nil {
-		blockHeight = chain.CurrentBlock.Height + 1
-	} else {
-		blockHeight = 0
+	block, err := blockchain.Deserialize(payload.Block)
+	if err != nil {
+		log.Warn("invalid block", "from", payload.AddrFrom, "err", err)
+		return
+	}
+	markKnown(payload.AddrFrom, block.Hash)
+
+	// 부모를 모르는 블록은 고아 블록 풀에 넣고 빠진 조상을 요청
+	var parentKnown bool
+	if block.Height == 0 {
+		parentKnown = true
+	} else if _, err := chain.GetHeader(block.PrevHash); err == nil {
+		parentKnown = true
 	}
 
-	if !isSync && block.Height > blockHeight {
-		otherHeight := block.Height
-		fmt.Println("blocksInTransit 에 추가안됨 초기화")
-		SyncWithLongestChain(chain, otherHeight, payload.AddrFrom)
+	if !parentKnown {
+		handleOrphan(chain, block, payload.AddrFrom)
 	} else {
+		inventoryMu.Lock()
+		relayInventory[string(block.Hash)] = struct{}{}
+		inventoryMu.Unlock()
+		setBlockSource(block.Hash, payload.AddrFrom)
 		blocksInTransit = append(blocksInTransit, block)
 
-		fmt.Println("blocksInTransit 에 추가됨 길이는:: ", len(blocksInTransit))
+		log.Debug("blocksInTransit 에 추가됨", "length", len(blocksInTransit))
 
 		if !isSync {
-			isSync = true
+			setSync(true)
 			syncChan <- true
 		}
 
@@ -143,25 +155,44 @@ func HandleLatestBlockHeight(request []byte, chain *blockchain.BlockChain) {
 	dec := gob.NewDecoder(&buff)
 	err := dec.Decode(&payload)
 	if err != nil {
-		log.Panic(err)
+		log.Warn("could not decode block range request", "err", err)
+		return
 	}
 
 	rangeStr := string(payload.ID)
 	var startHeight, endHeight int64
 	_, err = fmt.Sscanf(rangeStr, "%d-%d", &startHeight, &endHeight)
 	if err != nil {
-		fmt.Printf("Invalid block range requested: %s\n", payload.ID)
+		log.Warn("Invalid block range requested", "range", string(payload.ID), "from", payload.AddrFrom)
+		return
+	}
+
+	if chain.IsLight() {
+		log.Debug("light node cannot serve blocks", "from", startHeight, "to", endHeight, "peer", payload.AddrFrom)
 		return
 	}
 
-	blocks := chain.GetBlocksInRange(startHeight, endHeight)
+	// 본문을 지웠거나 아직 받지 못한 블록은 보낼 수 없으므로 본문이 있는 블록부터 보냄
+	if lowest := chain.LowestBodyHeight(); startHeight < lowest {
+		log.Info("Requested blocks below the lowest stored body, sending from there", "from", startHeight, "lowest", lowest, "peer", payload.AddrFrom)
+		startHeight = lowest
+	}
+	if startHeight > endHeight {
+		return
+	}
+
+	blocks, err := chain.GetBlocksInRange(startHeight, endHeight)
+	if err != nil {
+		log.Error("could not read requested blocks", "from", startHeight, "to", endHeight, "err", err)
+		return
+	}
 
 	// 오래된 블록부터 처리하기 위해 슬라이스를 뒤집음
 	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
 		blocks[i], blocks[j] = blocks[j], blocks[i]
 	}
 
-	fmt.Printf("Sending blocks from height %d to %d to %s\n", startHeight, endHeight, payload.AddrFrom)
+	log.Info("Sending blocks", "from", startHeight, "to", endHeight, "peer", payload.AddrFrom)
 
 	// 100개씩 나누어 SendBlockList 호출
 	batchSize := 100
@@ -173,7 +204,7 @@ func HandleLatestBlockHeight(request []byte, chain *blockchain.BlockChain) {
 
 		// 슬라이스의 부분 집합을 전달
 		batch := blocks[i:end]
-		fmt.Printf("Sending batch of blocks from index %d to %d to %s\n", i, end-1, payload.AddrFrom)
+		log.Debug("Sending batch of blocks", "start", i, "end", end-1, "peer", payload.AddrFrom)
 		SendBlockList(payload.AddrFrom, batch, len(blocks))
 
 		time.Sleep(1 * time.Second)
@@ -190,27 +221,32 @@ func HandleVersion(request []byte, chain *blockchain.BlockChain) {
 	dec := gob.NewDecoder(&buff)
 	err := dec.Decode(&payload)
 	if err != nil {
-		log.Panic(err)
+		log.Warn("could not decode version", "err", err)
+		return
 	}
 
 	bestHeight := chain.GetBestHeight()
 	otherHeight := payload.BestHeight
 
-	fmt.Printf("Received version from %s with bestHeight: %d. Local bestHeight: %d\n", payload.AddrFrom, otherHeight, bestHeight)
+	log.Debug("Received version", "from", payload.AddrFrom, "bestHeight", otherHeight, "localHeight", bestHeight)
 
 	if bestHeight < otherHeight && payload.AddrFrom != "" {
-		fmt.Printf("Node height is lower. Starting sync from height 1 with %s\n", payload.AddrFrom)
+		log.Info("Node height is lower. Starting sync", "peer", payload.AddrFrom, "localHeight", bestHeight, "peerHeight", otherHeight)
+		setSyncTarget(otherHeight)
 
-		if len(chain.LastHash) > 0 {
+		switch {
+		case requestSnapshot(chain, payload.AddrFrom, otherHeight):
+			// 스냅샷을 받은 뒤 나머지 블록을 요청
+		case len(chain.LastHash) > 0:
 			SendLatestBlockHeight(payload.AddrFrom, bestHeight+1, otherHeight)
-		} else {
+		default:
 			SendLatestBlockHeight(payload.AddrFrom, 0, otherHeight)
 		}
 
-		isSync = true
+		setSync(true)
 		syncChan <- true
 	} else if bestHeight > otherHeight && payload.AddrFrom != "" {
-		fmt.Printf("Node height is higher. Sending version to %s\n", payload.AddrFrom)
+		log.Debug("Node height is higher. Sending version", "peer", payload.AddrFrom)
 		SendVersion(payload.AddrFrom, chain)
 	}
 
@@ -222,11 +258,17 @@ func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
 	defer conn.Close()
 
 	if err != nil {
-		log.Panic(err)
+		log.Warn("could not read request", "remote", conn.RemoteAddr(), "err", err)
+		return
+	}
+	if len(req) < commandLength {
+		log.Warn("request too short", "remote", conn.RemoteAddr(), "bytes", len(req))
+		return
 	}
 
 	command := BytesToCmd(req[:commandLength])
-	fmt.Printf("Received command: %s\n", command)
+	log.Debug("Received command", "command", command, "bytes", len(req))
+	metrics.BytesIn.WithLabelValues(commandLabel(req)).Add(float64(len(req)))
 
 	switch command {
 	case "knownNodes":
@@ -239,7 +281,19 @@ func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
 		HandleVersion(req, chain)
 	case "blocklist":
 		HandleBlockList(req, chain)
+	case "getsnapshot":
+		HandleGetSnapshot(req, chain)
+	case "snapshot":
+		HandleSnapshot(req, chain)
+	case "inv":
+		HandleInv(req, chain)
+	case "getdata":
+		HandleGetData(req, chain)
+	case "getbody":
+		HandleGetBody(req, chain)
+	case "body":
+		HandleBody(req)
 	default:
-		fmt.Println("Unknown command")
+		log.Warn("Unknown command", "command", command)
 	}
 }
@@ -0,0 +1,34 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// 본문을 지운 높이부터 요청하면 본문이 남은 블록부터 보냄
+func TestLatestBlockHeightAbovePruned(t *testing.T) {
+	chain := newTestChain(t, 20)
+	if _, err := chain.Prune(10); err != nil {
+		t.Fatal(err)
+	}
+	peer := newTestPeer(t)
+
+	HandleLatestBlockHeight(message("latestBlockHeight", LatestBlockHeight{AddrFrom: peer.addr, Type: "block", ID: []byte("1-20")}), chain)
+	var list BlockList
+	peer.expect(t, "blocklist", &list)
+	if list.Length != 10 || len(list.Blocks) != 10 {
+		t.Fatalf("sent %d of %d blocks, want 10", len(list.Blocks), list.Length)
+	}
+	first, err := blockchain.Deserialize(list.Blocks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Height != chain.PrunedHeight() {
+		t.Fatalf("first block at height %d, want %d", first.Height, chain.PrunedHeight())
+	}
+
+	// 모두 지운 범위는 보내지 않음
+	HandleLatestBlockHeight(message("latestBlockHeight", LatestBlockHeight{AddrFrom: peer.addr, Type: "block", ID: []byte("1-5")}), chain)
+	peer.expectNothing(t)
+}
@@ -0,0 +1,497 @@
+package network
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// JSON-RPC 2.0 표준 오류 코드
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeInternal       = -32603
+	errCodeServer         = -32000
+	errCodeNotFound       = -32001
+	errCodeUnauthorized   = -32002
+	errCodePruned         = -32003
+	errCodeNotSynced      = -32004
+)
+
+// 요청 본문 최대 크기
+const maxRequestSize = 5 * 1024 * 1024
+
+type jsonrpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+type jsonrpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *jsonrpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, args ...interface{}) error {
+	return &jsonrpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+type jsonrpcMethod func(params []json.RawMessage) (interface{}, error)
+
+// jsonrpcServer는 RPCServer의 메서드를 JSON-RPC 2.0 이름(chain_*, miner_*, admin_*)으로 노출합니다.
+type jsonrpcServer struct {
+	methods map[string]jsonrpcMethod
+}
+
+func newJSONRPCServer(r *RPCServer) *jsonrpcServer {
+	s := &jsonrpcServer{methods: map[string]jsonrpcMethod{}}
+
+	s.methods["chain_blockNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetBlockNumberRes
+		err := r.GetBlockNumber(&GetBlockNumberArgs{}, &res)
+		return encodeQuantity(res.Height), err
+	}
+	s.methods["chain_getLastBlockHash"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetLastBlockHashRes
+		err := r.GetLastBlockHash(&GetLastBlockHashArgs{}, &res)
+		return "0x" + res.Hash, err
+	}
+	s.methods["chain_getBlockByHash"] = func(params []json.RawMessage) (interface{}, error) {
+		var hash string
+		if err := parseParams(params, &hash); err != nil {
+			return nil, err
+		}
+		hashBytes, err := decodeHexBytes(hash)
+		if err != nil || len(hashBytes) == 0 {
+			return nil, invalidParams("invalid block hash %q", hash)
+		}
+		block, err := r.chain.GetBlock(hashBytes)
+		if errors.Is(err, blockchain.ErrBlockPruned) || errors.Is(err, blockchain.ErrBodyNotSynced) {
+			return nil, err
+		} else if err != nil {
+			return nil, &jsonrpcError{Code: errCodeNotFound, Message: fmt.Sprintf("block %s not found", hash)}
+		}
+		return newRPCBlock(&block), nil
+	}
+	s.methods["chain_getBlockByNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		var number string
+		if err := parseParams(params, &number); err != nil {
+			return nil, err
+		}
+		height, err := r.resolveBlockNumber(number)
+		if err != nil {
+			return nil, err
+		}
+		block, err := r.chain.GetBlockByHeight(height)
+		if errors.Is(err, blockchain.ErrBlockPruned) || errors.Is(err, blockchain.ErrBodyNotSynced) {
+			return nil, err
+		} else if err != nil {
+			return nil, &jsonrpcError{Code: errCodeNotFound, Message: fmt.Sprintf("block %d not found", height)}
+		}
+		return newRPCBlock(block), nil
+	}
+	s.methods["chain_getHeadersRange"] = func(params []json.RawMessage) (interface{}, error) {
+		var from string
+		var count int
+		if err := parseParams(params, &from, &count); err != nil {
+			return nil, err
+		}
+		if count <= 0 {
+			return nil, invalidParams("invalid header count %d", count)
+		}
+		height, err := r.resolveBlockNumber(from)
+		if err != nil {
+			return nil, err
+		}
+		var res GetHeadersRangeRes
+		if err := r.GetHeadersRange(&GetHeadersRangeArgs{From: height, Count: count}, &res); err != nil {
+			return nil, err
+		}
+		headers := make([]*rpcHeader, len(res.Headers))
+		for i := range res.Headers {
+			headers[i] = newRPCHeader(&res.Headers[i])
+		}
+		return headers, nil
+	}
+	s.methods["chain_getBlockHashes"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetBlockHashesRes
+		err := r.GetBlockHashes(&GetBlockHashesArgs{}, &res)
+		hashes := make([]string, len(res.Hash))
+		for i, h := range res.Hash {
+			hashes[i] = "0x" + h
+		}
+		return hashes, err
+	}
+	s.methods["chain_getDifficulty"] = func(params []json.RawMessage) (interface{}, error) {
+		var number string
+		if err := parseParams(params, &number); err != nil {
+			return nil, err
+		}
+		height, err := r.resolveBlockNumber(number)
+		if err != nil {
+			return nil, err
+		}
+		var res GetDifficultyRes
+		err = r.GetDifficulty(&GetDifficultyArgs{Height: height}, &res)
+		return encodeBig(res.Difficulty), err
+	}
+
+	s.methods["miner_getWork"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetWorkRes
+		err := r.GetWork(&GetWorkArgs{}, &res)
+		return []string{res.CurrentPowHash, res.SeedHash, res.TargetThreshold}, err
+	}
+	s.methods["miner_submitWork"] = func(params []json.RawMessage) (interface{}, error) {
+		var powHash, nonce string
+		if err := parseParams(params, &powHash, &nonce); err != nil {
+			return nil, err
+		}
+		var res SubmitWorkRes
+		err := r.SubmitWork(&SubmitWorkArgs{PowHash: powHash, Nonce: nonce}, &res)
+		return res.Accepted, err
+	}
+	s.methods["miner_hashrate"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetHashRateRes
+		err := r.GetHashRate(&GetHashRateArgs{}, &res)
+		return encodeQuantity(int64(res.Hashrate)), err
+	}
+	s.methods["miner_nodeHashrate"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetNodeHashRateRes
+		err := r.GetNodeHashRate(&GetNodeHashRateArgs{}, &res)
+		return encodeQuantity(int64(res.Hashrate)), err
+	}
+	s.methods["miner_coinbase"] = func(params []json.RawMessage) (interface{}, error) {
+		var res CoinbaseRes
+		err := r.Coinbase(&CoinbaseArgs{}, &res)
+		return res.CoinbaseAddress, err
+	}
+	s.methods["miner_mining"] = func(params []json.RawMessage) (interface{}, error) {
+		var res MiningRes
+		err := r.Mining(&MiningArgs{}, &res)
+		return res.IsMining, err
+	}
+	s.methods["miner_setXpbase"] = func(params []json.RawMessage) (interface{}, error) {
+		var address string
+		if err := parseParams(params, &address); err != nil {
+			return nil, err
+		}
+		var res SetXpbaseRes
+		err := r.SetXpbase(&SetXpbaseArgs{Address: address}, &res)
+		return res.Success, err
+	}
+
+	s.methods["admin_peers"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetPeerRes
+		err := r.GetPeer(&GetPeerArgs{}, &res)
+		return res.Peers, err
+	}
+	s.methods["admin_addPeer"] = func(params []json.RawMessage) (interface{}, error) {
+		var address string
+		if err := parseParams(params, &address); err != nil {
+			return nil, err
+		}
+		var res AddPeerRes
+		err := r.AddPeer(&AddPeerArgs{PeerAddress: address}, &res)
+		return res.Success, err
+	}
+	s.methods["admin_removePeer"] = func(params []json.RawMessage) (interface{}, error) {
+		var address string
+		if err := parseParams(params, &address); err != nil {
+			return nil, err
+		}
+		var res RemovePeerRes
+		err := r.RemovePeer(&RemovePeerArgs{PeerAddress: address}, &res)
+		return res.Success, err
+	}
+	s.methods["admin_nodeInfo"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetNodeInfoRes
+		err := r.GetNodeInfo(&GetNodeInfoArgs{}, &res)
+		return res, err
+	}
+	s.methods["admin_datadir"] = func(params []json.RawMessage) (interface{}, error) {
+		var res GetDataDirRes
+		err := r.GetDataDir(&GetDataDirArgs{}, &res)
+		return res.DataDirectory, err
+	}
+	s.methods["admin_rewind"] = func(params []json.RawMessage) (interface{}, error) {
+		var number string
+		if err := parseParams(params, &number); err != nil {
+			return nil, err
+		}
+		height, err := r.resolveBlockNumber(number)
+		if err != nil {
+			return nil, err
+		}
+		var res RewindRes
+		if err := r.Rewind(&RewindArgs{Height: height}, &res); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"removed": res.Removed, "number": encodeQuantity(height), "hash": "0x" + res.Hash}, nil
+	}
+	s.methods["admin_reapplyRewound"] = func(params []json.RawMessage) (interface{}, error) {
+		var res ReapplyRewoundRes
+		err := r.ReapplyRewound(&ReapplyRewoundArgs{}, &res)
+		return map[string]interface{}{"applied": res.Applied, "number": encodeQuantity(res.Height)}, err
+	}
+
+	return s
+}
+
+// resolveBlockNumber는 "latest", "earliest", 16진수("0x1a") 또는 10진수 높이를 해석합니다.
+func (r *RPCServer) resolveBlockNumber(number string) (int64, error) {
+	switch number {
+	case "", "latest":
+		return r.chain.GetBestHeight(), nil
+	case "earliest":
+		return 0, nil
+	}
+
+	height, err := decodeQuantity(number)
+	if err != nil || height < 0 {
+		return 0, invalidParams("invalid block number %q", number)
+	}
+	return height, nil
+}
+
+// ServeHTTP는 단일 요청과 배치 요청을 처리합니다.
+func (s *jsonrpcServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requests must use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.handleMessage(body, grantsFrom(req.Context()))
+	w.Header().Set("Content-Type", "application/json")
+	if result == nil {
+		// 알림(notification)만 있는 요청에는 응답하지 않음
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleMessage는 JSON 본문 하나를 처리해 응답(단일 또는 배열)을 반환합니다.
+// grants에 없는 그룹의 메서드는 권한 오류로 응답합니다.
+func (s *jsonrpcServer) handleMessage(body []byte, grants rpcGrants) interface{} {
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return errorResponse(nil, errCodeParse, "parse error")
+		}
+		if len(batch) == 0 {
+			return errorResponse(nil, errCodeInvalidRequest, "empty batch")
+		}
+
+		var responses []*jsonrpcResponse
+		for _, raw := range batch {
+			if res := s.handleRaw(raw, grants); res != nil {
+				responses = append(responses, res)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return responses
+	}
+
+	if res := s.handleRaw(body, grants); res != nil {
+		return res
+	}
+	return nil
+}
+
+func (s *jsonrpcServer) handleRaw(raw json.RawMessage, grants rpcGrants) *jsonrpcResponse {
+	var req jsonrpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return errorResponse(nil, errCodeParse, "parse error")
+		}
+		return errorResponse(nil, errCodeInvalidRequest, "invalid request")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, errCodeInvalidRequest, "invalid request")
+	}
+
+	res := s.call(&req, grants)
+	if req.ID == nil {
+		return nil
+	}
+	return res
+}
+
+// call은 메서드를 실행합니다. 메서드 내부의 panic은 내부 오류로 바꿉니다.
+func (s *jsonrpcServer) call(req *jsonrpcRequest, grants rpcGrants) (res *jsonrpcResponse) {
+	method, ok := s.methods[req.Method]
+	if !ok {
+		return errorResponse(req.ID, errCodeMethodNotFound, fmt.Sprintf("the method %s does not exist", req.Method))
+	}
+	if group := methodGroup(req.Method); !grants.allows(group) {
+		return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Error: unauthorized(req.Method, group).(*jsonrpcError)}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rpcLog.Error("JSON-RPC method panicked", "method", req.Method, "panic", p)
+			res = errorResponse(req.ID, errCodeInternal, "internal error")
+		}
+	}()
+
+	result, err := method(req.Params)
+	if err != nil {
+		var rpcErr *jsonrpcError
+		if errors.As(err, &rpcErr) {
+			return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+		}
+		if errors.Is(err, blockchain.ErrBlockNotFound) {
+			return errorResponse(req.ID, errCodeNotFound, err.Error())
+		}
+		if errors.Is(err, blockchain.ErrBlockPruned) {
+			return errorResponse(req.ID, errCodePruned, err.Error())
+		}
+		if errors.Is(err, blockchain.ErrBodyNotSynced) {
+			return errorResponse(req.ID, errCodeNotSynced, err.Error())
+		}
+		return errorResponse(req.ID, errCodeServer, err.Error())
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", ID: id, Error: &jsonrpcError{Code: code, Message: message}}
+}
+
+// parseParams는 위치 기반 파라미터를 순서대로 디코딩합니다. 뒤쪽 파라미터는 생략할 수 있습니다.
+func parseParams(params []json.RawMessage, args ...interface{}) error {
+	if len(params) > len(args) {
+		return invalidParams("too many arguments, want at most %d", len(args))
+	}
+	for i, p := range params {
+		if err := json.Unmarshal(p, args[i]); err != nil {
+			return invalidParams("invalid argument %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// rpcBlock은 JSON-RPC 응답용 블록 표현입니다. 수량은 16진수 문자열, 바이트는 0x 접두 16진수입니다.
+type rpcBlock struct {
+	Number          string `json:"number"`
+	Hash            string `json:"hash"`
+	ParentHash      string `json:"parentHash"`
+	Timestamp       string `json:"timestamp"`
+	Nonce           string `json:"nonce"`
+	Difficulty      string `json:"difficulty"`
+	Miner           string `json:"miner"`
+	Validator       string `json:"validator"`
+	MainBlockHeight string `json:"mainBlockHeight"`
+	MainBlockHash   string `json:"mainBlockHash"`
+	ExtraData       string `json:"extraData"`
+}
+
+func newRPCBlock(b *blockchain.Block) *rpcBlock {
+	return &rpcBlock{
+		Number:          encodeQuantity(b.Height),
+		Hash:            encodeBytes(b.Hash),
+		ParentHash:      encodeBytes(b.PrevHash),
+		Timestamp:       encodeQuantity(b.Timestamp),
+		Nonce:           encodeBytes(b.Nonce),
+		Difficulty:      encodeBig(b.Difficulty),
+		Miner:           encodeBytes(b.Miner),
+		Validator:       encodeBytes(b.Validator),
+		MainBlockHeight: encodeQuantity(int64(b.MainBlockHeight)),
+		MainBlockHash:   encodeBytes(b.MainBlockHash),
+		ExtraData:       encodeBytes(b.ExtraData),
+	}
+}
+
+type rpcHeader struct {
+	Number     string `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Timestamp  string `json:"timestamp"`
+	Nonce      string `json:"nonce"`
+	Difficulty string `json:"difficulty"`
+	Miner      string `json:"miner"`
+}
+
+func newRPCHeader(h *blockchain.Header) *rpcHeader {
+	return &rpcHeader{
+		Number:     encodeQuantity(h.Height),
+		Hash:       encodeBytes(h.Hash),
+		ParentHash: encodeBytes(h.PrevHash),
+		Timestamp:  encodeQuantity(h.Timestamp),
+		Nonce:      encodeBytes(h.Nonce),
+		Difficulty: encodeBig(h.Difficulty),
+		Miner:      encodeBytes(h.Miner),
+	}
+}
+
+func encodeQuantity(n int64) string {
+	if n < 0 {
+		return "-0x" + strconv.FormatInt(-n, 16)
+	}
+	return "0x" + strconv.FormatInt(n, 16)
+}
+
+func encodeBig(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	if n.Sign() < 0 {
+		return "-0x" + new(big.Int).Neg(n).Text(16)
+	}
+	return "0x" + n.Text(16)
+}
+
+func encodeBytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// decodeQuantity는 "0x" 접두 16진수 또는 10진수 문자열을 정수로 바꿉니다.
+func decodeQuantity(s string) (int64, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return strconv.ParseInt(s[2:], 16, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func decodeHexBytes(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return hex.DecodeString(s)
+}
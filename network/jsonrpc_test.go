@@ -0,0 +1,158 @@
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testResponse는 JSON-RPC 응답을 디코딩한 형태입니다.
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpcError   `json:"error"`
+}
+
+// newTestJSONRPC는 blocks개의 블록을 가진 체인의 JSON-RPC 서버를 만듭니다.
+func newTestJSONRPC(t *testing.T, blocks int) *jsonrpcServer {
+	t.Helper()
+	return newJSONRPCServer(&RPCServer{chain: newTestChain(t, blocks)})
+}
+
+// rpcCall은 body를 처리한 응답을 JSON으로 바꿨다가 다시 읽어 반환합니다. 응답이 없으면 nil입니다.
+func rpcCall(t *testing.T, s *jsonrpcServer, body string, grants rpcGrants) json.RawMessage {
+	t.Helper()
+	result := s.handleMessage([]byte(body), grants)
+	if result == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// rpcSingle은 단일 요청의 응답을 반환합니다.
+func rpcSingle(t *testing.T, s *jsonrpcServer, body string) testResponse {
+	t.Helper()
+	var res testResponse
+	if err := json.Unmarshal(rpcCall(t, s, body, nil), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// errorCode는 응답의 오류 코드를 반환하며 오류가 없으면 0입니다.
+func (r testResponse) errorCode() int {
+	if r.Error == nil {
+		return 0
+	}
+	return r.Error.Code
+}
+
+func TestJSONRPCSingle(t *testing.T) {
+	s := newTestJSONRPC(t, 5)
+	res := rpcSingle(t, s, `{"jsonrpc":"2.0","id":7,"method":"chain_blockNumber"}`)
+	if res.Error != nil || string(res.Result) != `"0x5"` || string(res.ID) != "7" {
+		t.Fatalf("response %+v, result %s", res, res.Result)
+	}
+}
+
+func TestJSONRPCErrorCodes(t *testing.T) {
+	s := newTestJSONRPC(t, 2)
+	s.methods["chain_panic"] = func(params []json.RawMessage) (interface{}, error) { panic("boom") }
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"parse", `{"jsonrpc":`, errCodeParse},
+		{"version", `{"jsonrpc":"1.0","id":1,"method":"chain_blockNumber"}`, errCodeInvalidRequest},
+		{"no method", `{"jsonrpc":"2.0","id":1}`, errCodeInvalidRequest},
+		{"type", `{"jsonrpc":"2.0","id":1,"method":5}`, errCodeInvalidRequest},
+		{"method", `{"jsonrpc":"2.0","id":1,"method":"chain_nothing"}`, errCodeMethodNotFound},
+		{"params", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":[1]}`, errCodeInvalidParams},
+		{"too many params", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":["0x1","0x2"]}`, errCodeInvalidParams},
+		{"unauthorized", `{"jsonrpc":"2.0","id":1,"method":"admin_peers"}`, errCodeUnauthorized},
+		{"panic", `{"jsonrpc":"2.0","id":1,"method":"chain_panic"}`, errCodeInternal},
+		{"empty batch", `[]`, errCodeInvalidRequest},
+		{"broken batch", `[{"jsonrpc":"2.0"`, errCodeParse},
+	}
+	for _, tt := range tests {
+		if code := rpcSingle(t, s, tt.body).errorCode(); code != tt.want {
+			t.Errorf("%s: code %d, want %d", tt.name, code, tt.want)
+		}
+	}
+
+	// 권한이 있으면 admin 메서드를 부를 수 있음
+	var res testResponse
+	json.Unmarshal(rpcCall(t, s, `{"jsonrpc":"2.0","id":1,"method":"admin_peers"}`, rpcGrants{groupAdmin: true}), &res)
+	if res.Error != nil {
+		t.Fatalf("admin_peers with admin grant: %v", res.Error)
+	}
+}
+
+// 배치는 요청마다 응답하고, 알림(id 없음)에는 응답하지 않음
+func TestJSONRPCBatch(t *testing.T) {
+	s := newTestJSONRPC(t, 3)
+	body := `[
+		{"jsonrpc":"2.0","id":1,"method":"chain_blockNumber"},
+		{"jsonrpc":"2.0","method":"chain_blockNumber"},
+		{"jsonrpc":"2.0","id":"b","method":"chain_nothing"},
+		5
+	]`
+	var responses []testResponse
+	if err := json.Unmarshal(rpcCall(t, s, body, nil), &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("%d responses, want 3", len(responses))
+	}
+	if string(responses[0].ID) != "1" || string(responses[0].Result) != `"0x3"` {
+		t.Errorf("first response %+v", responses[0])
+	}
+	if string(responses[1].ID) != `"b"` || responses[1].errorCode() != errCodeMethodNotFound {
+		t.Errorf("second response %+v", responses[1])
+	}
+	if string(responses[2].ID) != "null" || responses[2].errorCode() != errCodeInvalidRequest {
+		t.Errorf("third response %+v", responses[2])
+	}
+
+	// 알림만 있으면 응답이 없음
+	if res := rpcCall(t, s, `[{"jsonrpc":"2.0","method":"chain_blockNumber"}]`, nil); res != nil {
+		t.Fatalf("response to notifications: %s", res)
+	}
+	if res := rpcCall(t, s, `{"jsonrpc":"2.0","method":"chain_nothing"}`, nil); res != nil {
+		t.Fatalf("response to a notification: %s", res)
+	}
+}
+
+func TestJSONRPCServeHTTP(t *testing.T) {
+	s := newTestJSONRPC(t, 1)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"chain_blockNumber"}`)))
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("notification status %d, body %q", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"chain_blockNumber"}`)))
+	var res testResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil || string(res.Result) != `"0x1"` {
+		t.Fatalf("status %d, response %+v, %v", rec.Code, res, err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content type %q", ct)
+	}
+}
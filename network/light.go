@@ -0,0 +1,117 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// peer 하나의 블록 본문 응답을 기다리는 최대 시간
+const bodyFetchTimeout = 5 * time.Second
+
+// 본문 응답을 기다리는 요청. 키는 블록 해시이며 같은 블록을 여러 곳에서 동시에 기다릴 수 있음
+var (
+	bodyRequestsMu sync.Mutex
+	bodyRequests   = map[string][]chan *blockchain.Block{}
+)
+
+// fetchBlock은 저장하지 않은 블록을 알려진 peer에게 차례로 요청하고, check를 통과한 첫 블록을 반환합니다.
+func fetchBlock(hash []byte, check func(*blockchain.Block) error) (*blockchain.Block, error) {
+	key := string(hash)
+	ch := make(chan *blockchain.Block, 1)
+
+	bodyRequestsMu.Lock()
+	bodyRequests[key] = append(bodyRequests[key], ch)
+	bodyRequestsMu.Unlock()
+	defer func() {
+		bodyRequestsMu.Lock()
+		waiters := slices.DeleteFunc(bodyRequests[key], func(c chan *blockchain.Block) bool { return c == ch })
+		if len(waiters) == 0 {
+			delete(bodyRequests, key)
+		} else {
+			bodyRequests[key] = waiters
+		}
+		bodyRequestsMu.Unlock()
+	}()
+
+	// SendData가 연결에 실패한 노드를 KnownNodes에서 지우므로 복사본을 순회
+	for _, peer := range slices.Clone(KnownNodes) {
+		if peer == nodeAddress {
+			continue
+		}
+		SendGetBody(peer, hash)
+
+		select {
+		case block := <-ch:
+			if block == nil {
+				continue
+			}
+			if err := check(block); err != nil {
+				log.Warn("peer sent invalid block body", "hash", fmt.Sprintf("%x", hash), "peer", peer, "err", err)
+				continue
+			}
+			return block, nil
+		case <-time.After(bodyFetchTimeout):
+			log.Debug("block body request timed out", "hash", fmt.Sprintf("%x", hash), "peer", peer)
+		}
+	}
+	return nil, fmt.Errorf("%w: could not fetch block %x from peers", blockchain.ErrBlockPruned, hash)
+}
+
+// 블록 본문 요청을 처리하는 함수. 블록이 없으면 빈 응답을 보내 요청한 노드가 다음 peer에게 바로 요청하게 함
+func HandleGetBody(request []byte, chain *blockchain.BlockChain) {
+	var buff bytes.Buffer
+	var payload GetBody
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode block body request", "err", err)
+		return
+	}
+
+	var data []byte
+	// light 노드는 다른 peer에게 다시 요청하지 않도록 빈 응답을 보냄
+	if !chain.IsLight() {
+		if block, err := chain.GetBlock(payload.Hash); err == nil {
+			data, _ = block.Serialize()
+		}
+	}
+	SendBody(payload.AddrFrom, payload.Hash, data)
+}
+
+// 블록 본문 응답을 처리하는 함수. 같은 블록을 기다리는 모든 요청에 전달
+func HandleBody(request []byte) {
+	var buff bytes.Buffer
+	var payload Body
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode block body", "err", err)
+		return
+	}
+
+	var block *blockchain.Block
+	if len(payload.Block) > 0 {
+		var err error
+		if block, err = blockchain.Deserialize(payload.Block); err != nil {
+			log.Warn("invalid block body", "peer", payload.AddrFrom, "err", err)
+			block = nil
+		}
+	}
+
+	bodyRequestsMu.Lock()
+	defer bodyRequestsMu.Unlock()
+	for _, ch := range bodyRequests[string(payload.Hash)] {
+		select {
+		case ch <- block:
+		default:
+		}
+	}
+}
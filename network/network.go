@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
-	"log"
 	"net"
 	"os"
 	"runtime"
@@ -14,12 +13,15 @@ import (
 
 	"github.com/Kim-DaeHan/mining-chain/blockchain"
 	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/logger"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
 	"github.com/Kim-DaeHan/mining-chain/mining"
-	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/vrecan/death/v3"
 )
 
+var log = logger.New(logger.Network)
+
 const (
 	protocol      = "tcp"
 	version       = 1
@@ -33,6 +35,7 @@ var (
 	blocksInTransit  []*blockchain.Block // []Block 타입으로 선언
 	tempBlockList    []*blockchain.Block
 	globalChainId    string
+	globalChain      *blockchain.BlockChain
 
 	// sync block list
 	newBlockListChan = make(chan bool) // blocksInTransit에 새 블록이 추가되면 알림
@@ -79,12 +82,48 @@ type Inv struct {
 	Items    [][]byte
 }
 
+// inv로 알린 데이터를 요청
+type GetData struct {
+	AddrFrom string
+	Type     string
+	ID       []byte
+}
+
 type Version struct {
 	Version    int
 	BestHeight int64
 	AddrFrom   string
 }
 
+// 블록 본문 요청. light 노드가 저장하지 않은 블록을 받을 때 사용
+type GetBody struct {
+	AddrFrom string
+	Hash     []byte
+}
+
+// 블록 본문 응답. 블록이 없으면 Block이 비어있음
+type Body struct {
+	AddrFrom string
+	Hash     []byte
+	Block    []byte
+}
+
+// 스냅샷 조각 요청. Offset부터 한 조각을 요청
+type GetSnapshot struct {
+	AddrFrom string
+	Height   int64
+	Offset   int64
+}
+
+// 스냅샷 조각 응답. 조각을 이어 붙이면 blockchain 스냅샷 파일 형식이며, Total은 전체 크기입니다.
+type Snapshot struct {
+	AddrFrom string
+	Height   int64
+	Offset   int64
+	Total    int64
+	Data     []byte
+}
+
 // 명령어를 바이트 배열로 변환
 func CmdToBytes(cmd string) []byte {
 	// 명령어 길이에 맞는 바이트 배열 생성
@@ -128,6 +167,23 @@ func RemoveDuplicatesNodes(nodes []string) []string {
 	return result
 }
 
+// 지표 레이블로 쓸 명령어. 알 수 없는 명령어는 하나로 묶어 레이블 수가 늘지 않게 합니다.
+var knownCommands = map[string]bool{
+	"knownNodes": true, "block": true, "latestBlockHeight": true, "version": true, "blocklist": true,
+	"getsnapshot": true, "snapshot": true, "getbody": true, "body": true, "inv": true, "getdata": true,
+}
+
+func commandLabel(request []byte) string {
+	if len(request) < commandLength {
+		return "unknown"
+	}
+	command := BytesToCmd(request[:commandLength])
+	if !knownCommands[command] {
+		return "unknown"
+	}
+	return command
+}
+
 // 요청에서 명령어 부분을 추출
 func ExtractCmd(request []byte) []byte {
 	// 요청 데이터에서 명령어 부분만 추출
@@ -138,6 +194,7 @@ func StartServer(chain *blockchain.BlockChain, valiAddress string) {
 	var bcNode blockchain.Node
 
 	globalChainId = chain.ChainId
+	globalChain = chain
 	validatorAddress = valiAddress
 
 	newNode := bcNode.NewNode(valiAddress, config.GlobalConfig.Port)
@@ -145,35 +202,49 @@ func StartServer(chain *blockchain.BlockChain, valiAddress string) {
 	if !newNode.IsPublicIP(newNode.IP) {
 		nodeAddress = fmt.Sprintf("localhost:%d", newNode.ListenPort)
 	}
-	fmt.Printf("Starting node server with nodeAddress: %s\n", nodeAddress)
-
 	conn, err := net.Dial("tcp", nodeAddress)
 
 	if err == nil {
 		conn.Close()
-		log.Printf("Error: Port %s is already in use.", nodeAddress)
+		log.Error("Port is already in use", "addr", nodeAddress)
 		os.Exit(1)
 	}
 
-	log.Printf("Starting node server on %s", nodeAddress)
+	log.Info("Starting node server", "addr", nodeAddress)
 
 	ln, err := net.Listen("tcp", nodeAddress)
 	if err != nil {
-		log.Printf("Error occurred while starting server: %v", err)
+		log.Error("Error occurred while starting server", "err", err)
 		os.Exit(1)
 	}
 
 	defer ln.Close()
-	defer chain.Database.Close()
+	defer chain.Close()
 	go CloseDB(chain)
 
-	log.Printf("Node server successfully started on %s", nodeAddress)
+	if chain.IsLight() {
+		// 작업증명을 검증하려면 본문이 필요하므로 동기화는 full 노드와 같이 블록 목록으로 받고 헤더만 저장
+		log.Info("Running as a light node, synced blocks are verified and only their headers stored, bodies are fetched from peers on demand")
+	}
+	// light 노드와 스냅샷으로 시작한 full 노드는 저장하지 않은 본문을 peer에게서 받음
+	chain.FetchBlock = fetchBlock
+	startBackfill(chain)
+	if config.GlobalConfig.NodeType == "pruned" {
+		log.Info("Pruning old block bodies", "retain", config.GlobalConfig.PruneRetain)
+		go chain.RunPruner(config.GlobalConfig.PruneRetain)
+	}
+
+	config.OnReload(trimKnownNodes)
+	config.WatchSIGHUP()
+
+	log.Info("Node server successfully started", "addr", nodeAddress)
+	updatePeerCount()
 	rpcErrorChan := make(chan error)
 
 	go StartRPCServer(chain, rpcErrorChan, newNode)
 
 	if nodeAddress != KnownNodes[0] {
-		fmt.Printf("Sending version from node %s to master node %s\n", nodeAddress, KnownNodes[0])
+		log.Info("Sending version to master node", "from", nodeAddress, "master", KnownNodes[0])
 		SendVersion(KnownNodes[0], chain)
 	}
 
@@ -183,14 +254,15 @@ func StartServer(chain *blockchain.BlockChain, valiAddress string) {
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
-			log.Panic()
+			log.Error("accept failed", "err", err)
+			continue
 		}
 		go HandleConnection(conn, chain)
 
 		select {
 		case rpcErr := <-rpcErrorChan:
 			if rpcErr != nil {
-				log.Println("Error in RPC server:", rpcErr)
+				rpcLog.Error("Error in RPC server", "err", rpcErr)
 			}
 		default:
 			// No action needed in default case
@@ -198,16 +270,77 @@ func StartServer(chain *blockchain.BlockChain, valiAddress string) {
 	}
 }
 
-func GobEncode(data interface{}) []byte {
+func GobEncode(data interface{}) ([]byte, error) {
 	var buff bytes.Buffer
 
 	enc := gob.NewEncoder(&buff)
-	err := enc.Encode(data)
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
 
-	if err != nil {
-		log.Panic(err)
+// addKnownNodes는 maxPeers를 넘지 않는 범위에서 KnownNodes에 주소를 추가합니다.
+// 새로 추가된 주소가 있으면 true를 반환합니다.
+func addKnownNodes(addrs ...string) bool {
+	added := false
+	for _, addr := range addrs {
+		if addr == "" || NodeIsKnown(addr) {
+			continue
+		}
+		if len(KnownNodes) >= config.MaxPeers() {
+			log.Warn("maxPeers reached, ignoring node", "maxPeers", config.MaxPeers(), "addr", addr)
+			break
+		}
+		KnownNodes = append(KnownNodes, addr)
+		added = true
+		PeerFeed.Send(PeerEvent{Type: PeerAdded, Peer: addr})
 	}
-	return buff.Bytes()
+	updatePeerCount()
+	return added
+}
+
+// trimKnownNodes는 maxPeers가 줄어든 경우 초과한 노드를 목록에서 제거합니다.
+func trimKnownNodes(cfg config.Config) {
+	if len(KnownNodes) > cfg.MaxPeers {
+		for _, addr := range KnownNodes[cfg.MaxPeers:] {
+			PeerFeed.Send(PeerEvent{Type: PeerDropped, Peer: addr})
+			forgetPeer(addr)
+		}
+		KnownNodes = KnownNodes[:cfg.MaxPeers]
+	}
+	updatePeerCount()
+}
+
+// setSync는 동기화 상태를 바꾸고 지표에 반영합니다.
+func setSync(v bool) {
+	changed := isSync != v
+	isSync = v
+	if changed && globalChain != nil {
+		SyncFeed.Send(SyncEvent{Syncing: v, CurrentHeight: globalChain.GetBestHeight(), TargetHeight: syncTarget})
+	}
+	if v {
+		metrics.Syncing.Set(1)
+	} else {
+		metrics.Syncing.Set(0)
+	}
+}
+
+// setSyncTarget은 동기화 대상 높이를 기록합니다.
+func setSyncTarget(height int64) {
+	syncTarget = height
+	metrics.SetSyncTarget(height)
+}
+
+// updatePeerCount는 자신을 제외한 알려진 노드 수를 지표에 반영합니다.
+func updatePeerCount() {
+	count := 0
+	for _, node := range KnownNodes {
+		if node != nodeAddress {
+			count++
+		}
+	}
+	metrics.Peers.Set(float64(count))
 }
 
 // 노드가 알려진 노드 목록에 있는지 확인하는 함수
@@ -230,7 +363,7 @@ func CloseDB(chain *blockchain.BlockChain) {
 	d.WaitForDeathWithFunc(func() {
 		defer os.Exit(1)
 		defer runtime.Goexit()
-		chain.Database.Close()
+		chain.Close()
 	})
 }
 
@@ -238,49 +371,82 @@ func CloseDB(chain *blockchain.BlockChain) {
 func monitorBlocksInTransit(chain *blockchain.BlockChain) {
 	mu.Lock()
 	defer mu.Unlock()
-	isSync = true
+	setSync(true)
 
 	blockchain.SortBlocksByHeight(blocksInTransit)
 
 	for len(blocksInTransit) > 0 {
 		block := blocksInTransit[0]
 		blocksInTransit = blocksInTransit[1:] // 첫 번째 블록 제거
-		fmt.Printf("블록 추가 작업 중... 블록높이: %d, 해시: %x\n", block.Height, block.Hash)
+		log.Debug("블록 추가 작업 중...", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash))
 
 		chain.Mu.Lock()
 
 		blockHeight := block.Height
 		blockHash := block.Hash
+		from := takeBlockSource(blockHash)
 
+		added := []*blockchain.Block{block}
 		if blockHeight == 0 {
-			batch := new(leveldb.Batch)
-			batch.Put(blockHash, block.Serialize())
-			batch.Put([]byte("lh"), blockHash)
-			heightKey := []byte(fmt.Sprintf("height-%d", blockHeight))
-			batch.Put(heightKey, blockHash)
-
-			err := chain.Database.Write(batch, nil)
-			blockchain.Handle(err)
-
-			chain.LastHash = blockHash
+			// 이미 체인이 있으면 genesis를 다시 쓰지 않음
+			if len(chain.LastHash) == 0 {
+				if err := chain.WriteGenesis(block); err != nil {
+					log.Error("genesis 블록 거부", "hash", fmt.Sprintf("%x", blockHash), "err", err)
+				}
+			}
+		} else if connected, err := connectBlock(chain, block); err != nil {
+			log.Debug("블록 추가 실패", "height", blockHeight, "hash", fmt.Sprintf("%x", blockHash), "err", err)
+			takeRelay(blockHash)
 		} else {
-			chain.AddBlock(block)
+			added = connected
+			for i, b := range added {
+				// peer에게서 알림으로 받은 블록과 갈래를 바꾸며 함께 추가한 고아 블록은 다른 peer에게 다시 알림
+				if takeRelay(b.Hash) || i > 0 {
+					announceBlock(chain, b)
+				}
+			}
 		}
 
 		chain.Mu.Unlock()
+
+		if _, err := chain.GetHeader(blockHash); err != nil {
+			// 다른 갈래의 블록이라 부모가 없으면 고아 블록 풀에서 조상을 기다림
+			if blockHeight > 0 {
+				if _, err := chain.GetHeader(block.PrevHash); err != nil {
+					handleOrphan(chain, block, from)
+				}
+			}
+			continue
+		}
+
+		// 체인에 들어간 블록을 기다리던 고아 블록을 이어서 추가
+		var children []*orphanBlock
+		for _, b := range added {
+			children = append(children, takeOrphans(b.Hash)...)
+		}
+		if len(children) > 0 {
+			inventoryMu.Lock()
+			for _, child := range children {
+				relayInventory[string(child.block.Hash)] = struct{}{}
+			}
+			inventoryMu.Unlock()
+			for _, child := range children {
+				setBlockSource(child.block.Hash, child.from)
+				blocksInTransit = append(blocksInTransit, child.block)
+			}
+			blockchain.SortBlocksByHeight(blocksInTransit)
+		}
 	}
 
-	isSync = false
+	setSync(false)
 }
 
 func listenForNewBlocks(chain *blockchain.BlockChain) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if len(chain.LastHash) > 0 {
-		// 초기 mining 시작
-		go mining.Run(ctx, chain, validatorAddress, miningBlockChan)
-	}
+	// 초기 mining 시작
+	startMining(ctx, chain)
 
 	for {
 
@@ -292,53 +458,42 @@ func listenForNewBlocks(chain *blockchain.BlockChain) {
 			cancel()
 			ctx, cancel = context.WithCancel(context.Background())
 
-			// 블록 전파
-			for _, node := range KnownNodes {
-				if node == nodeAddress {
-					continue
-				}
-				fmt.Println("블록 전파 node: ", node)
-				fmt.Println("Propagating block to node:", node)
-				SendBlock(node, miningBlock)
-			}
-
 			chain.Mu.Lock()
-			chain.AddBlock(miningBlock)
+			err := chain.ImportBlock(miningBlock)
 			chain.Mu.Unlock()
 
-			if len(chain.LastHash) > 0 {
-				go mining.Run(ctx, chain, validatorAddress, miningBlockChan)
+			// 체인에 추가된 블록만 전파
+			if err != nil {
+				log.Warn("mined block rejected", "height", miningBlock.Height, "hash", fmt.Sprintf("%x", miningBlock.Hash), "err", err)
+			} else {
+				announceBlock(chain, miningBlock)
 			}
 
+			startMining(ctx, chain)
+
 		case <-newBlockListChan:
 			// 새 블록 알림 수신 시 즉시 blocksInTransit 확인 및 add 블록 작업
 			monitorBlocksInTransit(chain)
 
-			if len(chain.LastHash) > 0 {
-				go mining.Run(ctx, chain, validatorAddress, miningBlockChan)
-			}
+			startMining(ctx, chain)
 
 		}
 
 	}
 }
 
-func SyncWithLongestChain(chain *blockchain.BlockChain, otherHeight int64, addr string) {
-	if !isSync {
-		isSync = true
-		syncChan <- true
-
-		chain.ResetDatabase()
-		chain.LastHash = []byte{}
-		chain.CurrentBlock = nil
-		SendLatestBlockHeight(addr, 0, otherHeight)
+// startMining은 tip이 있으면 채굴을 시작합니다. light 노드는 채굴하지 않습니다.
+func startMining(ctx context.Context, chain *blockchain.BlockChain) {
+	if len(chain.LastHash) > 0 && !chain.IsLight() {
+		go mining.Run(ctx, chain, validatorAddress, miningBlockChan)
 	}
 }
 
 func SyncKnownNodes(addr string) {
 	if !NodeIsKnown(addr) && addr != "" {
-		KnownNodes = append(KnownNodes, addr)
-		KnownNodes = RemoveDuplicatesNodes(KnownNodes)
+		if !addKnownNodes(addr) {
+			return
+		}
 
 		for _, node := range KnownNodes {
 			if node == nodeAddress {
@@ -347,6 +502,6 @@ func SyncKnownNodes(addr string) {
 
 			SendKnownNodes(node)
 		}
-		fmt.Println("KnownNodes: ", KnownNodes)
+		log.Debug("known nodes updated", "nodes", KnownNodes)
 	}
 }
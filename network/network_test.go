@@ -0,0 +1,184 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/params"
+)
+
+// testParams는 테스트 체인의 합의 파라미터입니다.
+// 블록 간격을 ResourceInterval로 맞추므로 난이도는 1에서 바뀌지 않습니다.
+var testParams = params.ChainParams{
+	Name:              "test",
+	InitialDifficulty: big.NewInt(1),
+	Rules: params.Rules{
+		DifficultyChangeCycle: 10,
+		ResourceInterval:      5,
+		MaxDifficultyWeight:   4.0,
+		MinDifficultyWeight:   0.25,
+	},
+}
+
+const (
+	testChainId = 3002
+	testMiner   = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+)
+
+// useTestConfig는 테스트가 끝나면 설정과 노드 주소를 되돌립니다. 데이터베이스는 새 메모리 저장소를 사용합니다.
+func useTestConfig(t *testing.T) {
+	t.Helper()
+	saved, savedAddr := config.GlobalConfig, nodeAddress
+	t.Cleanup(func() { config.GlobalConfig, nodeAddress = saved, savedAddr })
+
+	config.GlobalConfig.DataDir = t.TempDir()
+	config.GlobalConfig.DBEngine = "memory"
+	nodeAddress = "localhost:0"
+}
+
+// newTestChain은 테스트 genesis와 blocks개의 블록을 가진 체인을 만듭니다.
+func newTestChain(t *testing.T, blocks int) *blockchain.BlockChain {
+	t.Helper()
+	useTestConfig(t)
+	spec := &blockchain.GenesisSpec{
+		ChainId:    testChainId,
+		Timestamp:  1700000000,
+		Difficulty: big.NewInt(1),
+		Validators: []string{testMiner},
+		Consensus:  testParams.Copy(),
+	}
+	chain, err := blockchain.InitBlockChainFromGenesis(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+
+	for i := 0; i < blocks; i++ {
+		if err := chain.ImportBlock(newTestBlock(t, chain.GetLastBlock(), "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return chain
+}
+
+// newTestBlock은 parent 바로 위에 오는 채굴된 블록을 만듭니다. extra로 같은 높이의 다른 블록을 만들 수 있습니다.
+func newTestBlock(t *testing.T, parent *blockchain.Block, extra string) *blockchain.Block {
+	t.Helper()
+	block := &blockchain.Block{
+		Timestamp:     parent.Timestamp + testParams.ResourceInterval,
+		Hash:          blockchain.HexBytes{},
+		PrevHash:      parent.Hash,
+		MainBlockHash: blockchain.HexBytes{},
+		Nonce:         blockchain.HexBytes{},
+		Height:        parent.Height + 1,
+		Difficulty:    big.NewInt(1),
+		Miner:         blockchain.HexBytes(testMiner),
+		Validator:     blockchain.HexBytes(testMiner),
+	}
+	if extra != "" {
+		block.ExtraData = blockchain.HexBytes(extra)
+	}
+	return sealTestBlock(t, block)
+}
+
+// sealTestBlock은 block의 작업증명을 찾고 해시를 채웁니다.
+func sealTestBlock(t *testing.T, block *blockchain.Block) *blockchain.Block {
+	t.Helper()
+	pow := blockchain.NewProof(block)
+	nonce, err := pow.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Nonce = nonce
+	block.Hash = pow.GetHash(block)
+	return block
+}
+
+// testPeer는 노드가 보낸 메시지를 받는 가짜 peer입니다.
+type testPeer struct {
+	addr string
+	msgs chan []byte
+}
+
+func newTestPeer(t *testing.T) *testPeer {
+	t.Helper()
+	ln, err := net.Listen(protocol, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	peer := &testPeer{addr: ln.Addr().String(), msgs: make(chan []byte, 64)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			peer.msgs <- data
+		}
+	}()
+	return peer
+}
+
+// expect는 peer가 받은 다음 메시지가 command인지 확인하고 payload를 디코딩합니다.
+func (p *testPeer) expect(t *testing.T, command string, payload any) {
+	t.Helper()
+	select {
+	case msg := <-p.msgs:
+		if got := BytesToCmd(msg[:commandLength]); got != command {
+			t.Fatalf("peer received %q, want %q", got, command)
+		}
+		if err := gob.NewDecoder(bytes.NewReader(msg[commandLength:])).Decode(payload); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("peer did not receive %q", command)
+	}
+}
+
+// expectNothing은 잠시 기다리는 동안 peer가 아무것도 받지 않았는지 확인합니다.
+func (p *testPeer) expectNothing(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-p.msgs:
+		t.Fatalf("peer received unexpected %q", BytesToCmd(msg[:commandLength]))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// message는 command와 payload로 노드가 받을 요청을 만듭니다.
+func message(command string, payload any) []byte {
+	data, err := GobEncode(payload)
+	if err != nil {
+		panic(err)
+	}
+	return append(CmdToBytes(command), data...)
+}
+
+// useKnownNodes는 테스트 동안 알려진 노드 목록을 nodes로 바꿉니다.
+func useKnownNodes(t *testing.T, nodes ...string) {
+	t.Helper()
+	saved := KnownNodes
+	t.Cleanup(func() { KnownNodes = saved })
+	KnownNodes = nodes
+}
+
+// 인코딩할 수 없는 메시지는 보내지 않고 노드도 멈추지 않음
+func TestSendMessageEncodeError(t *testing.T) {
+	if _, err := GobEncode(make(chan int)); err == nil {
+		t.Fatal("encoded a channel")
+	}
+	peer := newTestPeer(t)
+	sendMessage(peer.addr, "inv", make(chan int))
+	peer.expectNothing(t)
+}
@@ -0,0 +1,327 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
+)
+
+const (
+	// 고아 블록 풀에 보관할 최대 블록 수
+	maxOrphans = 256
+	// peer 하나가 고아 블록 풀에 보관할 수 있는 최대 블록 수
+	maxOrphansPerPeer = 64
+	// 부모가 이 시간 안에 오지 않은 고아 블록은 버림
+	orphanExpiry = 5 * time.Minute
+)
+
+// orphanBlock은 부모 블록이 아직 없어 체인에 추가하지 못한 블록입니다.
+type orphanBlock struct {
+	block    *blockchain.Block
+	from     string
+	received time.Time
+}
+
+var (
+	orphanMu      sync.Mutex
+	orphans       = map[string]*orphanBlock{}   // 블록 해시 → 고아 블록
+	orphansByPrev = map[string][]*orphanBlock{} // 부모 해시(PrevHash) → 그 부모를 기다리는 고아 블록
+	orphanOrder   []*orphanBlock                // 받은 순서. 오래된 블록부터 버림
+	blockSources  = map[string]string{}         // blocksInTransit의 블록 해시 → 보낸 peer
+)
+
+// orphanRoot는 block에서 고아 블록 풀의 부모를 따라 올라가 부모가 풀에 없는 가장 오래된 조상을 반환합니다.
+func orphanRoot(block *blockchain.Block) *blockchain.Block {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+
+	for {
+		parent, ok := orphans[string(block.PrevHash)]
+		if !ok {
+			return block
+		}
+		block = parent.block
+	}
+}
+
+// setBlockSource는 blocksInTransit에 넣은 블록을 보낸 peer를 기록합니다.
+func setBlockSource(hash []byte, from string) {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+	blockSources[string(hash)] = from
+}
+
+// takeBlockSource는 블록을 보낸 peer를 반환하고 기록을 지웁니다.
+func takeBlockSource(hash []byte) string {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+	from := blockSources[string(hash)]
+	delete(blockSources, string(hash))
+	return from
+}
+
+// addOrphan은 block을 고아 블록 풀에 넣습니다. 풀이나 보낸 peer의 몫이 차면 가장 오래된 블록을 버립니다.
+// 이미 풀에 있으면 false를 반환합니다.
+func addOrphan(block *blockchain.Block, from string) bool {
+	// 버린 고아 블록의 알림 기록은 orphanMu를 놓은 뒤 지움
+	var dropped []*orphanBlock
+	defer func() { forgetRelay(dropped) }()
+
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+
+	if _, ok := orphans[string(block.Hash)]; ok {
+		return false
+	}
+	dropped = expireOrphansLocked()
+
+	count := 0
+	var oldest *orphanBlock
+	for _, o := range orphanOrder {
+		if o.from == from {
+			if oldest == nil {
+				oldest = o
+			}
+			count++
+		}
+	}
+	if count >= maxOrphansPerPeer {
+		dropOrphanLocked(oldest)
+		dropped = append(dropped, oldest)
+	}
+	if len(orphanOrder) >= maxOrphans {
+		oldest := orphanOrder[0]
+		dropOrphanLocked(oldest)
+		dropped = append(dropped, oldest)
+	}
+
+	o := &orphanBlock{block: block, from: from, received: time.Now()}
+	orphans[string(block.Hash)] = o
+	orphansByPrev[string(block.PrevHash)] = append(orphansByPrev[string(block.PrevHash)], o)
+	orphanOrder = append(orphanOrder, o)
+	metrics.OrphanBlocks.Set(float64(len(orphanOrder)))
+
+	log.Debug("Added orphan block", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash), "from", from, "orphans", len(orphanOrder))
+	return true
+}
+
+// takeOrphans는 hash를 부모로 기다리던 고아 블록을 풀에서 꺼내 반환합니다.
+func takeOrphans(hash []byte) []*orphanBlock {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+
+	children := append([]*orphanBlock{}, orphansByPrev[string(hash)]...)
+	for _, o := range children {
+		removeOrphanLocked(o)
+	}
+	return children
+}
+
+// removeOrphans는 갈래를 바꾸며 체인에 추가한 고아 블록을 풀과 보낸 peer 기록에서 지웁니다.
+func removeOrphans(blocks []*blockchain.Block) {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+
+	for _, b := range blocks {
+		if o, ok := orphans[string(b.Hash)]; ok {
+			dropOrphanLocked(o)
+		}
+	}
+}
+
+// orphanBranch는 hash에서 고아 블록으로 이어지는 가장 긴 갈래를 낮은 높이부터 반환합니다.
+func orphanBranch(hash []byte) []*blockchain.Block {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+
+	var longest func(hash []byte) []*blockchain.Block
+	longest = func(hash []byte) []*blockchain.Block {
+		var best []*blockchain.Block
+		for _, o := range orphansByPrev[string(hash)] {
+			if branch := append([]*blockchain.Block{o.block}, longest(o.block.Hash)...); len(branch) > len(best) {
+				best = branch
+			}
+		}
+		return best
+	}
+	return longest(hash)
+}
+
+// expireOrphansLocked는 orphanExpiry보다 오래된 고아 블록을 버리고 반환합니다. orphanMu를 잡고 호출해야 합니다.
+func expireOrphansLocked() []*orphanBlock {
+	var expired []*orphanBlock
+	for len(orphanOrder) > 0 && time.Since(orphanOrder[0].received) >= orphanExpiry {
+		o := orphanOrder[0]
+		log.Debug("Orphan block expired", "height", o.block.Height, "hash", fmt.Sprintf("%x", o.block.Hash), "from", o.from)
+		dropOrphanLocked(o)
+		expired = append(expired, o)
+	}
+	return expired
+}
+
+// dropOrphanLocked는 체인에 추가하지 않고 버리는 o를 풀에서 지우고, 보낸 peer 기록도 지웁니다.
+// 다른 peer에게 알릴 기록은 inventoryMu로 보호되므로 orphanMu를 놓은 뒤 forgetRelay로 지워야 합니다.
+// orphanMu를 잡고 호출해야 합니다.
+func dropOrphanLocked(o *orphanBlock) {
+	removeOrphanLocked(o)
+	delete(blockSources, string(o.block.Hash))
+}
+
+// removeOrphanLocked는 o를 풀의 모든 색인에서 지웁니다. orphanMu를 잡고 호출해야 합니다.
+func removeOrphanLocked(o *orphanBlock) {
+	delete(orphans, string(o.block.Hash))
+
+	prev := string(o.block.PrevHash)
+	siblings := orphansByPrev[prev]
+	for i, s := range siblings {
+		if s == o {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(orphansByPrev, prev)
+	} else {
+		orphansByPrev[prev] = siblings
+	}
+
+	for i, s := range orphanOrder {
+		if s == o {
+			orphanOrder = append(orphanOrder[:i], orphanOrder[i+1:]...)
+			break
+		}
+	}
+	metrics.OrphanBlocks.Set(float64(len(orphanOrder)))
+}
+
+// handleOrphan은 부모를 모르는 block을 고아 블록 풀에 넣고, block이 속한 고아 갈래에서 빠진 조상을
+// 보낸 peer에게 요청합니다. 같은 조상을 최근에 요청했으면 다시 요청하지 않습니다.
+func handleOrphan(chain *blockchain.BlockChain, block *blockchain.Block, from string) {
+	if !addOrphan(block, from) || from == "" {
+		return
+	}
+	root := orphanRoot(block)
+	if !requestInventory(root.PrevHash) {
+		return
+	}
+
+	var bestHeight int64 = -1
+	if len(chain.LastHash) > 0 {
+		bestHeight = chain.GetBestHeight()
+	}
+
+	if root.Height-1 > bestHeight {
+		// tip 위의 높이가 비어 있으면 블록 목록으로 한 번에 요청
+		log.Info("Requesting missing blocks for orphan", "from", bestHeight+1, "to", root.Height-1, "peer", from)
+		setSyncTarget(block.Height)
+		SendLatestBlockHeight(from, bestHeight+1, root.Height-1)
+	} else {
+		// 다른 갈래의 블록이면 갈라진 곳까지 부모를 하나씩 거슬러 요청
+		SendGetData(from, invBlock, root.PrevHash)
+	}
+}
+
+// connectBlock은 block을 검증해 체인에 추가하고 추가한 블록을 낮은 높이부터 반환합니다.
+// block이 tip이 아닌 정규 블록에서 갈라지면 block과 그 뒤를 잇는 고아 블록의 갈래를 먼저 검증하고,
+// 검증된 갈래가 현재 체인보다 길 때만 갈라진 높이로 되돌린 뒤 검증된 블록을 모두 추가합니다.
+// 추가한 고아 블록은 풀에서 지우고, 버린 이전 블록은 되돌린 블록 보관소에 남기지 않습니다.
+// 추가하다 실패해 갈래가 이전 체인보다 길지 않으면 이전 블록을 다시 추가합니다. chain.Mu를 잡고 호출해야 합니다.
+func connectBlock(chain *blockchain.BlockChain, block *blockchain.Block) ([]*blockchain.Block, error) {
+	if _, err := chain.GetHeader(block.Hash); err == nil {
+		return nil, blockchain.ErrKnownBlock
+	}
+	err := chain.ImportBlock(block)
+	if err == nil {
+		return []*blockchain.Block{block}, nil
+	}
+	if !errors.Is(err, blockchain.ErrPrevHashMismatch) && !errors.Is(err, blockchain.ErrInvalidHeight) {
+		return nil, err
+	}
+
+	parent, perr := chain.GetHeader(block.PrevHash)
+	if perr != nil {
+		return nil, err
+	}
+	canonical, perr := chain.GetHeaderByHeight(parent.Height)
+	if perr != nil || !bytes.Equal(canonical.Hash, parent.Hash) {
+		return nil, err
+	}
+
+	branch := append([]*blockchain.Block{block}, orphanBranch(block.Hash)...)
+	valid, verr := chain.VerifyBranch(branch)
+	if valid == 0 {
+		return nil, verr
+	}
+	if verr != nil {
+		log.Debug("Orphan branch has an invalid block", "height", branch[valid].Height, "hash", fmt.Sprintf("%x", branch[valid].Hash), "err", verr)
+	}
+	branch = branch[:valid]
+	oldHeight := chain.GetBestHeight()
+	if branch[len(branch)-1].Height <= oldHeight {
+		return nil, err
+	}
+
+	// 갈래를 추가하지 못하면 되돌릴 수 있도록 버릴 블록을 먼저 읽음
+	var old []*blockchain.Block
+	for height := parent.Height + 1; height <= oldHeight; height++ {
+		b, err := chain.GetBlockByHeight(height)
+		if err != nil {
+			return nil, fmt.Errorf("could not read block at height %d: %w", height, err)
+		}
+		old = append(old, b)
+	}
+
+	log.Info("Switching to longer branch", "forkHeight", parent.Height, "localHeight", oldHeight, "branchHeight", branch[len(branch)-1].Height)
+	if _, err := chain.Rewind(parent.Height); err != nil {
+		return nil, fmt.Errorf("could not rewind to fork height %d: %w", parent.Height, err)
+	}
+	added, err := importBranch(chain, branch)
+	if err != nil && chain.GetBestHeight() <= oldHeight {
+		restoreBranch(chain, parent.Height, old)
+		if derr := chain.DiscardRewound(added); derr != nil {
+			log.Error("Could not discard rewound branch", "err", derr)
+		}
+		return nil, err
+	}
+	if err != nil {
+		log.Warn("Switched to a shorter part of the verified branch", "height", chain.GetBestHeight(), "err", err)
+	}
+
+	removeOrphans(added[1:])
+	if derr := chain.DiscardRewound(old); derr != nil {
+		log.Error("Could not discard replaced blocks", "err", derr)
+	}
+	return added, nil
+}
+
+// importBranch는 blocks를 차례로 추가하고 추가한 블록을 반환합니다. 추가하지 못하면 그 앞에서 멈춥니다.
+func importBranch(chain *blockchain.BlockChain, blocks []*blockchain.Block) ([]*blockchain.Block, error) {
+	for i, b := range blocks {
+		if err := chain.ImportBlock(b); err != nil {
+			return blocks[:i], err
+		}
+	}
+	return blocks, nil
+}
+
+// restoreBranch는 갈래로 바꾸지 못했을 때 갈라진 높이로 다시 되돌리고 이전 블록 old를 다시 추가합니다.
+func restoreBranch(chain *blockchain.BlockChain, forkHeight int64, old []*blockchain.Block) {
+	if chain.GetBestHeight() > forkHeight {
+		if _, err := chain.Rewind(forkHeight); err != nil {
+			log.Error("Could not rewind the failed branch", "height", chain.GetBestHeight(), "err", err)
+			return
+		}
+	}
+	added, err := importBranch(chain, old)
+	if err != nil {
+		log.Error("Could not restore replaced blocks, run \"chain reapply\" to add the rest", "height", chain.GetBestHeight(), "err", err)
+	}
+	if err := chain.DiscardRewound(added); err != nil {
+		log.Error("Could not discard restored blocks", "err", err)
+	}
+}
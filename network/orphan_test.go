@@ -0,0 +1,331 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/storage"
+)
+
+// resetOrphans는 고아 블록 풀과 블록을 보낸 peer 기록을 비웁니다.
+func resetOrphans(t *testing.T) {
+	reset := func() {
+		orphanMu.Lock()
+		defer orphanMu.Unlock()
+		orphans = map[string]*orphanBlock{}
+		orphansByPrev = map[string][]*orphanBlock{}
+		orphanOrder = nil
+		blockSources = map[string]string{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// fakeOrphan은 풀에만 넣을 검증하지 않는 블록을 만듭니다.
+func fakeOrphan(n int) *blockchain.Block {
+	hash := make([]byte, 32)
+	binary.BigEndian.PutUint64(hash, uint64(n)+1)
+	return &blockchain.Block{Hash: hash, PrevHash: []byte(fmt.Sprintf("parent-%d", n)), Height: int64(n) + 100}
+}
+
+func hasOrphan(block *blockchain.Block) bool {
+	orphanMu.Lock()
+	defer orphanMu.Unlock()
+	_, ok := orphans[string(block.Hash)]
+	return ok
+}
+
+// relayPending은 block을 다른 peer에게 알릴 블록으로 기록하고 보낸 peer를 남깁니다.
+func relayPending(block *blockchain.Block, from string) {
+	inventoryMu.Lock()
+	relayInventory[string(block.Hash)] = struct{}{}
+	inventoryMu.Unlock()
+	setBlockSource(block.Hash, from)
+}
+
+// forgotten은 버린 고아 블록의 알림과 보낸 peer 기록이 지워졌는지 확인합니다.
+func forgotten(block *blockchain.Block) bool {
+	inventoryMu.Lock()
+	_, relay := relayInventory[string(block.Hash)]
+	inventoryMu.Unlock()
+	orphanMu.Lock()
+	_, source := blockSources[string(block.Hash)]
+	orphanMu.Unlock()
+	return !relay && !source
+}
+
+func TestOrphanPerPeerQuota(t *testing.T) {
+	resetOrphans(t)
+	resetInventory(t)
+
+	other := fakeOrphan(-1)
+	addOrphan(other, "other")
+	var blocks []*blockchain.Block
+	for i := 0; i < maxOrphansPerPeer; i++ {
+		blocks = append(blocks, fakeOrphan(i))
+		addOrphan(blocks[i], "peer")
+	}
+	if addOrphan(blocks[0], "peer") {
+		t.Fatal("same orphan added twice")
+	}
+	relayPending(blocks[0], "peer")
+
+	// 몫이 찬 peer의 블록은 그 peer의 가장 오래된 블록을 밀어냄
+	extra := fakeOrphan(maxOrphansPerPeer)
+	if !addOrphan(extra, "peer") {
+		t.Fatal("orphan not added")
+	}
+	if hasOrphan(blocks[0]) || !hasOrphan(blocks[1]) || !hasOrphan(extra) {
+		t.Fatal("oldest orphan of the peer not evicted")
+	}
+	if !hasOrphan(other) {
+		t.Fatal("orphan of another peer evicted")
+	}
+	if !forgotten(blocks[0]) {
+		t.Fatal("relay or source of evicted orphan left behind")
+	}
+}
+
+func TestOrphanPoolLimit(t *testing.T) {
+	resetOrphans(t)
+	resetInventory(t)
+
+	var blocks []*blockchain.Block
+	for i := 0; i < maxOrphans; i++ {
+		blocks = append(blocks, fakeOrphan(i))
+		addOrphan(blocks[i], fmt.Sprintf("peer-%d", i%8))
+	}
+	relayPending(blocks[0], "peer-0")
+
+	addOrphan(fakeOrphan(maxOrphans), "new-peer")
+	if len(orphanOrder) != maxOrphans {
+		t.Fatalf("pool has %d orphans, want %d", len(orphanOrder), maxOrphans)
+	}
+	if hasOrphan(blocks[0]) || !hasOrphan(blocks[1]) {
+		t.Fatal("oldest orphan not evicted")
+	}
+	if !forgotten(blocks[0]) {
+		t.Fatal("relay or source of evicted orphan left behind")
+	}
+}
+
+func TestOrphanExpiry(t *testing.T) {
+	resetOrphans(t)
+	resetInventory(t)
+
+	old := fakeOrphan(0)
+	addOrphan(old, "peer")
+	relayPending(old, "peer")
+	orphanMu.Lock()
+	orphans[string(old.Hash)].received = time.Now().Add(-orphanExpiry)
+	orphanMu.Unlock()
+
+	fresh := fakeOrphan(1)
+	addOrphan(fresh, "peer")
+	if hasOrphan(old) || !hasOrphan(fresh) {
+		t.Fatal("expired orphan kept")
+	}
+	if !forgotten(old) {
+		t.Fatal("relay or source of expired orphan left behind")
+	}
+	if _, ok := orphansByPrev[string(old.PrevHash)]; ok {
+		t.Fatal("expired orphan left in parent index")
+	}
+}
+
+func TestTakeOrphans(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 1)
+	parent := chain.GetLastBlock()
+	a, b := newTestBlock(t, parent, "a"), newTestBlock(t, parent, "b")
+	child := newTestBlock(t, a, "")
+	addOrphan(a, "peer-a")
+	addOrphan(b, "peer-b")
+	addOrphan(child, "peer-a")
+
+	taken := takeOrphans(parent.Hash)
+	if len(taken) != 2 || taken[0].block != a || taken[1].block != b || taken[0].from != "peer-a" || taken[1].from != "peer-b" {
+		t.Fatalf("took %v", taken)
+	}
+	if hasOrphan(a) || hasOrphan(b) || !hasOrphan(child) {
+		t.Fatal("taken orphans still pooled or child removed")
+	}
+}
+
+// newForkBranch는 chain의 height 블록에서 갈라지는 n개 블록의 갈래를 만듭니다.
+func newForkBranch(t *testing.T, chain *blockchain.BlockChain, height int64, n int) []*blockchain.Block {
+	t.Helper()
+	parent, err := chain.GetBlockByHeight(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var branch []*blockchain.Block
+	for i := 0; i < n; i++ {
+		parent = newTestBlock(t, parent, "fork")
+		branch = append(branch, parent)
+	}
+	return branch
+}
+
+func TestOrphanBranch(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 1)
+	long := newForkBranch(t, chain, 1, 3)
+	short := newTestBlock(t, long[0], "short")
+	for _, b := range append(long[1:], short) {
+		addOrphan(b, "peer")
+	}
+
+	branch := orphanBranch(long[0].Hash)
+	if len(branch) != 2 || branch[0] != long[1] || branch[1] != long[2] {
+		t.Fatalf("branch %v, want the two blocks above %d", branch, long[0].Height)
+	}
+	if branch := orphanBranch(long[2].Hash); len(branch) != 0 {
+		t.Fatalf("branch above tip %v", branch)
+	}
+}
+
+// 검증된 갈래가 더 길면 갈라진 높이로 되돌린 뒤 추가
+func TestConnectBlockSwitchesBranch(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 10)
+	branch := newForkBranch(t, chain, 5, 7)
+	for _, b := range branch[1:] {
+		addOrphan(b, "peer")
+	}
+
+	added, err := connectBlock(chain, branch[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 검증한 갈래를 모두 추가하고 풀에서 지움
+	if len(added) != len(branch) {
+		t.Fatalf("added %d blocks, want %d", len(added), len(branch))
+	}
+	if tip := chain.GetLastBlock(); !bytes.Equal(tip.Hash, branch[6].Hash) {
+		t.Fatalf("tip at height %d, want branch tip %d", tip.Height, branch[6].Height)
+	}
+	for _, b := range branch[1:] {
+		if hasOrphan(b) || !forgotten(b) {
+			t.Fatalf("orphan %d left in the pool", b.Height)
+		}
+	}
+	// 버린 이전 블록은 되돌린 블록 보관소에 남기지 않음
+	if rewound, _ := chain.RewoundCount(); rewound != 0 {
+		t.Fatalf("%d rewound blocks left after the switch", rewound)
+	}
+	if _, err := connectBlock(chain, branch[0]); !errors.Is(err, blockchain.ErrKnownBlock) {
+		t.Fatalf("connect known block: %v", err)
+	}
+}
+
+// 갈래 중간에 잘못된 블록이 있어도 그 앞까지가 더 길면 그 앞까지 추가
+func TestConnectBlockImportsVerifiedPrefix(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 10)
+	branch := newForkBranch(t, chain, 5, 8)
+	branch[7].Nonce = blockchain.HexBytes("bad")
+	for _, b := range branch[1:] {
+		addOrphan(b, "peer")
+	}
+
+	added, err := connectBlock(chain, branch[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 7 || !bytes.Equal(chain.GetLastBlock().Hash, branch[6].Hash) {
+		t.Fatalf("added %d blocks, tip at height %d", len(added), chain.GetBestHeight())
+	}
+	if !hasOrphan(branch[7]) {
+		t.Fatal("invalid orphan removed with the added branch")
+	}
+}
+
+// failingStore는 fail이 true를 반환하는 차례의 배치 기록을 실패시킵니다.
+type failingStore struct {
+	storage.Store
+	writes int
+	fail   func(write int) bool
+}
+
+type failingBatch struct {
+	storage.Batch
+	store *failingStore
+}
+
+func (s *failingStore) NewBatch() storage.Batch {
+	return &failingBatch{Batch: s.Store.NewBatch(), store: s}
+}
+
+func (b *failingBatch) Write() error {
+	b.store.writes++
+	if b.store.fail != nil && b.store.fail(b.store.writes) {
+		return errors.New("disk full")
+	}
+	return b.Batch.Write()
+}
+
+// 되돌린 뒤 갈래를 추가하지 못하면 이전 블록을 다시 추가함
+func TestConnectBlockRestoresChain(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 10)
+	tip := chain.GetLastBlock()
+	branch := newForkBranch(t, chain, 5, 7)
+	for _, b := range branch[1:] {
+		addOrphan(b, "peer")
+	}
+
+	// 첫 기록은 되돌리기, 두 번째는 갈래의 첫 블록
+	chain.Database = &failingStore{Store: chain.Database, fail: func(write int) bool { return write == 2 }}
+	if _, err := connectBlock(chain, branch[0]); err == nil {
+		t.Fatal("branch connected on a failing store")
+	}
+	if got := chain.GetLastBlock(); !bytes.Equal(got.Hash, tip.Hash) {
+		t.Fatalf("tip at height %d, want the old tip %d", got.Height, tip.Height)
+	}
+	if rewound, _ := chain.RewoundCount(); rewound != 0 {
+		t.Fatalf("%d rewound blocks left after restoring", rewound)
+	}
+	for _, b := range branch[1:] {
+		if !hasOrphan(b) {
+			t.Fatalf("orphan %d removed although the branch was not added", b.Height)
+		}
+	}
+}
+
+// 갈래에 잘못된 블록이 있으면 그 앞까지만 길이로 치고, 현재 체인보다 짧으면 되돌리지 않음
+func TestConnectBlockKeepsChainForInvalidBranch(t *testing.T) {
+	resetOrphans(t)
+	chain := newTestChain(t, 10)
+	tip := chain.GetLastBlock()
+	branch := newForkBranch(t, chain, 5, 7)
+	branch[2].Nonce = blockchain.HexBytes("bad")
+	for _, b := range branch[1:] {
+		addOrphan(b, "peer")
+	}
+
+	if _, err := connectBlock(chain, branch[0]); !errors.Is(err, blockchain.ErrInvalidHeight) {
+		t.Fatalf("connect block: %v", err)
+	}
+	if got := chain.GetLastBlock(); !bytes.Equal(got.Hash, tip.Hash) {
+		t.Fatalf("tip moved to height %d", got.Height)
+	}
+	if rewound, _ := chain.RewoundCount(); rewound != 0 {
+		t.Fatalf("rewound %d blocks", rewound)
+	}
+
+	// 갈라지는 블록 자체가 잘못되면 그 오류를 반환
+	bad := newForkBranch(t, chain, 5, 1)[0]
+	bad.Difficulty.SetInt64(2)
+	if _, err := connectBlock(chain, bad); !errors.Is(err, blockchain.ErrInvalidDifficulty) {
+		t.Fatalf("connect invalid fork block: %v", err)
+	}
+	if got := chain.GetLastBlock(); !bytes.Equal(got.Hash, tip.Hash) {
+		t.Fatalf("tip moved to height %d", got.Height)
+	}
+}
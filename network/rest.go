@@ -0,0 +1,309 @@
+package network
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// REST API 페이지 크기 제한
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// 채굴자별 블록 조회 시 한 요청에서 살펴보는 최대 블록 수
+	maxMinerScan = 10000
+
+	defaultDifficultyWindow = 100
+	maxDifficultyWindow     = 1000
+)
+
+// blockPageRes는 블록 목록 응답입니다. Next는 다음 페이지의 시작 높이이며 마지막 페이지면 생략됩니다.
+type blockPageRes struct {
+	Blocks []*blockchain.Block `json:"blocks"`
+	Next   *int64              `json:"next,omitempty"`
+}
+
+type difficultyStatsRes struct {
+	From             int64    `json:"from"`
+	To               int64    `json:"to"`
+	Window           int      `json:"window"`
+	Current          *big.Int `json:"current"`
+	Next             *big.Int `json:"next"`
+	Min              *big.Int `json:"min"`
+	Max              *big.Int `json:"max"`
+	Average          *big.Int `json:"average"`
+	AverageBlockTime float64  `json:"averageBlockTime"`
+}
+
+type restError struct {
+	Error string `json:"error"`
+}
+
+// restServer는 블록 탐색기용 읽기 전용 REST API입니다.
+type restServer struct {
+	rpc *RPCServer
+}
+
+func registerREST(mux *http.ServeMux, r *RPCServer) {
+	s := &restServer{rpc: r}
+	mux.HandleFunc("GET /blocks", s.blocks)
+	mux.HandleFunc("GET /blocks/{height}", s.blockByHeight)
+	mux.HandleFunc("GET /blocks/hash/{hash}", s.blockByHash)
+	mux.HandleFunc("GET /miners/{address}/blocks", s.minerBlocks)
+	mux.HandleFunc("GET /stats/difficulty", s.difficultyStats)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		rpcLog.Debug("could not write REST response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, &restError{Error: fmt.Sprintf(format, args...)})
+}
+
+// queryInt는 쿼리 파라미터를 정수로 읽습니다. 값이 없으면 def를 반환합니다.
+func queryInt(req *http.Request, name string, def int64) (int64, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return n, nil
+}
+
+func pageLimit(req *http.Request) (int, error) {
+	limit, err := queryInt(req, "limit", defaultPageSize)
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return int(limit), nil
+}
+
+// checkPruned는 from부터 시작하는 블록 목록에 본문을 지운 블록이 있으면 ErrBlockPruned를,
+// 스냅샷 복원 뒤 아직 받지 못한 블록이 있으면 ErrBodyNotSynced를 반환합니다.
+func (r *RPCServer) checkPruned(from int64) error {
+	if pruned := r.chain.PrunedHeight(); from > 0 && from < pruned {
+		return fmt.Errorf("%w: height %d, this node keeps blocks from height %d", blockchain.ErrBlockPruned, from, pruned)
+	}
+	if backfill := r.chain.BackfillHeight(); from > 0 && from < backfill {
+		return fmt.Errorf("%w: height %d, this node has blocks from height %d", blockchain.ErrBodyNotSynced, from, backfill)
+	}
+	return nil
+}
+
+// bodyErrorStatus는 본문이 없는 블록 오류의 HTTP 상태 코드를 반환합니다. 해당하지 않으면 0입니다.
+func bodyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, blockchain.ErrBlockPruned):
+		return http.StatusGone
+	case errors.Is(err, blockchain.ErrBodyNotSynced):
+		return http.StatusServiceUnavailable
+	}
+	return 0
+}
+
+// blockPage는 from부터 to까지의 블록 중 한 페이지를 반환합니다. next는 다음 페이지의 시작 높이이며 없으면 -1입니다.
+func (r *RPCServer) blockPage(from, to int64, limit int) ([]*blockchain.Block, int64) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	} else if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	blocks := r.chain.GetBlockList(from, to, limit)
+	next := int64(-1)
+	if len(blocks) == limit {
+		if last := blocks[len(blocks)-1].Height; last < to && last < r.chain.GetBestHeight() {
+			next = last + 1
+		}
+	}
+	return blocks, next
+}
+
+func newBlockPageRes(blocks []*blockchain.Block, next int64) *blockPageRes {
+	res := &blockPageRes{Blocks: blocks}
+	if res.Blocks == nil {
+		res.Blocks = []*blockchain.Block{}
+	}
+	if next >= 0 {
+		res.Next = &next
+	}
+	return res
+}
+
+// GET /blocks?from=&to=&limit=
+// from을 생략하면 최신 limit개의 블록을 반환합니다.
+func (s *restServer) blocks(w http.ResponseWriter, req *http.Request) {
+	limit, err := pageLimit(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	best := s.rpc.chain.GetBestHeight()
+	to, err := queryInt(req, "to", best)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	from, err := queryInt(req, "from", max(to-int64(limit)+1, 0))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if from > to {
+		writeError(w, http.StatusBadRequest, "from %d is greater than to %d", from, to)
+		return
+	}
+	if err := s.rpc.checkPruned(from); err != nil {
+		writeError(w, bodyErrorStatus(err), "%v", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newBlockPageRes(s.rpc.blockPage(from, to, limit)))
+}
+
+// GET /blocks/{height}
+func (s *restServer) blockByHeight(w http.ResponseWriter, req *http.Request) {
+	value := req.PathValue("height")
+	height, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || height < 0 {
+		writeError(w, http.StatusBadRequest, "invalid height: %q", value)
+		return
+	}
+
+	block, err := s.rpc.chain.GetBlockByHeight(height)
+	if status := bodyErrorStatus(err); status != 0 {
+		writeError(w, status, "%v", err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusNotFound, "block %d not found", height)
+		return
+	}
+	writeJSON(w, http.StatusOK, block)
+}
+
+// GET /blocks/hash/{hash}
+func (s *restServer) blockByHash(w http.ResponseWriter, req *http.Request) {
+	value := strings.TrimPrefix(req.PathValue("hash"), "0x")
+	hash, err := hex.DecodeString(value)
+	if err != nil || len(hash) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid block hash: %q", value)
+		return
+	}
+
+	block, err := s.rpc.chain.GetBlock(hash)
+	if errors.Is(err, blockchain.ErrBlockNotFound) {
+		writeError(w, http.StatusNotFound, "block %x not found", hash)
+		return
+	} else if status := bodyErrorStatus(err); status != 0 {
+		writeError(w, status, "%v", err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &block)
+}
+
+// GET /miners/{address}/blocks?before=&limit=
+// 주소는 블록 JSON의 Miner 필드와 같은 16진수 또는 원래 주소 문자열을 받습니다.
+func (s *restServer) minerBlocks(w http.ResponseWriter, req *http.Request) {
+	limit, err := pageLimit(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	before, err := queryInt(req, "before", s.rpc.chain.GetBestHeight())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	blocks, next := s.rpc.chain.GetBlocksByMiner(minerAddress(req.PathValue("address")), before, limit, maxMinerScan)
+	writeJSON(w, http.StatusOK, newBlockPageRes(blocks, next))
+}
+
+// minerAddress는 경로의 주소를 블록에 저장된 채굴자 값으로 바꿉니다.
+// 블록에는 주소 문자열("0x...")이 그대로 저장되므로, 16진수를 풀어 주소 문자열이 나오면
+// 블록 JSON에 보이는 형태로 보고 그 값을, 아니면 원래 주소 문자열을 사용합니다.
+func minerAddress(address string) []byte {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err == nil && strings.HasPrefix(string(decoded), "0x") {
+		return decoded
+	}
+	return []byte(address)
+}
+
+// GET /stats/difficulty?window=
+// 최근 window개 블록의 난이도와 평균 블록 생성 시간(초)을 반환합니다.
+func (s *restServer) difficultyStats(w http.ResponseWriter, req *http.Request) {
+	window, err := queryInt(req, "window", defaultDifficultyWindow)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if window == 0 || window > maxDifficultyWindow {
+		writeError(w, http.StatusBadRequest, "window must be between 1 and %d", maxDifficultyWindow)
+		return
+	}
+
+	chain := s.rpc.chain
+	to := chain.GetBestHeight()
+	// pruned 노드나 본문을 받는 중인 노드는 본문이 있는 블록만 사용
+	from := max(to-window+1, chain.LowestBodyHeight(), 0)
+	blocks := chain.GetBlockList(from, to, int(window))
+	if len(blocks) == 0 {
+		writeError(w, http.StatusNotFound, "no blocks found")
+		return
+	}
+
+	next, err := chain.Difficulty(to + 1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := &difficultyStatsRes{
+		From:    blocks[0].Height,
+		To:      blocks[len(blocks)-1].Height,
+		Window:  len(blocks),
+		Current: blocks[len(blocks)-1].Difficulty,
+		Next:    next,
+		Average: new(big.Int),
+	}
+	for _, block := range blocks {
+		if res.Min == nil || block.Difficulty.Cmp(res.Min) < 0 {
+			res.Min = block.Difficulty
+		}
+		if res.Max == nil || block.Difficulty.Cmp(res.Max) > 0 {
+			res.Max = block.Difficulty
+		}
+		res.Average.Add(res.Average, block.Difficulty)
+	}
+	res.Average.Div(res.Average, big.NewInt(int64(len(blocks))))
+
+	if len(blocks) > 1 {
+		first, last := blocks[0], blocks[len(blocks)-1]
+		res.AverageBlockTime = float64(last.Timestamp-first.Timestamp) / float64(len(blocks)-1)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,135 @@
+package network
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+const otherMiner = "0x1111111111111111111111111111111111111111"
+
+// restGet은 REST 요청을 처리하고 상태 코드와 본문을 반환합니다.
+func restGet(t *testing.T, chain *blockchain.BlockChain, path string) (int, []byte) {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerREST(mux, &RPCServer{chain: chain})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.Bytes()
+}
+
+// restPage는 블록 목록 응답을 읽어 블록 높이들과 next를 반환합니다. next가 없으면 -1입니다.
+func restPage(t *testing.T, chain *blockchain.BlockChain, path string) ([]int64, int64) {
+	t.Helper()
+	code, body := restGet(t, chain, path)
+	if code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, body %s", path, code, body)
+	}
+	var res blockPageRes
+	if err := json.Unmarshal(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	heights := []int64{}
+	for _, block := range res.Blocks {
+		heights = append(heights, block.Height)
+	}
+	next := int64(-1)
+	if res.Next != nil {
+		next = *res.Next
+	}
+	return heights, next
+}
+
+func equalHeights(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newMinerTestChain은 짝수 높이는 testMiner, 홀수 높이는 otherMiner가 채굴한 blocks개의 블록을 가진 체인을 만듭니다.
+func newMinerTestChain(t *testing.T, blocks int) *blockchain.BlockChain {
+	t.Helper()
+	chain := newTestChain(t, 0)
+	for i := 1; i <= blocks; i++ {
+		block := newTestBlock(t, chain.GetLastBlock(), "")
+		if i%2 == 1 {
+			block.Miner = blockchain.HexBytes(otherMiner)
+			sealTestBlock(t, block)
+		}
+		if err := chain.ImportBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return chain
+}
+
+func TestRESTBlocksPaging(t *testing.T) {
+	chain := newTestChain(t, 7)
+
+	tests := []struct {
+		path    string
+		heights []int64
+		next    int64
+	}{
+		{"/blocks?from=0&limit=3", []int64{0, 1, 2}, 3},
+		{"/blocks?from=3&limit=3", []int64{3, 4, 5}, 6},
+		{"/blocks?from=6&limit=3", []int64{6, 7}, -1},
+		{"/blocks?from=2&to=4&limit=3", []int64{2, 3, 4}, -1},
+		{"/blocks?limit=2", []int64{6, 7}, -1},
+		{"/blocks?to=3&limit=2", []int64{2, 3}, -1},
+	}
+	for _, tt := range tests {
+		heights, next := restPage(t, chain, tt.path)
+		if !equalHeights(heights, tt.heights) || next != tt.next {
+			t.Errorf("GET %s: heights %v next %d, want %v next %d", tt.path, heights, next, tt.heights, tt.next)
+		}
+	}
+
+	for _, path := range []string{"/blocks?limit=0", "/blocks?limit=101", "/blocks?from=5&to=2", "/blocks?from=x"} {
+		if code, body := restGet(t, chain, path); code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, body %s", path, code, body)
+		}
+	}
+}
+
+func TestRESTGetBlockListDefaultLimit(t *testing.T) {
+	r := &RPCServer{chain: newTestChain(t, defaultPageSize+5)}
+
+	var res GetBlockListRes
+	if err := r.GetBlockList(&GetBlockListArgs{}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Block) != defaultPageSize || res.Next != defaultPageSize {
+		t.Fatalf("got %d blocks, next %d", len(res.Block), res.Next)
+	}
+}
+
+func TestRESTMinerBlocks(t *testing.T) {
+	chain := newMinerTestChain(t, 6)
+
+	// 주소 문자열과 블록 JSON에 보이는 16진수 형태 모두 같은 블록을 찾아야 함
+	for _, address := range []string{otherMiner, hex.EncodeToString([]byte(otherMiner)), "0x" + hex.EncodeToString([]byte(otherMiner))} {
+		heights, next := restPage(t, chain, "/miners/"+address+"/blocks?limit=2")
+		if !equalHeights(heights, []int64{5, 3}) || next != 2 {
+			t.Errorf("address %s: heights %v next %d", address, heights, next)
+		}
+	}
+
+	heights, next := restPage(t, chain, "/miners/"+otherMiner+"/blocks?before=2&limit=2")
+	if !equalHeights(heights, []int64{1}) || next != -1 {
+		t.Errorf("second page: heights %v next %d", heights, next)
+	}
+	if heights, _ := restPage(t, chain, "/miners/"+testMiner+"/blocks?before=5"); !equalHeights(heights, []int64{4, 2}) {
+		t.Errorf("testMiner: heights %v", heights)
+	}
+}
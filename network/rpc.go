@@ -3,15 +3,24 @@ package network
 import (
 	"encoding/hex"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"net/rpc"
+	"strconv"
+	"strings"
 
 	"github.com/Kim-DaeHan/mining-chain/blockchain"
 	"github.com/Kim-DaeHan/mining-chain/config"
+	"github.com/Kim-DaeHan/mining-chain/logger"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
+	"github.com/Kim-DaeHan/mining-chain/storage"
 )
 
+var rpcLog = logger.New(logger.RPC)
+
+// GetHeadersRange 한 번에 반환하는 최대 헤더 수
+const maxHeadersRange = 1000
+
 type RPCServer struct {
 	Port  int
 	chain *blockchain.BlockChain
@@ -34,29 +43,68 @@ func (r *RPCServer) GetBestHeight(req *GetBestHeightArgs, res *GetBestHeightRes)
 }
 
 func (r *RPCServer) GetLastBlockHash(req *GetLastBlockHashArgs, res *GetLastBlockHashRes) error {
-	blockHash := r.chain.GetLastBlockHash()
+	blockHash, err := r.chain.GetLastBlockHash()
+	if err != nil {
+		return err
+	}
 	res.Hash = fmt.Sprintf("%x", blockHash)
 	return nil
 }
 
 func (r *RPCServer) GetBlock(req *GetBlockArgs, res *GetBlockRes) error {
 	if req.Hash == "" {
-		log.Panic("-hash option")
+		return fmt.Errorf("block hash is required")
 	}
 
-	hashBytes, err := hex.DecodeString(req.Hash)
-
+	hashBytes, err := hex.DecodeString(strings.TrimPrefix(req.Hash, "0x"))
 	if err != nil {
-		log.Panic(err)
+		return fmt.Errorf("invalid block hash %q", req.Hash)
 	}
 
 	block, err := r.chain.GetBlock(hashBytes)
-
+	if err != nil {
+		return err
+	}
 	res.Block = block
 
+	return nil
+}
+
+// 높이, "latest" 또는 "earliest"로 블록을 조회하는 RPC 메서드
+func (r *RPCServer) GetBlockByNumber(req *GetBlockByNumberArgs, res *GetBlockByNumberRes) error {
+	height, err := r.resolveBlockNumber(req.Number)
+	if err != nil {
+		return err
+	}
+
+	block, err := r.chain.GetBlockByHeight(height)
 	if err != nil {
-		fmt.Printf("여기구나!")
-		log.Panic(err)
+		return err
+	}
+	res.Block = *block
+
+	return nil
+}
+
+// from부터 최대 count개(서버 최대 maxHeadersRange개)의 블록 헤더를 조회하는 RPC 메서드
+func (r *RPCServer) GetHeadersRange(req *GetHeadersRangeArgs, res *GetHeadersRangeRes) error {
+	if req.From < 0 || req.Count <= 0 {
+		return fmt.Errorf("invalid header range: from %d count %d", req.From, req.Count)
+	}
+	count := min(req.Count, maxHeadersRange)
+
+	best := r.chain.GetBestHeight()
+	if req.From > best {
+		return fmt.Errorf("%w: height %d", blockchain.ErrBlockNotFound, req.From)
+	}
+
+	// 헤더는 pruned 노드에서도 남아 있으므로 블록 대신 헤더를 읽음
+	for height := req.From; height <= min(req.From+int64(count)-1, best); height++ {
+		header, err := r.chain.GetHeaderByHeight(height)
+		if err != nil {
+			break
+		}
+		res.Headers = append(res.Headers, *header)
 	}
 
 	return nil
@@ -74,28 +122,53 @@ func (r *RPCServer) GetBlockHashes(req *GetBlockHashesArgs, res *GetBlockHashesR
 }
 
 func (r *RPCServer) GetBlockList(req *GetBlockListArgs, res *GetBlockListRes) error {
+	to := req.To
+	if to <= 0 {
+		to = r.chain.GetBestHeight()
+	}
+	if req.From < 0 || req.From > to {
+		return fmt.Errorf("invalid block range: from %d to %d", req.From, to)
+	}
+	if err := r.checkPruned(req.From); err != nil {
+		return err
+	}
 
-	blockList := r.chain.GetBlockList()
-
-	for _, block := range blockList {
-		fmt.Printf("block height GetBlockList :: >> %d\n", block.Height)
-		res.Block = append(res.Block, block)
+	blocks, next := r.blockPage(req.From, to, req.Limit)
+	for _, block := range blocks {
+		res.Block = append(res.Block, *block)
 	}
+	res.Next = next
 
 	return nil
 }
 
 // 작업증명 기반 블록체인에서 작업의 난이도와 작업을 완료하기 위한 해시값을 제공하는 JSON-RPC 메서드(마이너가 다음 블록을 채굴하기 위해 필요한 정보 반환)
 func (r *RPCServer) GetWork(req *GetWorkArgs, res *GetWorkRes) error {
-	// 현재 작업해야 하는 블록의 상태를 나타내는 해시(마이너가 이 값을 대상으로 작업 수행)
-	res.CurrentPowHash = "0x5eab5d6d3e47adf8d0d4ae9a4b96be1f24acb8b3f1b3cfa7cde7d77eae75a4a8"
-	// 난이도를 설정하는데 필요한 시드 값
-	res.SeedHash = "0x7e3f9c19b0d3ea84363e7fd32158ef87688b1bb1a5ed0a5d003a3f54e9a2a5a3"
+	powHash, data, block, err := newWork(r.chain)
+	if err != nil {
+		return err
+	}
+	hashLimit, err := blockchain.HashLimit(block)
+	if err != nil {
+		return err
+	}
+
+	// 현재 작업을 식별하는 해시(SubmitWork에 다시 전달)
+	res.CurrentPowHash = "0x" + powHash
+	// nonce 앞에 붙여 해시할 작업 데이터(sha256(data + nonce 16진수))
+	res.SeedHash = "0x" + hex.EncodeToString([]byte(data))
 	// 네트워크 난이도에 따라 작업이 완료되기 위해 충족해야 할 해시 목표값
-	res.TargetThreshold = "0x0000000000000000000000000000000000000000000000000000000000001abc"
+	res.TargetThreshold = "0x" + hashLimit
 	return nil
 }
 
+// 마이너가 찾은 nonce를 제출하는 JSON-RPC 메서드
+func (r *RPCServer) SubmitWork(req *SubmitWorkArgs, res *SubmitWorkRes) error {
+	accepted, err := submitWork(r.chain, req.PowHash, req.Nonce)
+	res.Accepted = accepted
+	return err
+}
+
 // 전체 네트워크의 평균적인 해시레이트(초당 해시 계산 속도)를 조회하는 JSON-RPC 메서드
 func (r *RPCServer) GetHashRate(req *GetHashRateArgs, res *GetHashRateRes) error {
 	// 초당 1000개의 해시를 계산
@@ -117,14 +190,14 @@ func (r *RPCServer) Mining(req *MiningArgs, res *MiningRes) error {
 
 // peer 추가하는 JSON-RPC 메서드
 func (r *RPCServer) AddPeer(req *AddPeerArgs, res *AddPeerRes) error {
-	fmt.Println("peer address: ", req.PeerAddress)
+	rpcLog.Info("AddPeer", "address", req.PeerAddress)
 	res.Success = true
 	return nil
 }
 
 // datadir를 조회하는 JSON-RPC 메서드
 func (r *RPCServer) GetDataDir(req *GetDataDirArgs, res *GetDataDirRes) error {
-	res.DataDirectory = "/tmp/blocks_1001"
+	res.DataDirectory = r.chain.Path
 	return nil
 }
 
@@ -162,54 +235,113 @@ func (r *RPCServer) GetPeer(req *GetPeerArgs, res *GetPeerRes) error {
 
 // peer 제거하는 JSON-RPC 메서드
 func (r *RPCServer) RemovePeer(req *RemovePeerArgs, res *RemovePeerRes) error {
-	fmt.Println("peer address: ", req.PeerAddress)
+	rpcLog.Info("RemovePeer", "address", req.PeerAddress)
 	res.Success = true
 	return nil
 }
 
 // xp 보상 얻을 주소 설정하는 JSON-RPC 메서드
 func (r *RPCServer) SetXpbase(req *SetXpbaseArgs, res *SetXpbaseRes) error {
-	fmt.Println("xpbase address: ", req.Address)
+	rpcLog.Info("SetXpbase", "address", req.Address)
 	res.Success = true
 	return nil
 }
 
 // 현재 노드의 해시레이트(초당 해시 계산 속도)를 조회하는 JSON-RPC 메서드
 func (r *RPCServer) GetNodeHashRate(req *GetNodeHashRateArgs, res *GetNodeHashRateRes) error {
-	// 초당 1000개의 해시를 계산
-	res.Hashrate = 1000
+	// 마지막 작업증명에서 측정한 값
+	res.Hashrate = int(metrics.LocalHashRate())
 	return nil
 }
 
 // 현재 노드의 해시레이트(초당 해시 계산 속도)를 조회하는 JSON-RPC 메서드
 func (r *RPCServer) GetDifficulty(req *GetDifficultyArgs, res *GetDifficultyRes) error {
 	// 초당 1000개의 해시를 계산
-	diff := r.chain.Difficulty(req.Height)
+	diff, err := r.chain.Difficulty(req.Height)
+	if err != nil {
+		return err
+	}
 	res.Difficulty = diff
 
 	return nil
 }
 
-func StartRPCServer(chain *blockchain.BlockChain, rpcErrorChan chan error, node *blockchain.Node) {
-	rpcServer := &RPCServer{config.GlobalConfig.RPCPort, chain, node}
+// tip을 Height로 되돌리고 뺀 블록을 보관하는 관리용 RPC 메서드
+func (r *RPCServer) Rewind(req *RewindArgs, res *RewindRes) error {
+	r.chain.Mu.Lock()
+	defer r.chain.Mu.Unlock()
 
-	err := rpc.Register(rpcServer)
+	removed, err := r.chain.Rewind(req.Height)
 	if err != nil {
+		return err
+	}
+	res.Removed = removed
+	res.Hash = fmt.Sprintf("%x", r.chain.LastHash)
+	return nil
+}
+
+// 되돌린 블록 중 tip을 잇는 블록을 다시 추가하는 관리용 RPC 메서드
+func (r *RPCServer) ReapplyRewound(req *ReapplyRewoundArgs, res *ReapplyRewoundRes) error {
+	r.chain.Mu.Lock()
+	defer r.chain.Mu.Unlock()
+
+	applied, err := r.chain.ReapplyRewound()
+	res.Applied = applied
+	res.Height = r.chain.GetBestHeight()
+	return err
+}
+
+func StartRPCServer(chain *blockchain.BlockChain, rpcErrorChan chan error, node *blockchain.Node) {
+	cfg := config.GlobalConfig
+	rpcServer := &RPCServer{cfg.RPCPort, chain, node}
+
+	if err := rpc.Register(rpcServer); err != nil {
+		rpcErrorChan <- err
+		return
+	}
+	if err := rpc.RegisterName("RPCAuth", RPCAuth{}); err != nil {
 		rpcErrorChan <- err
 		return
 	}
 
-	rpchost := fmt.Sprintf("localhost:%d", rpcServer.Port)
+	rpchost := net.JoinHostPort(cfg.RPCAddr, strconv.Itoa(rpcServer.Port))
 
 	rpcListener, err := net.Listen("tcp", rpchost)
 	if err != nil {
 		rpcErrorChan <- err
+		return
 	}
 
-	rpc.HandleHTTP()
-	log.Printf("Serving RPC server on %s", rpchost)
+	mux := http.NewServeMux()
+	handler := newRPCHandler(mux, cfg)
+
+	mux.HandleFunc(rpc.DefaultRPCPath, serveGobRPC)
+	jsonrpc := newJSONRPCServer(rpcServer)
+	mux.Handle("/", jsonrpc)
+	mux.Handle("/ws", newWSServer(jsonrpc, chain, handler.checkOrigin))
+	registerREST(mux, rpcServer)
+
+	if cfg.Metrics {
+		// LevelDB 통계는 leveldb 백엔드에서만 제공
+		if db, ok := chain.Database.(*storage.LevelDB); ok {
+			if err := metrics.RegisterLevelDB(db.DB()); err != nil {
+				rpcLog.Warn("could not register leveldb metrics", "err", err)
+			}
+		}
+		mux.Handle("/metrics", metrics.Handler())
+		rpcLog.Info("Serving metrics", "url", rpcURL(cfg)+"/metrics")
+	}
 
-	err = http.Serve(rpcListener, nil)
+	if !handler.auth.enabled() {
+		rpcLog.Info("RPC authentication disabled, admin and miner methods are limited to local connections")
+	}
+	rpcLog.Info("Serving RPC server", "url", rpcURL(cfg))
+
+	if cfg.RPCTLSCert != "" {
+		err = http.ServeTLS(rpcListener, handler, cfg.RPCTLSCert, cfg.RPCTLSKey)
+	} else {
+		err = http.Serve(rpcListener, handler)
+	}
 	if err != nil {
 		rpcErrorChan <- err
 	}
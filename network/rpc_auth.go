@@ -0,0 +1,326 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+)
+
+// RPC 메서드 그룹
+const (
+	groupPublic = "public"
+	groupAdmin  = "admin"
+	groupMiner  = "miner"
+)
+
+// JSON-RPC 네임스페이스별 그룹
+var namespaceGroups = map[string]string{
+	"chain": groupPublic,
+	"miner": groupMiner,
+	"admin": groupAdmin,
+}
+
+// gob RPC 메서드별 그룹. 목록에 없는 메서드는 admin 그룹으로 취급
+var gobMethodGroups = map[string]string{
+	"RPCServer.GetBlockNumber":   groupPublic,
+	"RPCServer.GetBestHeight":    groupPublic,
+	"RPCServer.GetLastBlockHash": groupPublic,
+	"RPCServer.GetBlock":         groupPublic,
+	"RPCServer.GetBlockByNumber": groupPublic,
+	"RPCServer.GetHeadersRange":  groupPublic,
+	"RPCServer.GetBlockHashes":   groupPublic,
+	"RPCServer.GetBlockList":     groupPublic,
+	"RPCServer.GetDifficulty":    groupPublic,
+	"RPCServer.GetWork":          groupMiner,
+	"RPCServer.SubmitWork":       groupMiner,
+	"RPCServer.GetHashRate":      groupMiner,
+	"RPCServer.GetNodeHashRate":  groupMiner,
+	"RPCServer.Coinbase":         groupMiner,
+	"RPCServer.Mining":           groupMiner,
+	"RPCServer.SetXpbase":        groupMiner,
+	"RPCAuth.Denied":             groupPublic,
+}
+
+// 구독 종류별 그룹
+var subscriptionGroups = map[string]string{
+	subNewHeads:   groupPublic,
+	subReorgs:     groupPublic,
+	subSyncing:    groupPublic,
+	subPeerEvents: groupAdmin,
+}
+
+// JWT iat 허용 오차
+const jwtClockSkew = 60 * time.Second
+
+func methodGroup(method string) string {
+	namespace, _, _ := strings.Cut(method, "_")
+	if group, ok := namespaceGroups[namespace]; ok {
+		return group
+	}
+	return groupAdmin
+}
+
+func gobMethodGroup(method string) string {
+	if group, ok := gobMethodGroups[method]; ok {
+		return group
+	}
+	return groupAdmin
+}
+
+// rpcGrants는 요청에 허용된 메서드 그룹입니다. admin은 모든 그룹을 허용합니다.
+type rpcGrants map[string]bool
+
+func (g rpcGrants) allows(group string) bool {
+	return group == groupPublic || g[groupAdmin] || g[group]
+}
+
+type grantsKey struct{}
+
+func withGrants(ctx context.Context, grants rpcGrants) context.Context {
+	return context.WithValue(ctx, grantsKey{}, grants)
+}
+
+func grantsFrom(ctx context.Context) rpcGrants {
+	grants, _ := ctx.Value(grantsKey{}).(rpcGrants)
+	return grants
+}
+
+func unauthorized(method, group string) error {
+	return &jsonrpcError{Code: errCodeUnauthorized, Message: fmt.Sprintf("unauthorized: %s requires %s access", method, group)}
+}
+
+// rpcAuth는 Authorization 헤더의 bearer 토큰 또는 HS256 JWT로 요청의 그룹을 결정합니다.
+type rpcAuth struct {
+	tokens    map[string]string
+	jwtSecret []byte
+}
+
+func newRPCAuth(cfg config.Config) *rpcAuth {
+	a := &rpcAuth{tokens: cfg.RPCTokens}
+	if cfg.RPCJWTSecret != "" {
+		a.jwtSecret = []byte(cfg.RPCJWTSecret)
+	}
+	return a
+}
+
+func (a *rpcAuth) enabled() bool {
+	return len(a.tokens) > 0 || a.jwtSecret != nil
+}
+
+// grants는 요청에 허용된 그룹을 반환합니다.
+// 인증이 설정되지 않은 경우 로컬 접속에만 모든 그룹을 허용합니다.
+func (a *rpcAuth) grants(req *http.Request) (rpcGrants, error) {
+	grants := rpcGrants{}
+
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		if !a.enabled() && isLoopback(req.RemoteAddr) {
+			grants[groupAdmin] = true
+		}
+		return grants, nil
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+
+	for group, expected := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			grants[group] = true
+			return grants, nil
+		}
+	}
+
+	if a.jwtSecret != nil && strings.Count(token, ".") == 2 {
+		groups, err := verifyJWT(token, a.jwtSecret, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			grants[group] = true
+		}
+		return grants, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+type jwtClaims struct {
+	Groups []string `json:"groups"`
+	Exp    int64    `json:"exp,omitempty"`
+	Iat    int64    `json:"iat,omitempty"`
+}
+
+// verifyJWT는 HS256으로 서명된 JWT를 검증하고 groups 클레임을 반환합니다.
+func verifyJWT(token string, secret []byte, now time.Time) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed jwt")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid jwt signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.Exp != 0 && now.Unix() >= claims.Exp {
+		return nil, fmt.Errorf("jwt expired")
+	}
+	if claims.Iat != 0 && time.Unix(claims.Iat, 0).After(now.Add(jwtClockSkew)) {
+		return nil, fmt.Errorf("jwt issued in the future")
+	}
+	if len(claims.Groups) == 0 {
+		return nil, fmt.Errorf("jwt has no groups claim")
+	}
+	return claims.Groups, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed jwt")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed jwt: %v", err)
+	}
+	return nil
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// RPCAuth는 권한이 없는 gob RPC 호출을 받아 오류를 돌려주는 서비스입니다.
+type RPCAuth struct{}
+
+func (RPCAuth) Denied(req *AuthDeniedArgs, res *AuthDeniedRes) error {
+	return unauthorized(req.Method, req.Group)
+}
+
+// serveGobRPC는 rpc.HandleHTTP와 같은 CONNECT 방식으로 gob RPC 연결을 받습니다.
+// 허용되지 않은 메서드 호출은 RPCAuth.Denied로 바꿔 처리합니다.
+func serveGobRPC(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodConnect {
+		http.Error(w, "405 must CONNECT", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		rpcLog.Warn("rpc hijacking failed", "remote", req.RemoteAddr, "err", err)
+		return
+	}
+	io.WriteString(conn, "HTTP/1.0 200 Connected to Go RPC\n\n")
+
+	buf := bufio.NewWriter(conn)
+	rpc.ServeCodec(&authServerCodec{
+		rwc:    conn,
+		dec:    gob.NewDecoder(conn),
+		enc:    gob.NewEncoder(buf),
+		encBuf: buf,
+		grants: grantsFrom(req.Context()),
+	})
+}
+
+// authServerCodec은 net/rpc의 gob 코덱에 메서드 그룹 검사를 더한 것입니다.
+type authServerCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+
+	grants rpcGrants
+	denied *AuthDeniedArgs
+}
+
+func (c *authServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.dec.Decode(r); err != nil {
+		return err
+	}
+
+	c.denied = nil
+	if group := gobMethodGroup(r.ServiceMethod); !c.grants.allows(group) {
+		c.denied = &AuthDeniedArgs{Method: r.ServiceMethod, Group: group}
+		r.ServiceMethod = "RPCAuth.Denied"
+	}
+	return nil
+}
+
+func (c *authServerCodec) ReadRequestBody(body interface{}) error {
+	if c.denied != nil {
+		// 원래 요청 본문은 버리고 Denied 인자를 채움
+		if err := c.dec.DecodeValue(reflect.Value{}); err != nil {
+			return err
+		}
+		if args, ok := body.(*AuthDeniedArgs); ok {
+			*args = *c.denied
+		}
+		return nil
+	}
+	return c.dec.Decode(body)
+}
+
+func (c *authServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *authServerCodec) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
@@ -0,0 +1,145 @@
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+)
+
+var testJWTSecret = []byte(strings.Repeat("s", 32))
+
+// signJWT는 header와 claims로 secret을 키로 한 HS256 JWT를 만듭니다.
+func signJWT(t *testing.T, header, claims any, secret []byte) string {
+	t.Helper()
+	part := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := part(header) + "." + part(claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var hs256 = map[string]string{"alg": "HS256", "typ": "JWT"}
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid := signJWT(t, hs256, jwtClaims{Groups: []string{groupMiner}, Exp: now.Unix() + 60, Iat: now.Unix()}, testJWTSecret)
+	groups, err := verifyJWT(valid, testJWTSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(groups, []string{groupMiner}) {
+		t.Fatalf("groups %v", groups)
+	}
+
+	// 시계 오차 안의 iat는 허용
+	skewed := signJWT(t, hs256, jwtClaims{Groups: []string{groupMiner}, Iat: now.Unix() + 30}, testJWTSecret)
+	if _, err := verifyJWT(skewed, testJWTSecret, now); err != nil {
+		t.Fatalf("iat within clock skew: %v", err)
+	}
+
+	tampered := strings.Split(valid, ".")
+	tampered[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"groups":["admin"]}`))
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"parts", "a.b", "malformed jwt"},
+		{"algorithm", signJWT(t, map[string]string{"alg": "none"}, jwtClaims{Groups: []string{groupAdmin}}, testJWTSecret), "unsupported jwt algorithm"},
+		{"secret", signJWT(t, hs256, jwtClaims{Groups: []string{groupAdmin}}, []byte(strings.Repeat("x", 32))), "invalid jwt signature"},
+		{"tampered", strings.Join(tampered, "."), "invalid jwt signature"},
+		{"expired", signJWT(t, hs256, jwtClaims{Groups: []string{groupAdmin}, Exp: now.Unix()}, testJWTSecret), "jwt expired"},
+		{"future", signJWT(t, hs256, jwtClaims{Groups: []string{groupAdmin}, Iat: now.Add(2 * jwtClockSkew).Unix()}, testJWTSecret), "issued in the future"},
+		{"groups", signJWT(t, hs256, jwtClaims{}, testJWTSecret), "no groups claim"},
+	}
+	for _, tt := range tests {
+		if _, err := verifyJWT(tt.token, testJWTSecret, now); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRPCAuthGrants(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.RPCTokens = map[string]string{groupMiner: "miner-token"}
+	cfg.RPCJWTSecret = string(testJWTSecret)
+	auth := newRPCAuth(cfg)
+	jwt := signJWT(t, hs256, jwtClaims{Groups: []string{groupAdmin}, Exp: time.Now().Unix() + 60}, testJWTSecret)
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		want   rpcGrants
+		fail   bool
+	}{
+		{name: "no token", remote: "127.0.0.1:1", want: rpcGrants{}},
+		{name: "token", remote: "10.0.0.1:1", header: "Bearer miner-token", want: rpcGrants{groupMiner: true}},
+		{name: "jwt", remote: "10.0.0.1:1", header: "Bearer " + jwt, want: rpcGrants{groupAdmin: true}},
+		{name: "wrong token", remote: "10.0.0.1:1", header: "Bearer other", fail: true},
+		{name: "scheme", remote: "10.0.0.1:1", header: "Basic miner-token", fail: true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		grants, err := auth.grants(req)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: accepted", tt.name)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(grants, tt.want) {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, grants, err, tt.want)
+		}
+	}
+
+	// 인증을 설정하지 않으면 로컬 접속에만 모든 그룹을 허용
+	open := newRPCAuth(config.Defaults())
+	for remote, admin := range map[string]bool{"127.0.0.1:1": true, "[::1]:1": true, "10.0.0.1:1": false} {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = remote
+		grants, err := open.grants(req)
+		if err != nil || grants.allows(groupAdmin) != admin {
+			t.Errorf("%s: admin %v, %v; want %v", remote, grants.allows(groupAdmin), err, admin)
+		}
+	}
+}
+
+func TestMethodGroups(t *testing.T) {
+	grants := rpcGrants{groupMiner: true}
+	for method, want := range map[string]bool{
+		"chain_getBlockByNumber": true,
+		"miner_getWork":          true,
+		"admin_peers":            false,
+		"unknown_method":         false,
+	} {
+		if got := grants.allows(methodGroup(method)); got != want {
+			t.Errorf("miner grants allow %s: %v, want %v", method, got, want)
+		}
+	}
+	if gobMethodGroup("RPCServer.Rewind") != groupAdmin || gobMethodGroup("RPCServer.GetWork") != groupMiner {
+		t.Error("unexpected gob method groups")
+	}
+	if !(rpcGrants{groupAdmin: true}).allows(groupMiner) {
+		t.Error("admin grants do not allow miner methods")
+	}
+}
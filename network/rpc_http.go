@@ -0,0 +1,164 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/config"
+)
+
+// 오래 사용하지 않은 IP별 버킷을 정리하는 주기
+const rateLimitCleanupInterval = time.Minute
+
+// rpcHandler는 RPC HTTP 서버의 공통 처리(CORS, IP별 요청 제한, 인증)를 담당합니다.
+type rpcHandler struct {
+	next    http.Handler
+	auth    *rpcAuth
+	origins []string
+	limiter *rateLimiter
+}
+
+func newRPCHandler(next http.Handler, cfg config.Config) *rpcHandler {
+	h := &rpcHandler{
+		next:    next,
+		auth:    newRPCAuth(cfg),
+		origins: cfg.RPCCorsOrigins,
+	}
+	if cfg.RPCRateLimit > 0 {
+		burst := cfg.RPCRateBurst
+		if burst == 0 {
+			burst = cfg.RPCRateLimit
+		}
+		h.limiter = newRateLimiter(float64(cfg.RPCRateLimit), float64(burst))
+	}
+	return h
+}
+
+func (h *rpcHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if origin := req.Header.Get("Origin"); origin != "" && h.allowOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if req.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	if h.limiter != nil && !h.limiter.allow(remoteIP(req.RemoteAddr)) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	grants, err := h.auth.grants(req)
+	if err != nil {
+		rpcLog.Debug("rpc authentication failed", "remote", req.RemoteAddr, "err", err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	h.next.ServeHTTP(w, req.WithContext(withGrants(req.Context(), grants)))
+}
+
+// allowOrigin은 교차 출처 요청의 Origin이 허용 목록에 있는지 검사합니다.
+func (h *rpcHandler) allowOrigin(origin string) bool {
+	for _, allowed := range h.origins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin은 웹소켓 업그레이드 요청의 Origin을 검사합니다. 같은 호스트이거나 허용 목록에 있어야 합니다.
+func (h *rpcHandler) checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == req.Host {
+		return true
+	}
+	return h.allowOrigin(origin)
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// rateLimiter는 IP별 토큰 버킷입니다.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	l := &rateLimiter{rate: rate, burst: burst, buckets: map[string]*bucket{}}
+	go l.cleanup()
+	return l
+}
+
+func (l *rateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// cleanup은 가득 찬 버킷을 주기적으로 지웁니다. 지워도 다음 요청에서 같은 상태로 다시 만들어집니다.
+func (l *rateLimiter) cleanup() {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		now := time.Now()
+		for ip, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, ip)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// rpcURL은 로그에 남길 RPC 서버 주소입니다.
+func rpcURL(cfg config.Config) string {
+	scheme := "http"
+	if cfg.RPCTLSCert != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(cfg.RPCAddr, strconv.Itoa(cfg.RPCPort))
+}
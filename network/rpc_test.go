@@ -0,0 +1,134 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+func TestJSONRPCGetBlockByNumber(t *testing.T) {
+	s := newTestJSONRPC(t, 5)
+
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{`"latest"`, "0x5"},
+		{`"earliest"`, "0x0"},
+		{`"0x3"`, "0x3"},
+		{`"2"`, "0x2"},
+	}
+	for _, tt := range tests {
+		res := rpcSingle(t, s, `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":[`+tt.number+`]}`)
+		var block rpcBlock
+		if res.Error != nil || json.Unmarshal(res.Result, &block) != nil || block.Number != tt.want {
+			t.Errorf("number %s: error %+v, result %s", tt.number, res.Error, res.Result)
+		}
+	}
+
+	errs := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"above best", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":["0x6"]}`, errCodeNotFound},
+		{"negative", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":["-1"]}`, errCodeInvalidParams},
+		{"invalid", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":["pending"]}`, errCodeInvalidParams},
+		{"hash not found", `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByHash","params":["0x1234"]}`, errCodeNotFound},
+	}
+	for _, tt := range errs {
+		if res := rpcSingle(t, s, tt.body); res.errorCode() != tt.want {
+			t.Errorf("%s: error %+v, want code %d", tt.name, res.Error, tt.want)
+		}
+	}
+}
+
+func TestJSONRPCGetBlockByNumberPruned(t *testing.T) {
+	chain := newTestChain(t, 10)
+	if _, err := chain.Prune(5); err != nil {
+		t.Fatal(err)
+	}
+	s := newJSONRPCServer(&RPCServer{chain: chain})
+
+	if res := rpcSingle(t, s, `{"jsonrpc":"2.0","id":1,"method":"chain_getBlockByNumber","params":["0x2"]}`); res.errorCode() != errCodePruned {
+		t.Fatalf("error %+v, want code %d", res.Error, errCodePruned)
+	}
+
+	// 헤더는 지우지 않으므로 가지치기한 높이도 조회됨
+	res := rpcSingle(t, s, `{"jsonrpc":"2.0","id":1,"method":"chain_getHeadersRange","params":["0x1",3]}`)
+	var headers []rpcHeader
+	if res.Error != nil || json.Unmarshal(res.Result, &headers) != nil || len(headers) != 3 || headers[0].Number != "0x1" {
+		t.Fatalf("error %+v, result %s", res.Error, res.Result)
+	}
+}
+
+func TestJSONRPCGetHeadersRangeErrors(t *testing.T) {
+	s := newTestJSONRPC(t, 3)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"zero count", `{"jsonrpc":"2.0","id":1,"method":"chain_getHeadersRange","params":["0x1",0]}`, errCodeInvalidParams},
+		{"negative count", `{"jsonrpc":"2.0","id":1,"method":"chain_getHeadersRange","params":["0x1",-2]}`, errCodeInvalidParams},
+		{"above best", `{"jsonrpc":"2.0","id":1,"method":"chain_getHeadersRange","params":["0x4",2]}`, errCodeNotFound},
+		{"invalid from", `{"jsonrpc":"2.0","id":1,"method":"chain_getHeadersRange","params":["pending",2]}`, errCodeInvalidParams},
+	}
+	for _, tt := range tests {
+		if res := rpcSingle(t, s, tt.body); res.errorCode() != tt.want {
+			t.Errorf("%s: error %+v, want code %d", tt.name, res.Error, tt.want)
+		}
+	}
+}
+
+func TestGetHeadersRange(t *testing.T) {
+	r := &RPCServer{chain: newTestChain(t, 5)}
+
+	tests := []struct {
+		from, count int
+		want        []int64
+	}{
+		{0, 3, []int64{0, 1, 2}},
+		{3, 10, []int64{3, 4, 5}},
+		{5, 1, []int64{5}},
+	}
+	for _, tt := range tests {
+		var res GetHeadersRangeRes
+		if err := r.GetHeadersRange(&GetHeadersRangeArgs{From: int64(tt.from), Count: tt.count}, &res); err != nil {
+			t.Fatalf("from %d count %d: %v", tt.from, tt.count, err)
+		}
+		heights := []int64{}
+		for _, header := range res.Headers {
+			heights = append(heights, header.Height)
+		}
+		if !equalHeights(heights, tt.want) {
+			t.Errorf("from %d count %d: heights %v, want %v", tt.from, tt.count, heights, tt.want)
+		}
+	}
+
+	var res GetHeadersRangeRes
+	if err := r.GetHeadersRange(&GetHeadersRangeArgs{From: 6, Count: 1}, &res); !errors.Is(err, blockchain.ErrBlockNotFound) {
+		t.Fatalf("from above best: %v", err)
+	}
+	for _, args := range []GetHeadersRangeArgs{{From: -1, Count: 1}, {From: 0, Count: 0}} {
+		if err := r.GetHeadersRange(&args, &res); err == nil {
+			t.Fatalf("args %+v accepted", args)
+		}
+	}
+}
+
+// 한 번에 maxHeadersRange개를 넘게 요청해도 최대 개수만 반환
+func TestGetHeadersRangeCap(t *testing.T) {
+	r := &RPCServer{chain: newTestChain(t, maxHeadersRange+1)}
+
+	var res GetHeadersRangeRes
+	if err := r.GetHeadersRange(&GetHeadersRangeArgs{From: 0, Count: maxHeadersRange + 2}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Headers) != maxHeadersRange || res.Headers[len(res.Headers)-1].Height != maxHeadersRange-1 {
+		t.Fatalf("got %d headers", len(res.Headers))
+	}
+}
@@ -31,6 +31,27 @@ type GetBlockRes struct {
 	Block blockchain.Block
 }
 
+// GetBlockByNumber
+// Number는 높이(10진수 또는 0x 16진수), "latest" 또는 "earliest"입니다.
+type GetBlockByNumberArgs struct {
+	Number string
+}
+
+type GetBlockByNumberRes struct {
+	Block blockchain.Block
+}
+
+// GetHeadersRange
+// Count가 서버 최대값보다 크면 최대값만큼만 반환합니다.
+type GetHeadersRangeArgs struct {
+	From  int64
+	Count int
+}
+
+type GetHeadersRangeRes struct {
+	Headers []blockchain.Header
+}
+
 // PrintChain
 type PrintChainArgs struct {
 	Block blockchain.Block
@@ -52,10 +73,17 @@ type GetBlockHashesRes struct {
 }
 
 // GetBlockList
-type GetBlockListArgs struct{}
+// To가 0이면 현재 높이, Limit가 0이면 기본 개수(20개)를 사용합니다. Limit는 최대 100개입니다.
+type GetBlockListArgs struct {
+	From  int64
+	To    int64
+	Limit int
+}
 
+// Next는 다음 페이지의 시작 높이입니다(마지막 페이지면 -1).
 type GetBlockListRes struct {
 	Block []blockchain.Block
+	Next  int64
 }
 
 // GetWork
@@ -67,6 +95,16 @@ type GetWorkRes struct {
 	TargetThreshold string `json:"targetThreshold"`
 }
 
+// SubmitWork
+type SubmitWorkArgs struct {
+	PowHash string `json:"powHash"`
+	Nonce   string `json:"nonce"`
+}
+
+type SubmitWorkRes struct {
+	Accepted bool `json:"accepted"`
+}
+
 // GetHashRate
 type GetHashRateArgs struct{}
 
@@ -161,3 +199,29 @@ type GetDifficultyArgs struct {
 type GetDifficultyRes struct {
 	Difficulty *big.Int
 }
+
+// Rewind
+type RewindArgs struct {
+	Height int64
+}
+
+type RewindRes struct {
+	Removed int
+	Hash    string
+}
+
+// ReapplyRewound
+type ReapplyRewoundArgs struct{}
+
+type ReapplyRewoundRes struct {
+	Applied int
+	Height  int64
+}
+
+// AuthDenied
+type AuthDeniedArgs struct {
+	Method string
+	Group  string
+}
+
+type AuthDeniedRes struct{}
@@ -4,81 +4,99 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"net"
 
 	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/metrics"
 )
 
 // 알려진 노드 주소를 전송
 func SendKnownNodes(addr string) {
 	// 현재 알려진 노드 리스트를 Addr 구조체에 저장
 	nodes := Addr{KnownNodes}
-	// Addr 구조체를 GOB 인코딩하여 바이트 배열로 변환
-	payload := GobEncode(nodes)
-	// 'addr' 명령어와 인코딩된 데이터를 결합하여 요청 생성
-	request := append(CmdToBytes("knownNodes"), payload...)
-
-	// 특정 주소로 요청 데이터 전송
-	SendData(addr, request)
+	// 'knownNodes' 명령어와 GOB 인코딩한 데이터를 특정 주소로 전송
+	sendMessage(addr, "knownNodes", nodes)
 }
 
 // 블록 데이터를 전송
 func SendBlock(addr string, b *blockchain.Block) {
 	// 현재 노드 주소와 직렬화된 블록 데이터를 Block 구조체에 담음
-	data := Block{nodeAddress, b.Serialize()}
-	// Block 구조체를 GOB 인코딩하여 바이트 배열로 변환
-	payload := GobEncode(data)
-	// "block" 명령어와 인코딩된 데이터를 결합하여 요청 생성
-	request := append(CmdToBytes("block"), payload...)
-
-	// 특정 주소로 요청 데이터 전송
-	SendData(addr, request)
+	blockData, err := b.Serialize()
+	if err != nil {
+		log.Error("could not serialize block", "height", b.Height, "err", err)
+		return
+	}
+	data := Block{nodeAddress, blockData}
+	// "block" 명령어와 GOB 인코딩한 데이터를 특정 주소로 전송
+	sendMessage(addr, "block", data)
+}
+
+// 인벤토리(블록 해시 목록)를 알림
+func SendInv(addr, kind string, items [][]byte) {
+	sendMessage(addr, "inv", Inv{AddrFrom: nodeAddress, Type: kind, Items: items})
+}
+
+// inv로 알림받은 데이터를 요청
+func SendGetData(addr, kind string, id []byte) {
+	log.Debug("Requesting data", "type", kind, "id", fmt.Sprintf("%x", id), "peer", addr)
+	sendMessage(addr, "getdata", GetData{AddrFrom: nodeAddress, Type: kind, ID: id})
+}
+
+// command와 GOB 인코딩한 payload를 특정 주소로 전송. 인코딩에 실패하면 보내지 않음
+func sendMessage(addr, command string, payload any) {
+	data, err := GobEncode(payload)
+	if err != nil {
+		log.Error("could not encode message", "command", command, "peer", addr, "err", err)
+		return
+	}
+	SendData(addr, append(CmdToBytes(command), data...))
 }
 
 // 데이터를 특정 주소로 전송
 func SendData(addr string, data []byte) {
 	if addr == "" {
-		log.Println("Error: Target address is empty, cannot send data.")
+		log.Error("Target address is empty, cannot send data")
 		return
 	}
 
 	conn, err := net.Dial(protocol, addr)
 	if err != nil {
-		fmt.Printf("Failed to connect to %s: %v\n", addr, err)
+		log.Warn("Failed to connect to peer", "addr", addr, "err", err)
 		var updatedNodes []string
 		for _, node := range KnownNodes {
 			if node != addr {
 				updatedNodes = append(updatedNodes, node)
 			}
 		}
+		if len(updatedNodes) != len(KnownNodes) {
+			PeerFeed.Send(PeerEvent{Type: PeerDropped, Peer: addr})
+			forgetPeer(addr)
+		}
 		KnownNodes = updatedNodes
+		updatePeerCount()
 		return
 	}
 	defer conn.Close()
 
-	fmt.Printf("Sending data to %s\n", addr)
-	_, err = io.Copy(conn, bytes.NewReader(data))
+	n, err := io.Copy(conn, bytes.NewReader(data))
+	metrics.BytesOut.WithLabelValues(commandLabel(data)).Add(float64(n))
 	if err != nil {
-		log.Printf("Error while sending data to %s: %v\n", addr, err)
+		log.Warn("Error while sending data", "addr", addr, "err", err)
 	} else {
-		fmt.Printf("Data successfully sent to %s\n", addr)
+		log.Debug("Data successfully sent", "addr", addr, "command", BytesToCmd(data[:commandLength]), "bytes", len(data))
 	}
 }
 
 // 특정 데이터(블록 또는 트랜잭션)를 요청하는 함수
 // Sends a request for a range of blocks by height
 func SendLatestBlockHeight(addr string, startHeight, endHeight int64) {
-	payload := GobEncode(LatestBlockHeight{AddrFrom: nodeAddress, ID: []byte(fmt.Sprintf("%d-%d", startHeight, endHeight))})
-	request := append(CmdToBytes("latestBlockHeight"), payload...)
-
-	fmt.Printf("Requesting blocks from height %d to %d from %s\n", startHeight, endHeight, addr)
-	SendData(addr, request)
+	log.Info("Requesting blocks", "from", startHeight, "to", endHeight, "peer", addr)
+	sendMessage(addr, "latestBlockHeight", LatestBlockHeight{AddrFrom: nodeAddress, ID: []byte(fmt.Sprintf("%d-%d", startHeight, endHeight))})
 }
 
 func SendBlockList(addr string, blocks [][]byte, length int) {
 	if addr == "" {
-		log.Println("Error: Target address for block list is empty.")
+		log.Error("Target address for block list is empty")
 		return
 	}
 
@@ -88,31 +106,50 @@ func SendBlockList(addr string, blocks [][]byte, length int) {
 		Length:   length,
 	}
 
-	payload := GobEncode(data)
-	request := append(CmdToBytes("blocklist"), payload...)
+	log.Debug("Sending blocklist", "peer", addr, "blocks", len(blocks))
 
-	fmt.Printf("Sending blocklist to %s with %d blocks\n", addr, len(blocks))
-
-	SendData(addr, request)
+	sendMessage(addr, "blocklist", data)
 }
 
 func SendVersion(addr string, chain *blockchain.BlockChain) {
 	if nodeAddress == "" {
-		log.Println("Error: nodeAddress is empty, cannot send version message.")
+		log.Error("nodeAddress is empty, cannot send version message")
 		return
 	}
 
 	bestHeight := chain.GetBestHeight()
-	fmt.Println("bestHeight: ", bestHeight)
+	if chain.IsLight() {
+		// light 노드는 블록을 내줄 수 없으므로 peer가 이 노드에서 동기화하지 않도록 높이를 0으로 알림
+		bestHeight = 0
+	}
+
+	log.Debug("Sending version", "peer", addr, "bestHeight", bestHeight)
 
-	payload := GobEncode(Version{
+	sendMessage(addr, "version", Version{
 		Version:    version,
 		BestHeight: bestHeight,
 		AddrFrom:   nodeAddress, // Ensure AddrFrom is set here
 	})
-	request := append(CmdToBytes("version"), payload...)
+}
+
+// 높이 height의 스냅샷을 offset부터 한 조각 요청
+func SendGetSnapshot(addr string, height, offset int64) {
+	log.Debug("Requesting snapshot chunk", "height", height, "offset", offset, "peer", addr)
+	sendMessage(addr, "getsnapshot", GetSnapshot{AddrFrom: nodeAddress, Height: height, Offset: offset})
+}
 
-	fmt.Printf("Sending version to %s with bestHeight: %d from %s\n", addr, bestHeight, nodeAddress)
+func SendSnapshot(addr string, height, offset, total int64, data []byte) {
+	log.Debug("Sending snapshot chunk", "height", height, "offset", offset, "total", total, "peer", addr)
+	sendMessage(addr, "snapshot", Snapshot{AddrFrom: nodeAddress, Height: height, Offset: offset, Total: total, Data: data})
+}
+
+// 블록 본문 요청을 전송
+func SendGetBody(addr string, hash []byte) {
+	log.Debug("Requesting block body", "hash", fmt.Sprintf("%x", hash), "peer", addr)
+	sendMessage(addr, "getbody", GetBody{AddrFrom: nodeAddress, Hash: hash})
+}
 
-	SendData(addr, request)
+// 블록 본문 응답을 전송. data가 비어있으면 블록이 없다는 뜻
+func SendBody(addr string, hash, data []byte) {
+	sendMessage(addr, "body", Body{AddrFrom: nodeAddress, Hash: hash, Block: data})
 }
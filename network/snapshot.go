@@ -0,0 +1,216 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+const (
+	// 응답이 없을 때 스냅샷을 다시 요청하기까지의 시간
+	snapshotRequestTimeout = 30 * time.Second
+	// 스냅샷 조각 하나의 최대 크기
+	snapshotChunkSize = 1 << 20
+	// 보내거나 받을 스냅샷의 최대 크기. 헤더 약 200만 개
+	maxSnapshotSize = 256 << 20
+)
+
+var errSnapshotTooLarge = fmt.Errorf("snapshot exceeds %d bytes", maxSnapshotSize)
+
+var (
+	snapshotMu        sync.Mutex
+	snapshotRequested time.Time // 마지막으로 스냅샷 조각을 요청한 시각
+	snapshotPeer      string    // 스냅샷을 받고 있는 peer
+	snapshotTotal     int64     // 받고 있는 스냅샷의 전체 크기
+	snapshotData      []byte    // 지금까지 받은 조각
+
+	// 마지막으로 만든 스냅샷. 같은 높이의 블록이 바뀌지 않았으면 다시 만들지 않고 보냄
+	servedSnapshot struct {
+		height int64
+		hash   []byte // height 블록의 해시
+		sum    []byte // 내용 해시
+		data   []byte
+	}
+)
+
+// limitedBuffer는 maxSnapshotSize보다 많이 쓰면 실패하는 버퍼입니다.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxSnapshotSize {
+		return 0, errSnapshotTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// requestSnapshot은 블록이 없는 노드가 설정의 신뢰하는 체크포인트 높이에 도달한 peer에게 스냅샷을 요청합니다.
+// 요청했으면 true를 반환하며, 이때는 블록을 따로 요청하지 않습니다.
+func requestSnapshot(chain *blockchain.BlockChain, addr string, otherHeight int64) bool {
+	height, _, ok := blockchain.TrustedSnapshot()
+	if !ok || otherHeight < height {
+		return false
+	}
+	if len(chain.LastHash) > 0 {
+		// 블록이 없는 체인에만 복원할 수 있음
+		return false
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	if time.Since(snapshotRequested) < snapshotRequestTimeout {
+		// 이미 요청한 스냅샷을 받는 중
+		return true
+	}
+	// 응답이 없던 peer에게서 받던 조각은 버리고 처음부터 받음
+	snapshotRequested = time.Now()
+	snapshotPeer, snapshotTotal, snapshotData = addr, 0, nil
+	log.Info("Requesting snapshot", "height", height, "peer", addr)
+	SendGetSnapshot(addr, height, 0)
+	return true
+}
+
+// 스냅샷 조각 요청을 처리하는 함수. 설정의 신뢰하는 체크포인트 높이의 스냅샷만 보냄
+func HandleGetSnapshot(request []byte, chain *blockchain.BlockChain) {
+	var buff bytes.Buffer
+	var payload GetSnapshot
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode snapshot request", "err", err)
+		return
+	}
+	if chain.IsLight() {
+		log.Debug("light node cannot serve snapshots", "peer", payload.AddrFrom)
+		return
+	}
+	height, trusted, ok := blockchain.TrustedSnapshot()
+	if !ok || payload.Height != height {
+		log.Debug("snapshot requested at a height other than the trusted checkpoint", "height", payload.Height, "peer", payload.AddrFrom)
+		return
+	}
+
+	data, err := snapshotAt(chain, height, trusted)
+	if err != nil {
+		log.Warn("could not serve snapshot", "height", height, "peer", payload.AddrFrom, "err", err)
+		return
+	}
+	if payload.Offset < 0 || payload.Offset >= int64(len(data)) {
+		log.Warn("invalid snapshot offset", "offset", payload.Offset, "size", len(data), "peer", payload.AddrFrom)
+		return
+	}
+	end := min(payload.Offset+snapshotChunkSize, int64(len(data)))
+	SendSnapshot(payload.AddrFrom, height, payload.Offset, int64(len(data)), data[payload.Offset:end])
+}
+
+// snapshotAt은 height의 스냅샷을 반환합니다. 같은 높이의 블록이 바뀌지 않았으면 만들어 둔 스냅샷을 사용합니다.
+// 내용 해시가 trusted와 다르면 이 노드의 체인이 체크포인트와 다르므로 오류를 반환합니다.
+func snapshotAt(chain *blockchain.BlockChain, height int64, trusted []byte) ([]byte, error) {
+	header, err := chain.GetHeaderByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	if servedSnapshot.height != height || !bytes.Equal(servedSnapshot.hash, header.Hash) {
+		var buf limitedBuffer
+		sum, err := chain.CreateSnapshot(&buf, height)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("snapshot created", "height", height, "hash", fmt.Sprintf("%x", sum), "bytes", buf.Len())
+		servedSnapshot.height, servedSnapshot.hash = height, header.Hash
+		servedSnapshot.sum, servedSnapshot.data = sum, buf.Bytes()
+	}
+	if !bytes.Equal(servedSnapshot.sum, trusted) {
+		return nil, fmt.Errorf("local snapshot %x does not match the trusted checkpoint %x", servedSnapshot.sum, trusted)
+	}
+	return servedSnapshot.data, nil
+}
+
+// 스냅샷 조각을 처리하는 함수. 요청한 peer가 보낸 다음 조각만 받고, 다 받으면 내용 해시가 신뢰하는 체크포인트와 같을 때만 사용
+func HandleSnapshot(request []byte, chain *blockchain.BlockChain) {
+	var buff bytes.Buffer
+	var payload Snapshot
+
+	buff.Write(request[commandLength:])
+	dec := gob.NewDecoder(&buff)
+	if err := dec.Decode(&payload); err != nil {
+		log.Warn("could not decode snapshot", "err", err)
+		return
+	}
+
+	height, trusted, ok := blockchain.TrustedSnapshot()
+	if !ok || payload.Height != height {
+		log.Warn("unexpected snapshot", "height", payload.Height, "peer", payload.AddrFrom)
+		return
+	}
+
+	snapshotMu.Lock()
+	if payload.AddrFrom != snapshotPeer || payload.Offset != int64(len(snapshotData)) {
+		snapshotMu.Unlock()
+		log.Debug("unexpected snapshot chunk", "offset", payload.Offset, "peer", payload.AddrFrom)
+		return
+	}
+	size := payload.Offset + int64(len(payload.Data))
+	if payload.Total <= 0 || payload.Total > maxSnapshotSize || (snapshotTotal != 0 && payload.Total != snapshotTotal) ||
+		len(payload.Data) == 0 || len(payload.Data) > snapshotChunkSize || size > payload.Total {
+		// 다음 peer에게서 처음부터 받음
+		snapshotRequested, snapshotPeer, snapshotTotal, snapshotData = time.Time{}, "", 0, nil
+		snapshotMu.Unlock()
+		log.Warn("invalid snapshot chunk", "offset", payload.Offset, "bytes", len(payload.Data), "total", payload.Total, "peer", payload.AddrFrom)
+		return
+	}
+	snapshotTotal = payload.Total
+	snapshotData = append(snapshotData, payload.Data...)
+	if size < payload.Total {
+		snapshotRequested = time.Now()
+		snapshotMu.Unlock()
+		SendGetSnapshot(payload.AddrFrom, height, size)
+		return
+	}
+	data := snapshotData
+	snapshotRequested, snapshotPeer, snapshotTotal, snapshotData = time.Time{}, "", 0, nil
+	snapshotMu.Unlock()
+	log.Info("snapshot received", "height", height, "bytes", len(data), "peer", payload.AddrFrom)
+
+	chain.Mu.Lock()
+	err := chain.RestoreSnapshot(bytes.NewReader(data), trusted)
+	chain.Mu.Unlock()
+
+	if err != nil {
+		log.Error("snapshot rejected", "height", payload.Height, "peer", payload.AddrFrom, "err", err)
+		// 블록을 처음부터 받음
+		start := int64(0)
+		if len(chain.LastHash) > 0 {
+			start = chain.GetBestHeight() + 1
+		}
+		SendLatestBlockHeight(payload.AddrFrom, start, syncTarget)
+		return
+	}
+
+	startBackfill(chain)
+	// 스냅샷 이후의 블록을 받음
+	if syncTarget > height {
+		SendLatestBlockHeight(payload.AddrFrom, height+1, syncTarget)
+	} else {
+		setSync(false)
+		// 새 tip에서 채굴을 시작하도록 알림
+		newBlockListChan <- true
+	}
+}
+
+// startBackfill은 스냅샷으로 시작한 full 노드가 받지 못한 본문을 peer에게서 받기 시작합니다.
+func startBackfill(chain *blockchain.BlockChain) {
+	if height := chain.BackfillHeight(); height > 0 {
+		log.Info("Fetching block bodies below the restored snapshot", "height", height)
+		go chain.RunBackfill()
+	}
+}
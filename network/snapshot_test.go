@@ -0,0 +1,131 @@
+package network
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/config"
+)
+
+// resetSnapshotState는 패키지의 스냅샷 송수신 상태를 비웁니다.
+func resetSnapshotState(t *testing.T) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	snapshotRequested, snapshotPeer, snapshotTotal, snapshotData = time.Time{}, "", 0, nil
+	servedSnapshot.height, servedSnapshot.hash, servedSnapshot.sum, servedSnapshot.data = 0, nil, nil, nil
+	t.Cleanup(func() { setSyncTarget(0) })
+}
+
+// trustSnapshot은 chain의 height 스냅샷을 만들고 설정의 신뢰하는 체크포인트로 지정합니다.
+func trustSnapshot(t *testing.T, chain *blockchain.BlockChain, height int64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	sum, err := chain.CreateSnapshot(&buf, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.GlobalConfig.SnapshotHeight = height
+	config.GlobalConfig.SnapshotHash = hex.EncodeToString(sum)
+	return buf.Bytes()
+}
+
+func TestServeSnapshotOnlyAtCheckpoint(t *testing.T) {
+	resetSnapshotState(t)
+	chain := newTestChain(t, 30)
+	data := trustSnapshot(t, chain, 25)
+	peer := newTestPeer(t)
+
+	HandleGetSnapshot(message("getsnapshot", GetSnapshot{AddrFrom: peer.addr, Height: 25}), chain)
+	var chunk Snapshot
+	peer.expect(t, "snapshot", &chunk)
+	if chunk.Offset != 0 || chunk.Total != int64(len(data)) || !bytes.Equal(chunk.Data, data) {
+		t.Fatalf("chunk offset %d, total %d, %d bytes; snapshot has %d bytes", chunk.Offset, chunk.Total, len(chunk.Data), len(data))
+	}
+
+	// 체크포인트가 아닌 높이나 범위를 벗어난 위치는 보내지 않음
+	HandleGetSnapshot(message("getsnapshot", GetSnapshot{AddrFrom: peer.addr, Height: 20}), chain)
+	HandleGetSnapshot(message("getsnapshot", GetSnapshot{AddrFrom: peer.addr, Height: 25, Offset: int64(len(data))}), chain)
+	HandleGetSnapshot(message("getsnapshot", GetSnapshot{AddrFrom: peer.addr, Height: 25, Offset: -1}), chain)
+	peer.expectNothing(t)
+
+	// 로컬 체인의 스냅샷이 체크포인트와 다르면 보내지 않음
+	config.GlobalConfig.SnapshotHash = hex.EncodeToString(make([]byte, 32))
+	HandleGetSnapshot(message("getsnapshot", GetSnapshot{AddrFrom: peer.addr, Height: 25}), chain)
+	peer.expectNothing(t)
+}
+
+func TestReceiveSnapshotInChunks(t *testing.T) {
+	resetSnapshotState(t)
+	source := newTestChain(t, 30)
+	data := trustSnapshot(t, source, 25)
+	trustedHeight, trustedHash := config.GlobalConfig.SnapshotHeight, config.GlobalConfig.SnapshotHash
+
+	// 받는 노드는 같은 체인의 빈 데이터베이스에서 시작
+	useTestConfig(t)
+	config.GlobalConfig.SnapshotHeight, config.GlobalConfig.SnapshotHash = trustedHeight, trustedHash
+	chain, err := blockchain.InitEmptyBlockChain(source.ChainId, source.Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	peer := newTestPeer(t)
+	other := newTestPeer(t)
+	setSyncTarget(30)
+	if !requestSnapshot(chain, peer.addr, 30) {
+		t.Fatal("snapshot not requested")
+	}
+	var req GetSnapshot
+	peer.expect(t, "getsnapshot", &req)
+	if req.Height != 25 || req.Offset != 0 {
+		t.Fatalf("requested height %d offset %d", req.Height, req.Offset)
+	}
+
+	const chunkSize = 100
+	total := int64(len(data))
+	for offset := int64(0); offset < total; offset += chunkSize {
+		chunk := data[offset:min(offset+chunkSize, total)]
+		// 요청하지 않은 peer가 보낸 조각은 무시
+		HandleSnapshot(message("snapshot", Snapshot{AddrFrom: other.addr, Height: 25, Offset: offset, Total: total, Data: chunk}), chain)
+		HandleSnapshot(message("snapshot", Snapshot{AddrFrom: peer.addr, Height: 25, Offset: offset, Total: total, Data: chunk}), chain)
+
+		if next := offset + chunkSize; next < total {
+			peer.expect(t, "getsnapshot", &req)
+			if req.Offset != next {
+				t.Fatalf("requested offset %d, want %d", req.Offset, next)
+			}
+		}
+	}
+	other.expectNothing(t)
+
+	// 다 받으면 복원하고 나머지 블록을 요청
+	var rest LatestBlockHeight
+	peer.expect(t, "latestBlockHeight", &rest)
+	if string(rest.ID) != "26-30" {
+		t.Fatalf("requested blocks %s after snapshot", rest.ID)
+	}
+	if best := chain.GetBestHeight(); best != 25 {
+		t.Fatalf("height %d after snapshot, want 25", best)
+	}
+}
+
+func TestRejectOversizedSnapshot(t *testing.T) {
+	resetSnapshotState(t)
+	chain := newTestChain(t, 10)
+	trustSnapshot(t, chain, 5)
+	peer := newTestPeer(t)
+
+	snapshotMu.Lock()
+	snapshotPeer, snapshotRequested = peer.addr, time.Now()
+	snapshotMu.Unlock()
+
+	HandleSnapshot(message("snapshot", Snapshot{AddrFrom: peer.addr, Height: 5, Total: maxSnapshotSize + 1, Data: []byte{1}}), chain)
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	if snapshotPeer != "" || snapshotData != nil || !snapshotRequested.IsZero() {
+		t.Fatal("oversized snapshot not abandoned")
+	}
+}
@@ -0,0 +1,319 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/Kim-DaeHan/mining-chain/event"
+	"github.com/gorilla/websocket"
+)
+
+// 구독 종류
+const (
+	subNewHeads   = "newHeads"
+	subReorgs     = "reorgs"
+	subSyncing    = "syncing"
+	subPeerEvents = "peerEvents"
+)
+
+const (
+	// 구독자마다 쌓아둘 수 있는 이벤트 수. 넘치면 구독이 끊어짐
+	subscriptionBuffer = 128
+	// 연결마다 전송 대기할 수 있는 메시지 수. 넘치면 연결이 끊어짐
+	wsSendBuffer   = 256
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = 30 * time.Second
+)
+
+var subscriptionCounter atomic.Uint64
+
+type subscriptionNotification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  subscriptionResult `json:"params"`
+}
+
+type subscriptionResult struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+type rpcReorg struct {
+	OldNumber string `json:"oldNumber"`
+	OldHash   string `json:"oldHash"`
+	NewNumber string `json:"newNumber"`
+	NewHash   string `json:"newHash"`
+}
+
+type rpcSyncStatus struct {
+	Syncing       bool   `json:"syncing"`
+	CurrentNumber string `json:"currentNumber"`
+	TargetNumber  string `json:"targetNumber"`
+}
+
+type rpcPeerEvent struct {
+	Type string `json:"type"`
+	Peer string `json:"peer"`
+}
+
+// wsServer는 /ws 엔드포인트입니다. 일반 JSON-RPC 호출과 subscribe/unsubscribe를 처리합니다.
+type wsServer struct {
+	rpc      *jsonrpcServer
+	chain    *blockchain.BlockChain
+	upgrader websocket.Upgrader
+}
+
+func newWSServer(jsonrpc *jsonrpcServer, chain *blockchain.BlockChain, checkOrigin func(*http.Request) bool) *wsServer {
+	return &wsServer{
+		rpc:   jsonrpc,
+		chain: chain,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     checkOrigin,
+		},
+	}
+}
+
+// wsConn은 웹소켓 연결 하나의 상태입니다.
+type wsConn struct {
+	server *wsServer
+	conn   *websocket.Conn
+	grants rpcGrants
+	send   chan interface{}
+	done   chan struct{}
+	once   sync.Once
+
+	mu   sync.Mutex
+	subs map[string]func()
+}
+
+func (s *wsServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		rpcLog.Debug("websocket upgrade failed", "err", err)
+		return
+	}
+
+	c := &wsConn{
+		server: s,
+		conn:   conn,
+		grants: grantsFrom(req.Context()),
+		send:   make(chan interface{}, wsSendBuffer),
+		done:   make(chan struct{}),
+		subs:   map[string]func(){},
+	}
+	rpcLog.Debug("websocket connected", "remote", req.RemoteAddr)
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (c *wsConn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+
+		c.mu.Lock()
+		for id, unsubscribe := range c.subs {
+			unsubscribe()
+			delete(c.subs, id)
+		}
+		c.mu.Unlock()
+	})
+}
+
+// queue는 메시지를 전송 대기열에 넣습니다. 대기열이 가득 찬 느린 클라이언트는 연결을 끊습니다.
+func (c *wsConn) queue(msg interface{}) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		rpcLog.Warn("websocket client too slow, closing connection", "remote", c.conn.RemoteAddr())
+		c.close()
+	}
+}
+
+func (c *wsConn) readLoop() {
+	defer c.close()
+
+	c.conn.SetReadLimit(maxRequestSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if res := c.handle(msg); res != nil {
+			c.queue(res)
+		}
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	defer c.close()
+
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// handle은 subscribe/unsubscribe를 직접 처리하고 나머지는 JSON-RPC 서버로 넘깁니다.
+func (c *wsConn) handle(msg []byte) interface{} {
+	var req jsonrpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil || (req.Method != "subscribe" && req.Method != "unsubscribe") {
+		return c.server.rpc.handleMessage(msg, c.grants)
+	}
+
+	if req.JSONRPC != "2.0" {
+		return errorResponse(req.ID, errCodeInvalidRequest, "invalid request")
+	}
+
+	var result interface{}
+	var err error
+	if req.Method == "subscribe" {
+		result, err = c.subscribe(req.Params)
+	} else {
+		result, err = c.unsubscribe(req.Params)
+	}
+
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *jsonrpcError
+		if errors.As(err, &rpcErr) {
+			return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+		}
+		return errorResponse(req.ID, errCodeServer, err.Error())
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (c *wsConn) subscribe(params []json.RawMessage) (interface{}, error) {
+	var kind string
+	if err := parseParams(params, &kind); err != nil {
+		return nil, err
+	}
+
+	if group, ok := subscriptionGroups[kind]; ok && !c.grants.allows(group) {
+		return nil, unauthorized("subscribe "+kind, group)
+	}
+
+	id := fmt.Sprintf("0x%x", subscriptionCounter.Add(1))
+	chain := c.server.chain
+
+	var unsubscribe func()
+	switch kind {
+	case subNewHeads:
+		unsubscribe = forward(c, id, chain.HeadFeed.Subscribe(subscriptionBuffer), func(ev blockchain.ChainHeadEvent) interface{} {
+			return newRPCBlock(ev.Block)
+		})
+	case subReorgs:
+		unsubscribe = forward(c, id, chain.ReorgFeed.Subscribe(subscriptionBuffer), func(ev blockchain.ChainReorgEvent) interface{} {
+			return &rpcReorg{
+				OldNumber: encodeQuantity(ev.OldHeight),
+				OldHash:   encodeBytes(ev.OldHash),
+				NewNumber: encodeQuantity(ev.NewHeight),
+				NewHash:   encodeBytes(ev.NewHash),
+			}
+		})
+	case subSyncing:
+		unsubscribe = forward(c, id, SyncFeed.Subscribe(subscriptionBuffer), func(ev SyncEvent) interface{} {
+			return &rpcSyncStatus{
+				Syncing:       ev.Syncing,
+				CurrentNumber: encodeQuantity(ev.CurrentHeight),
+				TargetNumber:  encodeQuantity(ev.TargetHeight),
+			}
+		})
+	case subPeerEvents:
+		unsubscribe = forward(c, id, PeerFeed.Subscribe(subscriptionBuffer), func(ev PeerEvent) interface{} {
+			return &rpcPeerEvent{Type: ev.Type, Peer: ev.Peer}
+		})
+	default:
+		return nil, invalidParams("unknown subscription %q (expected %s, %s, %s or %s)", kind, subNewHeads, subReorgs, subSyncing, subPeerEvents)
+	}
+
+	c.mu.Lock()
+	c.subs[id] = unsubscribe
+	c.mu.Unlock()
+	return id, nil
+}
+
+func (c *wsConn) unsubscribe(params []json.RawMessage) (interface{}, error) {
+	var id string
+	if err := parseParams(params, &id); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	unsubscribe, ok := c.subs[id]
+	delete(c.subs, id)
+	c.mu.Unlock()
+
+	if ok {
+		unsubscribe()
+	}
+	return ok, nil
+}
+
+// forward는 구독의 이벤트를 변환해 연결로 보냅니다.
+// 구독자 버퍼가 넘쳐 끊어지면 클라이언트에게 오류 알림을 보냅니다.
+func forward[T any](c *wsConn, id string, sub *event.Subscription[T], convert func(T) interface{}) func() {
+	go func() {
+		for {
+			select {
+			case ev := <-sub.Chan():
+				c.queue(&subscriptionNotification{
+					JSONRPC: "2.0",
+					Method:  "subscription",
+					Params:  subscriptionResult{Subscription: id, Result: convert(ev)},
+				})
+			case err, ok := <-sub.Err():
+				if ok && err != nil {
+					c.mu.Lock()
+					delete(c.subs, id)
+					c.mu.Unlock()
+					c.queue(&subscriptionNotification{
+						JSONRPC: "2.0",
+						Method:  "subscription",
+						Params:  subscriptionResult{Subscription: id, Error: err.Error()},
+					})
+				}
+				return
+			case <-c.done:
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return sub.Unsubscribe
+}
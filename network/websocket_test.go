@@ -0,0 +1,123 @@
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+	"github.com/gorilla/websocket"
+)
+
+// testNotification은 구독 알림을 디코딩한 형태입니다.
+type testNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+		Error        string          `json:"error"`
+	} `json:"params"`
+}
+
+// dialTestWS는 chain을 제공하는 웹소켓 서버에 연결합니다.
+func dialTestWS(t *testing.T, chain *blockchain.BlockChain) *websocket.Conn {
+	t.Helper()
+	ws := newWSServer(newJSONRPCServer(&RPCServer{chain: chain}), chain, func(*http.Request) bool { return true })
+	server := httptest.NewServer(ws)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// wsCall은 요청을 보내고 다음 메시지를 응답으로 읽습니다.
+func wsCall(t *testing.T, conn *websocket.Conn, body string) testResponse {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	var res testResponse
+	wsRead(t, conn, &res)
+	return res
+}
+
+func wsRead(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWSNewHeads(t *testing.T) {
+	chain := newTestChain(t, 1)
+	conn := dialTestWS(t, chain)
+
+	res := wsCall(t, conn, `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":["newHeads"]}`)
+	var id string
+	if res.Error != nil || json.Unmarshal(res.Result, &id) != nil || id == "" {
+		t.Fatalf("subscribe response %+v, result %s", res, res.Result)
+	}
+
+	block := newTestBlock(t, chain.GetLastBlock(), "")
+	if err := chain.ImportBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	var note testNotification
+	wsRead(t, conn, &note)
+	var head rpcBlock
+	if err := json.Unmarshal(note.Params.Result, &head); err != nil {
+		t.Fatal(err)
+	}
+	if note.Method != "subscription" || note.Params.Subscription != id || head.Number != "0x2" || head.Hash != encodeBytes(block.Hash) {
+		t.Fatalf("notification %+v, head %+v", note, head)
+	}
+
+	res = wsCall(t, conn, `{"jsonrpc":"2.0","id":2,"method":"unsubscribe","params":["`+id+`"]}`)
+	if res.Error != nil || string(res.Result) != "true" {
+		t.Fatalf("unsubscribe response %+v, result %s", res, res.Result)
+	}
+	res = wsCall(t, conn, `{"jsonrpc":"2.0","id":3,"method":"unsubscribe","params":["`+id+`"]}`)
+	if res.Error != nil || string(res.Result) != "false" {
+		t.Fatalf("second unsubscribe response %+v, result %s", res, res.Result)
+	}
+
+	// 구독을 취소한 뒤의 블록은 알리지 않고 다음 메시지는 일반 호출의 응답이어야 함
+	if err := chain.ImportBlock(newTestBlock(t, chain.GetLastBlock(), "")); err != nil {
+		t.Fatal(err)
+	}
+	res = wsCall(t, conn, `{"jsonrpc":"2.0","id":4,"method":"chain_blockNumber"}`)
+	if res.Error != nil || string(res.ID) != "4" || string(res.Result) != `"0x3"` {
+		t.Fatalf("response %+v, result %s", res, res.Result)
+	}
+}
+
+func TestWSSubscribeErrors(t *testing.T) {
+	conn := dialTestWS(t, newTestChain(t, 0))
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"unknown", `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":["logs"]}`, errCodeInvalidParams},
+		{"no params", `{"jsonrpc":"2.0","id":1,"method":"subscribe"}`, errCodeInvalidParams},
+		{"version", `{"jsonrpc":"1.0","id":1,"method":"subscribe","params":["newHeads"]}`, errCodeInvalidRequest},
+		{"unauthorized", `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":["peerEvents"]}`, errCodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := wsCall(t, conn, tt.body); res.errorCode() != tt.want {
+				t.Fatalf("error %+v, want code %d", res.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kim-DaeHan/mining-chain/blockchain"
+)
+
+// 외부 채굴자에게 내준 작업을 보관하는 최대 개수
+const maxPendingWork = 16
+
+// 채굴된 블록을 mining 루프에 넘길 때 기다리는 최대 시간
+const submitWorkTimeout = 5 * time.Second
+
+// pendingWork는 GetWork로 내준 블록 템플릿입니다. 키는 powHash입니다.
+var pendingWork = struct {
+	sync.Mutex
+	blocks map[string]*blockchain.Block
+	order  []string
+}{blocks: map[string]*blockchain.Block{}}
+
+// newWork는 현재 tip 위에 채굴할 블록 템플릿을 만들어 보관하고 powHash와 작업 데이터를 반환합니다.
+func newWork(chain *blockchain.BlockChain) (string, string, *blockchain.Block, error) {
+	if chain.IsLight() {
+		return "", "", nil, fmt.Errorf("light nodes do not mine")
+	}
+	chain.Mu.Lock()
+	lastBlock := chain.GetLastBlock()
+	difficulty, err := chain.Difficulty(lastBlock.Height + 1)
+	chain.Mu.Unlock()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	block := &blockchain.Block{
+		Timestamp:  time.Now().Unix(),
+		PrevHash:   lastBlock.Hash,
+		Height:     lastBlock.Height + 1,
+		Difficulty: difficulty,
+		Miner:      blockchain.HexBytes(validatorAddress),
+		Validator:  blockchain.HexBytes(validatorAddress),
+	}
+
+	data, err := blockchain.NewProof(block).WorkData()
+	if err != nil {
+		return "", "", nil, err
+	}
+	sum := sha256.Sum256([]byte(data))
+	powHash := hex.EncodeToString(sum[:])
+
+	pendingWork.Lock()
+	defer pendingWork.Unlock()
+
+	// tip이 바뀌었으면 이전 작업은 모두 버림
+	if len(pendingWork.order) > 0 && !bytes.Equal(pendingWork.blocks[pendingWork.order[0]].PrevHash, block.PrevHash) {
+		pendingWork.blocks = map[string]*blockchain.Block{}
+		pendingWork.order = nil
+	}
+	if len(pendingWork.order) >= maxPendingWork {
+		delete(pendingWork.blocks, pendingWork.order[0])
+		pendingWork.order = pendingWork.order[1:]
+	}
+	pendingWork.blocks[powHash] = block
+	pendingWork.order = append(pendingWork.order, powHash)
+
+	return powHash, data, block, nil
+}
+
+// submitWork는 외부 채굴자가 찾은 nonce를 검증하고 유효하면 블록을 mining 루프로 넘깁니다.
+func submitWork(chain *blockchain.BlockChain, powHash, nonce string) (bool, error) {
+	powHash = strings.TrimPrefix(powHash, "0x")
+	nonceBytes, err := hex.DecodeString(strings.TrimPrefix(nonce, "0x"))
+	if err != nil || len(nonceBytes) == 0 {
+		return false, fmt.Errorf("invalid nonce %q", nonce)
+	}
+
+	pendingWork.Lock()
+	template, ok := pendingWork.blocks[powHash]
+	pendingWork.Unlock()
+	if !ok {
+		return false, fmt.Errorf("unknown or stale work %s", powHash)
+	}
+	lastHash, err := chain.GetLastBlockHash()
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(template.PrevHash, lastHash) {
+		return false, fmt.Errorf("stale work %s, chain head has changed", powHash)
+	}
+
+	block := *template
+	pow := blockchain.NewProof(&block)
+	if !pow.Validate(nonceBytes) {
+		return false, nil
+	}
+	block.Nonce = nonceBytes
+	block.Hash = pow.GetHash(&block)
+
+	pendingWork.Lock()
+	delete(pendingWork.blocks, powHash)
+	pendingWork.order = slices.DeleteFunc(pendingWork.order, func(h string) bool { return h == powHash })
+	pendingWork.Unlock()
+
+	select {
+	case miningBlockChan <- &block:
+		log.Info("block submitted by external miner", "height", block.Height, "hash", fmt.Sprintf("%x", block.Hash))
+		return true, nil
+	case <-time.After(submitWorkTimeout):
+		return false, fmt.Errorf("node is not accepting mined blocks")
+	}
+}
@@ -0,0 +1,166 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Rules는 특정 높이에서 적용되는 난이도 조정 규칙입니다.
+type Rules struct {
+	DifficultyChangeCycle int64   `json:"difficultyChangeCycle"`
+	ResourceInterval      int64   `json:"resourceInterval"` // 초 단위
+	MaxDifficultyWeight   float64 `json:"maxDifficultyWeight"`
+	MinDifficultyWeight   float64 `json:"minDifficultyWeight"`
+}
+
+// Fork는 Height부터 적용되는 규칙 변경입니다.
+// 0으로 남겨둔 값은 이전 규칙을 그대로 이어받습니다.
+type Fork struct {
+	Name   string `json:"name"`
+	Height int64  `json:"height"`
+	Rules
+}
+
+// ChainParams는 genesis 시점에 데이터베이스에 저장되는 합의 파라미터입니다.
+// 노드별 설정(config.Config)과 달리 같은 체인의 모든 노드가 동일해야 합니다.
+type ChainParams struct {
+	Name              string   `json:"name"`
+	InitialDifficulty *big.Int `json:"initialDifficulty"`
+	Rules
+	Forks []Fork `json:"forks,omitempty"`
+}
+
+var (
+	Mainnet = ChainParams{
+		Name:              "mainnet",
+		InitialDifficulty: big.NewInt(500000),
+		Rules: Rules{
+			DifficultyChangeCycle: 20,
+			ResourceInterval:      20,
+			MaxDifficultyWeight:   4.0,
+			MinDifficultyWeight:   0.25,
+		},
+	}
+
+	Testnet = ChainParams{
+		Name:              "testnet",
+		InitialDifficulty: big.NewInt(100000),
+		Rules: Rules{
+			DifficultyChangeCycle: 20,
+			ResourceInterval:      20,
+			MaxDifficultyWeight:   4.0,
+			MinDifficultyWeight:   0.25,
+		},
+	}
+
+	Devnet = ChainParams{
+		Name:              "devnet",
+		InitialDifficulty: big.NewInt(1000),
+		Rules: Rules{
+			DifficultyChangeCycle: 10,
+			ResourceInterval:      5,
+			MaxDifficultyWeight:   4.0,
+			MinDifficultyWeight:   0.25,
+		},
+	}
+)
+
+var presets = map[string]*ChainParams{
+	Mainnet.Name: &Mainnet,
+	Testnet.Name: &Testnet,
+	Devnet.Name:  &Devnet,
+}
+
+// Preset은 이름에 해당하는 파라미터의 복사본을 반환합니다.
+func Preset(name string) (*ChainParams, error) {
+	p, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network preset: %q", name)
+	}
+	return p.Copy(), nil
+}
+
+func (p *ChainParams) Copy() *ChainParams {
+	cp := *p
+	if p.InitialDifficulty != nil {
+		cp.InitialDifficulty = new(big.Int).Set(p.InitialDifficulty)
+	}
+	cp.Forks = append([]Fork(nil), p.Forks...)
+	return &cp
+}
+
+// Validate는 파라미터 값과 포크 높이 순서를 확인합니다. 데이터베이스나 파일에서 읽은 파라미터는 사용하기 전에 검사합니다.
+func (p *ChainParams) Validate() error {
+	if p.InitialDifficulty == nil || p.InitialDifficulty.Cmp(big.NewInt(1)) < 0 {
+		return fmt.Errorf("invalid initialDifficulty: %v", p.InitialDifficulty)
+	}
+	if err := p.Rules.validate(); err != nil {
+		return err
+	}
+
+	last := int64(0)
+	for _, f := range p.Forks {
+		if f.Height <= last {
+			return fmt.Errorf("fork %q: heights must be positive and increasing", f.Name)
+		}
+		last = f.Height
+	}
+	for _, f := range p.Forks {
+		if err := p.RulesAt(f.Height).validate(); err != nil {
+			return fmt.Errorf("fork %q: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r Rules) validate() error {
+	if r.DifficultyChangeCycle <= 0 || r.ResourceInterval <= 0 {
+		return fmt.Errorf("difficultyChangeCycle and resourceInterval must be positive")
+	}
+	if r.MinDifficultyWeight <= 0 || r.MaxDifficultyWeight < r.MinDifficultyWeight {
+		return fmt.Errorf("invalid difficulty weights %v-%v", r.MinDifficultyWeight, r.MaxDifficultyWeight)
+	}
+	return nil
+}
+
+// RulesAt은 height에서 적용되는 규칙을 반환합니다.
+// Forks는 Validate가 확인한 대로 높이 순서여야 하며, 파라미터를 바꾸지 않고 앞에서부터 훑기만 합니다.
+func (p *ChainParams) RulesAt(height int64) Rules {
+	rules := p.Rules
+
+	for _, f := range p.Forks {
+		if height < f.Height {
+			break
+		}
+		if f.DifficultyChangeCycle != 0 {
+			rules.DifficultyChangeCycle = f.DifficultyChangeCycle
+		}
+		if f.ResourceInterval != 0 {
+			rules.ResourceInterval = f.ResourceInterval
+		}
+		if f.MaxDifficultyWeight != 0 {
+			rules.MaxDifficultyWeight = f.MaxDifficultyWeight
+		}
+		if f.MinDifficultyWeight != 0 {
+			rules.MinDifficultyWeight = f.MinDifficultyWeight
+		}
+	}
+	return rules
+}
+
+// Equal은 두 파라미터가 합의상 동일한지 비교합니다. 이름은 비교하지 않습니다.
+func (p *ChainParams) Equal(other *ChainParams) bool {
+	a, b := *p, *other
+	a.Name, b.Name = "", ""
+
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
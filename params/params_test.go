@@ -0,0 +1,97 @@
+package params
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestPresetReturnsCopy(t *testing.T) {
+	p, err := Preset(Devnet.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.InitialDifficulty.SetInt64(1)
+	p.ResourceInterval = 99
+	if Devnet.InitialDifficulty.Int64() != 1000 || Devnet.ResourceInterval != 5 {
+		t.Fatal("changing a preset copy changed the preset")
+	}
+	if _, err := Preset("unknown"); err == nil {
+		t.Fatal("unknown preset accepted")
+	}
+}
+
+func TestRulesAt(t *testing.T) {
+	p := Devnet.Copy()
+	p.Forks = []Fork{
+		{Name: "a", Height: 100, Rules: Rules{DifficultyChangeCycle: 20, ResourceInterval: 10}},
+		{Name: "b", Height: 200, Rules: Rules{MaxDifficultyWeight: 2}},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		height int64
+		want   Rules
+	}{
+		{0, Devnet.Rules},
+		{99, Devnet.Rules},
+		{100, Rules{DifficultyChangeCycle: 20, ResourceInterval: 10, MaxDifficultyWeight: 4, MinDifficultyWeight: 0.25}},
+		{200, Rules{DifficultyChangeCycle: 20, ResourceInterval: 10, MaxDifficultyWeight: 2, MinDifficultyWeight: 0.25}},
+		{1000, Rules{DifficultyChangeCycle: 20, ResourceInterval: 10, MaxDifficultyWeight: 2, MinDifficultyWeight: 0.25}},
+	}
+	for _, tt := range tests {
+		if got := p.RulesAt(tt.height); got != tt.want {
+			t.Errorf("RulesAt(%d) = %+v, want %+v", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{Mainnet.Name, Testnet.Name, Devnet.Name} {
+		p, _ := Preset(name)
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		edit func(*ChainParams)
+		want string
+	}{
+		{"difficulty", func(p *ChainParams) { p.InitialDifficulty = big.NewInt(0) }, "invalid initialDifficulty"},
+		{"cycle", func(p *ChainParams) { p.DifficultyChangeCycle = 0 }, "must be positive"},
+		{"weights", func(p *ChainParams) { p.MaxDifficultyWeight = 0.1 }, "invalid difficulty weights"},
+		{"fork order", func(p *ChainParams) {
+			p.Forks = []Fork{{Name: "a", Height: 20}, {Name: "b", Height: 10}}
+		}, "increasing"},
+		{"fork height", func(p *ChainParams) { p.Forks = []Fork{{Name: "a", Height: 0}} }, "positive"},
+		{"fork rules", func(p *ChainParams) {
+			p.Forks = []Fork{{Name: "a", Height: 10, Rules: Rules{MinDifficultyWeight: 8}}}
+		}, `fork "a"`},
+	}
+	for _, tt := range tests {
+		p := Devnet.Copy()
+		tt.edit(p)
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, b := Devnet.Copy(), Devnet.Copy()
+	b.Name = "renamed"
+	if !a.Equal(b) {
+		t.Fatal("params differing only in name are not equal")
+	}
+	b.Forks = []Fork{{Name: "a", Height: 10, Rules: Rules{ResourceInterval: 7}}}
+	if a.Equal(b) {
+		t.Fatal("params with different forks are equal")
+	}
+	if Mainnet.Equal(&Testnet) {
+		t.Fatal("mainnet equals testnet")
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LevelDB는 goleveldb 백엔드입니다.
+type LevelDB struct {
+	db *leveldb.DB
+}
+
+func OpenLevelDB(path string) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDB{db: db}, nil
+}
+
+// DB는 지표 수집용으로 내부 LevelDB를 반환합니다.
+func (s *LevelDB) DB() *leveldb.DB {
+	return s.db
+}
+
+func (s *LevelDB) Get(key []byte) ([]byte, error) {
+	value, err := s.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *LevelDB) Has(key []byte) (bool, error) {
+	return s.db.Has(key, nil)
+}
+
+func (s *LevelDB) Put(key, value []byte) error {
+	return s.db.Put(key, value, nil)
+}
+
+func (s *LevelDB) Delete(key []byte) error {
+	return s.db.Delete(key, nil)
+}
+
+func (s *LevelDB) NewIterator(prefix []byte) Iterator {
+	return s.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *LevelDB) NewBatch() Batch {
+	return &levelDBBatch{db: s.db, b: new(leveldb.Batch)}
+}
+
+func (s *LevelDB) NewSnapshot() (Snapshot, error) {
+	snap, err := s.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBSnapshot{snap: snap}, nil
+}
+
+func (s *LevelDB) Close() error {
+	return s.db.Close()
+}
+
+type levelDBBatch struct {
+	db *leveldb.DB
+	b  *leveldb.Batch
+}
+
+func (b *levelDBBatch) Put(key, value []byte) { b.b.Put(key, value) }
+func (b *levelDBBatch) Delete(key []byte)     { b.b.Delete(key) }
+func (b *levelDBBatch) Len() int              { return b.b.Len() }
+func (b *levelDBBatch) Write() error          { return b.db.Write(b.b, nil) }
+func (b *levelDBBatch) Reset()                { b.b.Reset() }
+
+type levelDBSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *levelDBSnapshot) Get(key []byte) ([]byte, error) {
+	value, err := s.snap.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *levelDBSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *levelDBSnapshot) NewIterator(prefix []byte) Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *levelDBSnapshot) Release() {
+	s.snap.Release()
+}
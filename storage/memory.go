@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory는 디스크를 쓰지 않는 백엔드입니다. 프로세스가 끝나면 데이터가 사라집니다.
+type Memory struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{data: map[string][]byte{}}
+}
+
+// 같은 프로세스에서 같은 경로로 다시 열면 같은 저장소를 사용
+var memoryStores = struct {
+	sync.Mutex
+	m map[string]*Memory
+}{m: map[string]*Memory{}}
+
+func openSharedMemory(path string) *Memory {
+	memoryStores.Lock()
+	defer memoryStores.Unlock()
+	if s, ok := memoryStores.m[path]; ok {
+		return s
+	}
+	s := NewMemory()
+	memoryStores.m[path] = s
+	return s
+}
+
+func memoryExists(path string) bool {
+	memoryStores.Lock()
+	defer memoryStores.Unlock()
+	_, ok := memoryStores.m[path]
+	return ok
+}
+
+func (s *Memory) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(value), nil
+}
+
+func (s *Memory) Has(key []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.data[string(key)]
+	return ok, nil
+}
+
+func (s *Memory) Put(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (s *Memory) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, string(key))
+	return nil
+}
+
+// NewIterator는 호출 시점의 데이터를 복사해 순회합니다.
+func (s *Memory) NewIterator(prefix []byte) Iterator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newMemoryIterator(s.data, prefix)
+}
+
+func (s *Memory) NewBatch() Batch {
+	return &memoryBatch{store: s}
+}
+
+func (s *Memory) NewSnapshot() (Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap := NewMemory()
+	for key, value := range s.data {
+		snap.data[key] = value
+	}
+	return &memorySnapshot{snap}, nil
+}
+
+func (s *Memory) Close() error {
+	return nil
+}
+
+type memoryOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+type memoryBatch struct {
+	store *Memory
+	ops   []memoryOp
+}
+
+func (b *memoryBatch) Put(key, value []byte) {
+	b.ops = append(b.ops, memoryOp{key: string(key), value: bytes.Clone(value)})
+}
+
+func (b *memoryBatch) Delete(key []byte) {
+	b.ops = append(b.ops, memoryOp{key: string(key), delete: true})
+}
+
+func (b *memoryBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *memoryBatch) Write() error {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	for _, op := range b.ops {
+		if op.delete {
+			delete(b.store.data, op.key)
+		} else {
+			b.store.data[op.key] = op.value
+		}
+	}
+	return nil
+}
+
+func (b *memoryBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+type memoryIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func newMemoryIterator(data map[string][]byte, prefix []byte) *memoryIterator {
+	it := &memoryIterator{pos: -1}
+	for key := range data {
+		if strings.HasPrefix(key, string(prefix)) {
+			it.keys = append(it.keys, key)
+		}
+	}
+	sort.Strings(it.keys)
+	for _, key := range it.keys {
+		it.values = append(it.values, data[key])
+	}
+	return it
+}
+
+func (it *memoryIterator) Next() bool {
+	if it.pos >= len(it.keys) {
+		return false
+	}
+	it.pos++
+	return it.pos < len(it.keys)
+}
+
+func (it *memoryIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.pos])
+}
+
+func (it *memoryIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *memoryIterator) Error() error {
+	return nil
+}
+
+func (it *memoryIterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+type memorySnapshot struct {
+	*Memory
+}
+
+func (s *memorySnapshot) Release() {}
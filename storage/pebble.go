@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// LevelDB 백엔드와 같이 쓰기마다 fsync하지 않음. WAL에는 기록되므로 배치는 원자적으로 복구됨
+var pebbleWriteOptions = pebble.NoSync
+
+// Pebble은 cockroachdb/pebble 백엔드입니다.
+type Pebble struct {
+	db *pebble.DB
+}
+
+func OpenPebble(path string) (*Pebble, error) {
+	db, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &Pebble{db: db}, nil
+}
+
+func (s *Pebble) Get(key []byte) ([]byte, error) {
+	return pebbleGet(s.db.Get(key))
+}
+
+func (s *Pebble) Has(key []byte) (bool, error) {
+	return pebbleHas(s.Get(key))
+}
+
+func (s *Pebble) Put(key, value []byte) error {
+	return s.db.Set(key, value, pebbleWriteOptions)
+}
+
+func (s *Pebble) Delete(key []byte) error {
+	return s.db.Delete(key, pebbleWriteOptions)
+}
+
+func (s *Pebble) NewIterator(prefix []byte) Iterator {
+	iter, err := s.db.NewIter(pebbleIterOptions(prefix))
+	return &pebbleIterator{iter: iter, err: err}
+}
+
+func (s *Pebble) NewBatch() Batch {
+	return &pebbleBatch{b: s.db.NewBatch()}
+}
+
+func (s *Pebble) NewSnapshot() (Snapshot, error) {
+	return &pebbleSnapshot{snap: s.db.NewSnapshot()}, nil
+}
+
+func (s *Pebble) Close() error {
+	return s.db.Close()
+}
+
+// pebbleGet은 Get 결과를 복사해 closer를 닫습니다.
+func pebbleGet(value []byte, closer interface{ Close() error }, err error) ([]byte, error) {
+	if err == pebble.ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return bytes.Clone(value), nil
+}
+
+func pebbleHas(_ []byte, err error) (bool, error) {
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func pebbleIterOptions(prefix []byte) *pebble.IterOptions {
+	if len(prefix) == 0 {
+		return nil
+	}
+	return &pebble.IterOptions{LowerBound: prefix, UpperBound: upperBound(prefix)}
+}
+
+type pebbleBatch struct {
+	b *pebble.Batch
+}
+
+func (b *pebbleBatch) Put(key, value []byte) { b.b.Set(key, value, nil) }
+func (b *pebbleBatch) Delete(key []byte)     { b.b.Delete(key, nil) }
+func (b *pebbleBatch) Len() int              { return int(b.b.Count()) }
+func (b *pebbleBatch) Write() error          { return b.b.Commit(pebbleWriteOptions) }
+func (b *pebbleBatch) Reset()                { b.b.Reset() }
+
+// pebbleIterator는 Next를 처음 호출할 때 첫 키로 이동합니다.
+type pebbleIterator struct {
+	iter    *pebble.Iterator
+	started bool
+	err     error
+}
+
+func (it *pebbleIterator) Next() bool {
+	if it.iter == nil {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		return it.iter.First()
+	}
+	return it.iter.Next()
+}
+
+func (it *pebbleIterator) Key() []byte {
+	return it.iter.Key()
+}
+
+func (it *pebbleIterator) Value() []byte {
+	return it.iter.Value()
+}
+
+func (it *pebbleIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.iter.Error()
+}
+
+func (it *pebbleIterator) Release() {
+	if it.iter != nil {
+		it.iter.Close()
+	}
+}
+
+type pebbleSnapshot struct {
+	snap *pebble.Snapshot
+}
+
+func (s *pebbleSnapshot) Get(key []byte) ([]byte, error) {
+	return pebbleGet(s.snap.Get(key))
+}
+
+func (s *pebbleSnapshot) Has(key []byte) (bool, error) {
+	return pebbleHas(s.Get(key))
+}
+
+func (s *pebbleSnapshot) NewIterator(prefix []byte) Iterator {
+	iter, err := s.snap.NewIter(pebbleIterOptions(prefix))
+	return &pebbleIterator{iter: iter, err: err}
+}
+
+func (s *pebbleSnapshot) Release() {
+	s.snap.Close()
+}
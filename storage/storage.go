@@ -0,0 +1,120 @@
+// storage 패키지는 체인 데이터베이스의 키-값 저장소 인터페이스와 백엔드(LevelDB, Pebble, 메모리)를 제공합니다.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 저장소 백엔드
+const (
+	EngineLevelDB = "leveldb"
+	EnginePebble  = "pebble"
+	EngineMemory  = "memory"
+)
+
+// Engines는 지원하는 백엔드 목록입니다.
+var Engines = []string{EngineLevelDB, EnginePebble, EngineMemory}
+
+// ErrNotFound는 키가 없을 때 Get이 반환합니다.
+var ErrNotFound = errors.New("not found")
+
+// Reader는 읽기 전용 접근입니다. Store와 Snapshot이 구현합니다.
+type Reader interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	// NewIterator는 prefix로 시작하는 키를 오름차순으로 순회합니다. prefix가 nil이면 전체를 순회합니다.
+	NewIterator(prefix []byte) Iterator
+}
+
+// Store는 키-값 저장소입니다. 반환된 값은 호출자가 소유합니다.
+type Store interface {
+	Reader
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// NewBatch는 Write를 호출할 때 원자적으로 기록되는 배치를 만듭니다.
+	NewBatch() Batch
+	// NewSnapshot은 현재 시점의 읽기 전용 뷰를 만듭니다. 사용 후 Release해야 합니다.
+	NewSnapshot() (Snapshot, error)
+	Close() error
+}
+
+// Batch는 여러 쓰기를 모아 한 번에 기록합니다.
+type Batch interface {
+	Put(key, value []byte)
+	Delete(key []byte)
+	// Len은 모인 쓰기 수입니다.
+	Len() int
+	Write() error
+	Reset()
+}
+
+// Iterator는 키 순서대로 순회합니다. Key와 Value는 다음 Next 호출 전까지만 유효합니다.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Snapshot은 만든 시점의 데이터를 읽습니다.
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+// Open은 engine 백엔드로 path의 저장소를 엽니다. 없으면 새로 만듭니다.
+func Open(engine, path string) (Store, error) {
+	if engine != EngineMemory {
+		if detected := detectEngine(path); detected != "" && detected != engine {
+			return nil, fmt.Errorf("database at %s was created with %s, not %s", path, detected, engine)
+		}
+	}
+
+	switch engine {
+	case EngineLevelDB, "":
+		return OpenLevelDB(path)
+	case EnginePebble:
+		return OpenPebble(path)
+	case EngineMemory:
+		return openSharedMemory(path), nil
+	default:
+		return nil, fmt.Errorf("unknown storage engine %q (expected one of %v)", engine, Engines)
+	}
+}
+
+// Exists는 path에 engine 백엔드의 저장소가 있는지 확인합니다.
+func Exists(engine, path string) bool {
+	if engine == EngineMemory {
+		return memoryExists(path)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// detectEngine은 디렉터리의 파일로 기존 저장소의 백엔드를 추정합니다.
+// Pebble은 OPTIONS 파일을 남기고 LevelDB는 남기지 않습니다.
+func detectEngine(path string) string {
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
+		return ""
+	}
+	if matches, _ := filepath.Glob(filepath.Join(path, "OPTIONS-*")); len(matches) > 0 {
+		return EnginePebble
+	}
+	return EngineLevelDB
+}
+
+// upperBound는 prefix로 시작하는 모든 키보다 큰 가장 작은 키를 반환합니다. 없으면 nil입니다.
+func upperBound(prefix []byte) []byte {
+	limit := append([]byte{}, prefix...)
+	for i := len(limit) - 1; i >= 0; i-- {
+		limit[i]++
+		if limit[i] != 0 {
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
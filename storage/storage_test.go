@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// backends는 테스트할 백엔드별로 새 저장소를 여는 함수입니다.
+var backends = map[string]func(t *testing.T) Store{
+	EngineMemory: func(t *testing.T) Store {
+		return NewMemory()
+	},
+	EngineLevelDB: func(t *testing.T) Store {
+		db, err := OpenLevelDB(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	},
+	EnginePebble: func(t *testing.T) Store {
+		db, err := OpenPebble(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	},
+}
+
+// forEachBackend는 모든 백엔드에서 test를 실행합니다.
+func forEachBackend(t *testing.T, test func(t *testing.T, db Store)) {
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			db := open(t)
+			defer db.Close()
+			test(t, db)
+		})
+	}
+}
+
+func mustGet(t *testing.T, db Reader, key string) []byte {
+	t.Helper()
+	value, err := db.Get([]byte(key))
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return value
+}
+
+func collect(t *testing.T, iter Iterator) []string {
+	t.Helper()
+	defer iter.Release()
+	var kvs []string
+	for iter.Next() {
+		kvs = append(kvs, fmt.Sprintf("%s=%s", iter.Key(), iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return kvs
+}
+
+func TestGetHasPutDelete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db Store) {
+		if _, err := db.Get([]byte("a")); err != ErrNotFound {
+			t.Fatalf("Get on empty store: got %v, want ErrNotFound", err)
+		}
+		if ok, err := db.Has([]byte("a")); err != nil || ok {
+			t.Fatalf("Has on empty store: got %v, %v", ok, err)
+		}
+
+		if err := db.Put([]byte("a"), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := db.Has([]byte("a")); err != nil || !ok {
+			t.Fatalf("Has after Put: got %v, %v", ok, err)
+		}
+
+		// 반환된 값은 호출자 소유이므로 고쳐도 저장된 값은 그대로여야 함
+		value := mustGet(t, db, "a")
+		value[0] = 'x'
+		if got := mustGet(t, db, "a"); string(got) != "1" {
+			t.Fatalf("stored value changed through returned slice: %q", got)
+		}
+
+		if err := db.Delete([]byte("a")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Get([]byte("a")); err != ErrNotFound {
+			t.Fatalf("Get after Delete: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestIteratorPrefix(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db Store) {
+		for _, key := range []string{"b2", "a1", "b1", "c1", "b\xff", "\xff\xff"} {
+			if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got := collect(t, db.NewIterator([]byte("b")))
+		want := []string{"b1=vb1", "b2=vb2", "b\xff=vb\xff"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("prefix b: got %q, want %q", got, want)
+		}
+
+		// 모든 바이트가 0xff인 접두사는 상한이 없음
+		if got := collect(t, db.NewIterator([]byte("\xff"))); len(got) != 1 {
+			t.Fatalf("prefix 0xff: got %q", got)
+		}
+		if got := collect(t, db.NewIterator(nil)); len(got) != 6 || got[0] != "a1=va1" {
+			t.Fatalf("full scan: got %q", got)
+		}
+	})
+}
+
+func TestBatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db Store) {
+		if err := db.Put([]byte("old"), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+
+		batch := db.NewBatch()
+		batch.Put([]byte("k1"), []byte("v1"))
+		batch.Put([]byte("k2"), []byte("v2"))
+		batch.Delete([]byte("old"))
+		if batch.Len() != 3 {
+			t.Fatalf("Len: got %d, want 3", batch.Len())
+		}
+		// Write 전에는 보이지 않아야 함
+		if ok, _ := db.Has([]byte("k1")); ok {
+			t.Fatal("batch write visible before Write")
+		}
+		if err := batch.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if got := mustGet(t, db, "k2"); string(got) != "v2" {
+			t.Fatalf("k2: got %q", got)
+		}
+		if ok, _ := db.Has([]byte("old")); ok {
+			t.Fatal("deleted key still present after Write")
+		}
+
+		batch.Reset()
+		if batch.Len() != 0 {
+			t.Fatalf("Len after Reset: got %d", batch.Len())
+		}
+		batch.Put([]byte("k3"), []byte("v3"))
+		if err := batch.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if got := collect(t, db.NewIterator([]byte("k"))); len(got) != 3 {
+			t.Fatalf("keys after second Write: got %q", got)
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, db Store) {
+		db.Put([]byte("a"), []byte("1"))
+		db.Put([]byte("b"), []byte("1"))
+
+		snap, err := db.NewSnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer snap.Release()
+
+		db.Put([]byte("a"), []byte("2"))
+		db.Delete([]byte("b"))
+		db.Put([]byte("c"), []byte("1"))
+
+		if got := mustGet(t, snap, "a"); string(got) != "1" {
+			t.Fatalf("snapshot sees later write: a=%q", got)
+		}
+		if ok, err := snap.Has([]byte("b")); err != nil || !ok {
+			t.Fatalf("snapshot lost deleted key: %v, %v", ok, err)
+		}
+		if _, err := snap.Get([]byte("c")); err != ErrNotFound {
+			t.Fatalf("snapshot sees later key: %v", err)
+		}
+		if got := collect(t, snap.NewIterator(nil)); fmt.Sprint(got) != fmt.Sprint([]string{"a=1", "b=1"}) {
+			t.Fatalf("snapshot iterator: got %q", got)
+		}
+		if got := mustGet(t, db, "a"); !bytes.Equal(got, []byte("2")) {
+			t.Fatalf("store: a=%q", got)
+		}
+	})
+}
+
+func TestOpenRejectsOtherEngine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := Open(EnginePebble, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := Open(EngineLevelDB, path); err == nil {
+		t.Fatal("opened a pebble database with leveldb")
+	}
+	db, err = Open(EnginePebble, path)
+	if err != nil {
+		t.Fatalf("reopen with the same engine: %v", err)
+	}
+	db.Close()
+}
+
+func TestSharedMemory(t *testing.T) {
+	path := t.Name()
+	if Exists(EngineMemory, path) {
+		t.Fatal("memory store exists before Open")
+	}
+	db, err := Open(EngineMemory, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put([]byte("k"), []byte("v"))
+
+	// 같은 경로로 다시 열면 같은 저장소
+	again, err := Open(EngineMemory, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustGet(t, again, "k"); string(got) != "v" {
+		t.Fatalf("reopened memory store: k=%q", got)
+	}
+	if !Exists(EngineMemory, path) {
+		t.Fatal("memory store does not exist after Open")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !unix
+
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// FileLock은 프로세스 간 배타적 파일 잠금입니다.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile은 path에 잠금 파일을 배타적으로 생성합니다.
+// flock이 없는 플랫폼에서는 비정상 종료 후 남은 잠금 파일을 직접 지워야 합니다.
+func LockFile(path string) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		owner, _ := os.ReadFile(path)
+		return nil, fmt.Errorf("%s is locked by another process (pid %s)", path, owner)
+	}
+
+	fmt.Fprintf(file, "%d", os.Getpid())
+	return &FileLock{file: file}, nil
+}
+
+// Release는 잠금 파일을 닫고 삭제합니다.
+func (l *FileLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	path := l.file.Name()
+	err := l.file.Close()
+	l.file = nil
+	os.Remove(path)
+	return err
+}
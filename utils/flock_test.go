@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.lock")
+	lock, err := LockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := os.ReadFile(path); string(owner) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("lock file has %q, want the pid", owner)
+	}
+
+	// 잠금을 가진 동안에는 다시 잠글 수 없고 오류에 잠근 프로세스가 나옴
+	if _, err := LockFile(path); err == nil || !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Fatalf("second lock: %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	again.Release()
+	// 두 번 해제해도 됨
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// FileLock은 프로세스 간 배타적 파일 잠금입니다.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile은 path에 잠금 파일을 만들고 배타적 잠금을 겁니다.
+// 다른 프로세스가 이미 잠금을 갖고 있으면 바로 오류를 반환합니다.
+func LockFile(path string) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		owner, _ := os.ReadFile(path)
+		file.Close()
+		return nil, fmt.Errorf("%s is locked by another process (pid %s)", path, owner)
+	}
+
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d", os.Getpid())
+	return &FileLock{file: file}, nil
+}
+
+// Release는 잠금을 해제합니다.
+// 다른 프로세스가 같은 파일을 열어 둔 상태일 수 있으므로 잠금 파일은 지우지 않습니다.
+func (l *FileLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
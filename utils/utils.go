@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 )
 
 // ToJSONString은 BlockInfo 구조체를 JSON 문자열로 변환하는 함수입니다.
@@ -17,8 +16,7 @@ func ToJSONString(v interface{}) (string, error) {
 
 	jsonBytes, err := json.Marshal(v)
 	if err != nil {
-		log.Printf("Failed to marshal JSON for value: %v, error: %v", v, err)
-		return "", err
+		return "", fmt.Errorf("failed to marshal JSON for value %v: %v", v, err)
 	}
 	return string(jsonBytes), nil
 }